@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/audit"
+	"github.com/kkcaz/shu-dades-server/internal/broadcast"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/encryption"
+	"github.com/kkcaz/shu-dades-server/internal/notification"
+	"github.com/kkcaz/shu-dades-server/internal/organisation"
+	"github.com/kkcaz/shu-dades-server/internal/product"
+	"github.com/kkcaz/shu-dades-server/internal/store"
+	"github.com/kkcaz/shu-dades-server/internal/user"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Imports a supplier's products from a CSV or JSON file, or exports the
+// catalogue to one, through the same checks, ledger entries, alerts and audit
+// log as the /product/import and /product/export routes. Run it from the
+// repository root while the server is stopped, as the server caches its data.
+func main() {
+	importFile := flag.String("import", "", "CSV or JSON file of products to import")
+	exportFile := flag.String("export", "", "file to export the catalogue to")
+	supplierId := flag.String("supplier", "", "the supplier to import for, or to export, every supplier's products are exported when empty")
+	format := flag.String("format", "", "csv or json, taken from the file extension when empty")
+	dryRun := flag.Bool("dry-run", false, "check the import and report what would change without changing anything")
+	flag.Parse()
+
+	if (*importFile == "") == (*exportFile == "") {
+		log.Fatal("exactly one of -import or -export must be given")
+	}
+	if *importFile != "" && *supplierId == "" {
+		log.Fatal("-supplier must be given to import")
+	}
+
+	path := *importFile + *exportFile
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		log.Fatalf("failed to read config: %v", err)
+	}
+
+	logger := *slog.Default()
+	dataStore, err := store.NewStore(cfg.Storage, logger)
+	if err != nil {
+		log.Fatalf("failed to open storage: %v", err)
+	}
+	defer dataStore.Close()
+
+	organisationUseCase := organisation.NewOrganisationUseCase(organisation.NewOrganisationRepository(logger), user.NewUserRepository(logger), logger)
+	broadcastUseCase := broadcast.NewBroadcastUseCase(logger, encryption.NewEncryptionUseCase(logger))
+	// Product alerts are sent to known users, so notifications don't need auth.
+	notificationUseCase := notification.NewNotificationUseCase(dataStore.Notifications(), nil, broadcastUseCase, logger)
+	productUseCase := product.NewProductUseCase(cfg.Product, dataStore, notificationUseCase, organisationUseCase, logger)
+
+	if *exportFile != "" {
+		data, err := productUseCase.ExportProducts(models.ExportProductsRequest{
+			Format:     models.CatalogueFormat(*format),
+			SupplierId: *supplierId,
+		})
+		if err != nil {
+			log.Fatalf("failed to export: %v", err)
+		}
+
+		err = os.WriteFile(*exportFile, []byte(data), 0644)
+		if err != nil {
+			log.Fatalf("failed to write %s: %v", *exportFile, err)
+		}
+		fmt.Printf("exported the catalogue to %s\n", *exportFile)
+		return
+	}
+
+	data, err := os.ReadFile(*importFile)
+	if err != nil {
+		log.Fatalf("failed to read %s: %v", *importFile, err)
+	}
+
+	request := models.ImportProductsRequest{
+		Format:     models.CatalogueFormat(*format),
+		Data:       string(data),
+		DryRun:     *dryRun,
+		SupplierId: *supplierId,
+	}
+	report, err := productUseCase.ImportProducts(*supplierId, request)
+	if err != nil {
+		log.Fatalf("failed to import: %v", err)
+	}
+
+	audit.NewAuditUseCase(audit.NewAuditRepository(cfg.Audit, logger), logger).Record(models.AuditEntry{
+		Event:         models.AuditChange,
+		ActorId:       *supplierId,
+		RemoteAddress: "cli",
+		Route:         "/product/import",
+		Method:        models.POST,
+		StatusCode:    200,
+		Summary: map[string]interface{}{
+			"file":       *importFile,
+			"format":     request.Format,
+			"dryRun":     request.DryRun,
+			"supplierId": request.SupplierId,
+		},
+	})
+
+	for _, row := range report.Rows {
+		if row.Action == models.ImportFailed {
+			fmt.Printf("row %d (%s): %s\n", row.Row, row.Sku, row.Error)
+		}
+	}
+
+	verb := "imported"
+	if *dryRun {
+		verb = "would import"
+	}
+	fmt.Printf("%s %s: %d created, %d updated, %d unchanged, %d failed\n", verb, *importFile, report.Created, report.Updated, report.Unchanged, report.Failed)
+	if report.Failed > 0 {
+		dataStore.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/store"
+	"log"
+	"log/slog"
+)
+
+// Copies products, subscriptions, chats and notifications between the JSON files
+// and a bolt database, so data can be moved between storage backends.
+func main() {
+	db := flag.String("db", "internal/data/dades.db", "path to the bolt database")
+	dir := flag.String("dir", "internal/data", "directory holding the JSON files")
+	importJSON := flag.Bool("import", false, "replace the contents of the database with the JSON files")
+	exportJSON := flag.Bool("export", false, "write the contents of the database to the JSON files")
+	flag.Parse()
+
+	if *importJSON == *exportJSON {
+		log.Fatal("exactly one of -import or -export must be given")
+	}
+
+	boltStore, err := store.OpenBoltStore(*db, *slog.Default())
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+	defer boltStore.Close()
+
+	if *importJSON {
+		err = boltStore.ImportJSON(*dir)
+		if err != nil {
+			log.Fatalf("failed to import: %v", err)
+		}
+		fmt.Printf("imported %s into %s\n", *dir, *db)
+		return
+	}
+
+	err = boltStore.ExportJSON(*dir)
+	if err != nil {
+		log.Fatalf("failed to export: %v", err)
+	}
+	fmt.Printf("exported %s to %s\n", *db, *dir)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/auth"
+	"github.com/kkcaz/shu-dades-server/internal/user"
+	"log"
+	"log/slog"
+)
+
+// Hashes every plaintext password in a users file so it can be deployed
+// without waiting for each user to log in.
+func main() {
+	path := flag.String("file", "internal/data/auth/users.json", "path to the users file to hash")
+	flag.Parse()
+
+	users, err := user.NewUserRepositoryFromFile(*path, *slog.Default())
+	if err != nil {
+		log.Fatalf("failed to read users file: %v", err)
+	}
+
+	hashed, err := auth.HashUserPasswords(users)
+	if err != nil {
+		log.Fatalf("failed to hash users file: %v", err)
+	}
+
+	fmt.Printf("hashed %d password(s) in %s\n", hashed, *path)
+}
@@ -0,0 +1,94 @@
+package apikey
+
+import (
+	"encoding/json"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type ApiKeyHandler struct {
+	ApiKeyUseCase domain.ApiKeyUseCase
+}
+
+func NewApiKeyHandler(router *router.RouterUseCase, uc domain.ApiKeyUseCase) {
+	handler := ApiKeyHandler{
+		ApiKeyUseCase: uc,
+	}
+
+	router.AddRoute("/apikey", models.POST, handler.Create)
+	router.AddRoute("/apikey/all", models.GET, handler.List)
+	router.AddRoute("/apikey", models.DELETE, handler.Revoke)
+}
+
+func (a ApiKeyHandler) Create(ctx *router.RouterContext) {
+	var request models.CreateApiKeyRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	key, apiKey, err := a.ApiKeyUseCase.Create(request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.CreateApiKeyResponse{
+		StatusCode: 200,
+		Key:        key,
+		ApiKey:     *apiKey,
+	})
+}
+
+func (a ApiKeyHandler) List(ctx *router.RouterContext) {
+	var request models.ApiKeyListRequest
+	if ctx.Body != "" {
+		err := json.Unmarshal([]byte(ctx.Body), &request)
+		if err != nil {
+			ctx.JSON(500, models.NewInternalServerError())
+			return
+		}
+	}
+
+	apiKeys, err := a.ApiKeyUseCase.List(request.UserId)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.ApiKeyListResponse{
+		StatusCode: 200,
+		ApiKeys:    apiKeys,
+	})
+}
+
+func (a ApiKeyHandler) Revoke(ctx *router.RouterContext) {
+	var request models.RequestById
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	err = a.ApiKeyUseCase.Revoke(request.Id)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "API key revoked"))
+}
+
+func writeError(ctx *router.RouterContext, err error) {
+	switch {
+	case errors.Is(err, ErrApiKeyNotFound):
+		ctx.JSON(404, models.NewErrorResponse(404, "API key not found"))
+	case errors.Is(err, ErrInvalidApiKeyData):
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+	default:
+		ctx.JSON(500, models.NewInternalServerError())
+	}
+}
@@ -0,0 +1,130 @@
+package apikey
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrApiKeyNotFound = errors.New("api key not found")
+
+type apiKeyData struct {
+	ApiKeys []models.ApiKey `json:"apiKeys"`
+}
+
+type apiKeyRepository struct {
+	Logger  slog.Logger
+	path    string
+	mu      sync.RWMutex
+	apiKeys []models.ApiKey
+}
+
+func NewApiKeyRepository(logger slog.Logger) domain.ApiKeyRepository {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	repo, err := newApiKeyRepository(fmt.Sprintf("%s/internal/data/auth/api_keys.json", currentDir), logger)
+	if err != nil {
+		panic(err)
+	}
+
+	return repo
+}
+
+func newApiKeyRepository(path string, logger slog.Logger) (*apiKeyRepository, error) {
+	var data apiKeyData
+	err := storage.ReadJSON(path, &data)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return &apiKeyRepository{
+		Logger:  logger,
+		path:    path,
+		apiKeys: data.ApiKeys,
+	}, nil
+}
+
+func (a *apiKeyRepository) GetAll() ([]models.ApiKey, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return append([]models.ApiKey{}, a.apiKeys...), nil
+}
+
+func (a *apiKeyRepository) GetByHash(hash string) (*models.ApiKey, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, apiKey := range a.apiKeys {
+		if apiKey.Hash == hash {
+			return &apiKey, nil
+		}
+	}
+
+	return nil, ErrApiKeyNotFound
+}
+
+func (a *apiKeyRepository) Create(apiKey models.ApiKey) error {
+	a.Logger.Info("creating api key", "apiKeyId", apiKey.Id, "userId", apiKey.UserId)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	apiKeys := append([]models.ApiKey{}, a.apiKeys...)
+	apiKeys = append(apiKeys, apiKey)
+	return a.save(apiKeys)
+}
+
+func (a *apiKeyRepository) Delete(id string) error {
+	a.Logger.Info("deleting api key", "apiKeyId", id)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	apiKeys := make([]models.ApiKey, 0, len(a.apiKeys))
+	for _, apiKey := range a.apiKeys {
+		if apiKey.Id != id {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+
+	if len(apiKeys) == len(a.apiKeys) {
+		return ErrApiKeyNotFound
+	}
+
+	return a.save(apiKeys)
+}
+
+func (a *apiKeyRepository) SetLastUsed(id string, at time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	apiKeys := append([]models.ApiKey{}, a.apiKeys...)
+	for i := range apiKeys {
+		if apiKeys[i].Id == id {
+			apiKeys[i].LastUsedAt = &at
+			return a.save(apiKeys)
+		}
+	}
+
+	return ErrApiKeyNotFound
+}
+
+// save persists apiKeys and, only once that succeeds, makes them the current set.
+// The caller must hold the write lock.
+func (a *apiKeyRepository) save(apiKeys []models.ApiKey) error {
+	err := storage.WriteJSON(a.path, apiKeyData{ApiKeys: apiKeys})
+	if err != nil {
+		return errors.Wrap(err, "failed to persist api keys")
+	}
+
+	a.apiKeys = apiKeys
+	return nil
+}
@@ -0,0 +1,164 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"time"
+)
+
+const (
+	maxKeyLifetime = 365 * 24 * time.Hour
+	// Last used times are only persisted when they've moved by at least this
+	// much, so busy keys don't rewrite the key file on every request.
+	lastUsedResolution = time.Minute
+)
+
+var (
+	ErrInvalidApiKey     = errors.New("api key is invalid or expired")
+	ErrInvalidApiKeyData = errors.New("invalid api key")
+)
+
+type apiKeyUseCase struct {
+	Logger     slog.Logger
+	Repository domain.ApiKeyRepository
+	Users      domain.UserRepository
+	now        func() time.Time
+}
+
+func NewApiKeyUseCase(repository domain.ApiKeyRepository, users domain.UserRepository, logger slog.Logger) domain.ApiKeyUseCase {
+	return &apiKeyUseCase{
+		Logger:     logger,
+		Repository: repository,
+		Users:      users,
+		now:        time.Now,
+	}
+}
+
+func (a *apiKeyUseCase) Create(request models.CreateApiKeyRequest) (string, *models.ApiKeyInfo, error) {
+	now := a.now()
+	switch {
+	case request.Name == "":
+		return "", nil, errors.Wrap(ErrInvalidApiKeyData, "name is required")
+	case len(request.Scopes) == 0:
+		return "", nil, errors.Wrap(ErrInvalidApiKeyData, "at least one scope is required")
+	case !request.ExpiresAt.After(now):
+		return "", nil, errors.Wrap(ErrInvalidApiKeyData, "expiry must be in the future")
+	case request.ExpiresAt.Sub(now) > maxKeyLifetime:
+		return "", nil, errors.Wrapf(ErrInvalidApiKeyData, "expiry must be within %d days", int(maxKeyLifetime.Hours()/24))
+	}
+
+	for _, scope := range request.Scopes {
+		if !scope.IsValid() {
+			return "", nil, errors.Wrapf(ErrInvalidApiKeyData, "unknown scope %q", scope)
+		}
+	}
+
+	_, err := a.Users.GetById(request.UserId)
+	if err != nil {
+		return "", nil, errors.Wrapf(ErrInvalidApiKeyData, "user %s does not exist", request.UserId)
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	apiKey := models.ApiKey{
+		Id:        uuid.New().String(),
+		UserId:    request.UserId,
+		Name:      request.Name,
+		Hash:      hashKey(key),
+		Scopes:    request.Scopes,
+		CreatedAt: now,
+		ExpiresAt: request.ExpiresAt,
+	}
+
+	err = a.Repository.Create(apiKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	info := apiKey.Info()
+	return key, &info, nil
+}
+
+func (a *apiKeyUseCase) List(userId string) ([]models.ApiKeyInfo, error) {
+	apiKeys, err := a.Repository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]models.ApiKeyInfo, 0)
+	for _, apiKey := range apiKeys {
+		if userId != "" && apiKey.UserId != userId {
+			continue
+		}
+		infos = append(infos, apiKey.Info())
+	}
+	return infos, nil
+}
+
+func (a *apiKeyUseCase) Revoke(id string) error {
+	return a.Repository.Delete(id)
+}
+
+func (a *apiKeyUseCase) Authenticate(key string) (*models.UserClaim, error) {
+	if !models.IsApiKey(key) {
+		return nil, ErrInvalidApiKey
+	}
+
+	apiKey, err := a.Repository.GetByHash(hashKey(key))
+	if err != nil {
+		return nil, ErrInvalidApiKey
+	}
+
+	now := a.now()
+	if !now.Before(apiKey.ExpiresAt) {
+		return nil, ErrInvalidApiKey
+	}
+
+	user, err := a.Users.GetById(apiKey.UserId)
+	if err != nil || user.Disabled {
+		return nil, ErrInvalidApiKey
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedResolution {
+		err = a.Repository.SetLastUsed(apiKey.Id, now)
+		if err != nil {
+			a.Logger.Error("failed to record api key use", "apiKeyId", apiKey.Id, "error", err)
+		}
+	}
+
+	return &models.UserClaim{
+		UserId:    user.Id,
+		Token:     key,
+		Role:      user.Role,
+		ExpiresAt: apiKey.ExpiresAt,
+		ApiKeyId:  apiKey.Id,
+		Scopes:    apiKey.Scopes,
+	}, nil
+}
+
+// generateKey returns a new random API key.
+func generateKey() (string, error) {
+	bytes := make([]byte, 32)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate api key")
+	}
+	return models.ApiKeyPrefix + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashKey returns the value stored in place of key. Keys are long and random so
+// a fast hash is enough.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,202 @@
+package apikey
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestApiKeyUseCase(t *testing.T) (*apiKeyUseCase, *mocks.ApiKeyRepository, *mocks.UserRepository) {
+	repo := mocks.NewApiKeyRepository(t)
+	users := mocks.NewUserRepository(t)
+	testUc := NewApiKeyUseCase(repo, users, *slog.Default()).(*apiKeyUseCase)
+	testUc.now = func() time.Time { return testNow }
+	return testUc, repo, users
+}
+
+func TestApiKeyUseCase_Create(t *testing.T) {
+	testCases := []struct {
+		name        string
+		request     models.CreateApiKeyRequest
+		userErr     error
+		expectedErr error
+	}{
+		{
+			name: "Happy path",
+			request: models.CreateApiKeyRequest{
+				UserId:    "1",
+				Name:      "warehouse sync",
+				Scopes:    []models.ApiKeyScope{models.StockAdjust},
+				ExpiresAt: testNow.Add(24 * time.Hour),
+			},
+		},
+		{
+			name: "Sad path - no scopes",
+			request: models.CreateApiKeyRequest{
+				UserId:    "1",
+				Name:      "warehouse sync",
+				ExpiresAt: testNow.Add(24 * time.Hour),
+			},
+			expectedErr: ErrInvalidApiKeyData,
+		},
+		{
+			name: "Sad path - unknown scope",
+			request: models.CreateApiKeyRequest{
+				UserId:    "1",
+				Name:      "warehouse sync",
+				Scopes:    []models.ApiKeyScope{"users:write"},
+				ExpiresAt: testNow.Add(24 * time.Hour),
+			},
+			expectedErr: ErrInvalidApiKeyData,
+		},
+		{
+			name: "Sad path - already expired",
+			request: models.CreateApiKeyRequest{
+				UserId:    "1",
+				Name:      "warehouse sync",
+				Scopes:    []models.ApiKeyScope{models.ProductsRead},
+				ExpiresAt: testNow,
+			},
+			expectedErr: ErrInvalidApiKeyData,
+		},
+		{
+			name: "Sad path - user not found",
+			request: models.CreateApiKeyRequest{
+				UserId:    "1",
+				Name:      "warehouse sync",
+				Scopes:    []models.ApiKeyScope{models.ProductsRead},
+				ExpiresAt: testNow.Add(24 * time.Hour),
+			},
+			userErr:     assert.AnError,
+			expectedErr: ErrInvalidApiKeyData,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testUc, repo, users := newTestApiKeyUseCase(t)
+			users.On("GetById", "1").Return(&models.User{Id: "1"}, tc.userErr).Maybe()
+
+			var stored models.ApiKey
+			repo.On("Create", mock.AnythingOfType("models.ApiKey")).Run(func(args mock.Arguments) {
+				stored = args.Get(0).(models.ApiKey)
+			}).Return(nil).Maybe()
+
+			key, info, err := testUc.Create(tc.request)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, models.IsApiKey(key))
+			assert.Equal(t, hashKey(key), stored.Hash)
+			assert.NotContains(t, stored.Hash, key)
+			assert.Equal(t, stored.Id, info.Id)
+			assert.Equal(t, tc.request.Scopes, info.Scopes)
+		})
+	}
+}
+
+func TestApiKeyUseCase_Authenticate(t *testing.T) {
+	recentlyUsed := testNow.Add(-time.Second)
+
+	testCases := []struct {
+		name           string
+		key            string
+		apiKey         *models.ApiKey
+		user           *models.User
+		expectLastUsed bool
+		expectedErr    error
+	}{
+		{
+			name: "Happy path",
+			key:  "dk_valid",
+			apiKey: &models.ApiKey{
+				Id:        "key",
+				UserId:    "1",
+				Scopes:    []models.ApiKeyScope{models.ProductsRead},
+				ExpiresAt: testNow.Add(time.Hour),
+			},
+			user:           &models.User{Id: "1", Role: models.Supplier},
+			expectLastUsed: true,
+		},
+		{
+			name: "Happy path - recently used",
+			key:  "dk_valid",
+			apiKey: &models.ApiKey{
+				Id:         "key",
+				UserId:     "1",
+				Scopes:     []models.ApiKeyScope{models.ProductsRead},
+				ExpiresAt:  testNow.Add(time.Hour),
+				LastUsedAt: &recentlyUsed,
+			},
+			user: &models.User{Id: "1", Role: models.Supplier},
+		},
+		{
+			name:        "Sad path - unknown key",
+			key:         "dk_unknown",
+			expectedErr: ErrInvalidApiKey,
+		},
+		{
+			name:        "Sad path - not an api key",
+			key:         "session-token",
+			expectedErr: ErrInvalidApiKey,
+		},
+		{
+			name: "Sad path - expired",
+			key:  "dk_valid",
+			apiKey: &models.ApiKey{
+				Id:        "key",
+				UserId:    "1",
+				ExpiresAt: testNow,
+			},
+			expectedErr: ErrInvalidApiKey,
+		},
+		{
+			name: "Sad path - user disabled",
+			key:  "dk_valid",
+			apiKey: &models.ApiKey{
+				Id:        "key",
+				UserId:    "1",
+				ExpiresAt: testNow.Add(time.Hour),
+			},
+			user:        &models.User{Id: "1", Disabled: true},
+			expectedErr: ErrInvalidApiKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testUc, repo, users := newTestApiKeyUseCase(t)
+			if tc.apiKey != nil {
+				repo.On("GetByHash", hashKey(tc.key)).Return(tc.apiKey, nil)
+			} else {
+				repo.On("GetByHash", hashKey(tc.key)).Return(nil, ErrApiKeyNotFound).Maybe()
+			}
+			if tc.user != nil {
+				users.On("GetById", tc.user.Id).Return(tc.user, nil)
+			}
+			if tc.expectLastUsed {
+				repo.On("SetLastUsed", tc.apiKey.Id, testNow).Return(nil)
+			}
+
+			claim, err := testUc.Authenticate(tc.key)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.user.Role, claim.Role)
+			assert.Equal(t, tc.apiKey.Id, claim.ApiKeyId)
+			assert.True(t, claim.HasScope(models.ProductsRead))
+		})
+	}
+}
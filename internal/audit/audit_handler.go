@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"encoding/json"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type AuditHandler struct {
+	AuditUseCase domain.AuditUseCase
+}
+
+func NewAuditHandler(router *router.RouterUseCase, uc domain.AuditUseCase) {
+	handler := AuditHandler{
+		AuditUseCase: uc,
+	}
+
+	router.AddRoute("/audit", models.GET, handler.Query)
+}
+
+func (a AuditHandler) Query(ctx *router.RouterContext) {
+	var request models.AuditQueryRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	entries, err := a.AuditUseCase.Query(request)
+	if errors.Is(err, ErrInvalidQuery) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.AuditListResponse{
+		StatusCode: 200,
+		Entries:    entries,
+	})
+}
@@ -0,0 +1,276 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logName = "audit.log"
+	// Rotated logs are named after the time they were rotated so they sort in order.
+	rotatedNameFormat = "audit-20060102T150405.000000000.log"
+	rotatedNameGlob   = "audit-*.log"
+	// How much of a log is read at a time when searching it from the end.
+	reverseChunkSize = 64 * 1024
+)
+
+// auditRepository appends entries to a JSON lines file, one entry per line,
+// rotating it once it grows beyond the configured size.
+type auditRepository struct {
+	Logger      slog.Logger
+	dir         string
+	maxFileSize int64
+	maxFiles    int
+	mu          sync.Mutex
+	now         func() time.Time
+}
+
+func NewAuditRepository(cfg config.Audit, logger slog.Logger) domain.AuditRepository {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	repo, err := newAuditRepository(fmt.Sprintf("%s/internal/data/audit", currentDir), cfg, logger)
+	if err != nil {
+		panic(err)
+	}
+
+	return repo
+}
+
+func newAuditRepository(dir string, cfg config.Audit, logger slog.Logger) (*auditRepository, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create audit directory %s", dir)
+	}
+
+	return &auditRepository{
+		Logger:      logger,
+		dir:         dir,
+		maxFileSize: cfg.MaxFileSize,
+		maxFiles:    cfg.MaxFiles,
+		now:         time.Now,
+	}, nil
+}
+
+func (a *auditRepository) Append(entry models.AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, err := os.OpenFile(filepath.Join(a.dir, logName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open audit log")
+	}
+
+	_, err = file.Write(line)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to write audit log")
+	}
+
+	return a.rotate()
+}
+
+// rotate renames the current log once it has reached the maximum size and
+// deletes the oldest rotated logs beyond the number to keep. The caller must
+// hold the lock.
+func (a *auditRepository) rotate() error {
+	if a.maxFileSize <= 0 {
+		return nil
+	}
+
+	current := filepath.Join(a.dir, logName)
+	info, err := os.Stat(current)
+	if err != nil {
+		return errors.Wrap(err, "failed to stat audit log")
+	}
+	if info.Size() < a.maxFileSize {
+		return nil
+	}
+
+	rotated := filepath.Join(a.dir, a.now().UTC().Format(rotatedNameFormat))
+	err = os.Rename(current, rotated)
+	if err != nil {
+		return errors.Wrap(err, "failed to rotate audit log")
+	}
+
+	if a.maxFiles <= 0 {
+		return nil
+	}
+
+	files, err := a.rotatedFiles()
+	if err != nil {
+		return err
+	}
+
+	for len(files) > a.maxFiles {
+		err = os.Remove(files[0])
+		if err != nil {
+			return errors.Wrap(err, "failed to remove old audit log")
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// rotatedFiles returns the rotated logs, oldest first.
+func (a *auditRepository) rotatedFiles() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(a.dir, rotatedNameGlob))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// Query returns the newest entries matching the request, newest first. Logs
+// are read backwards from the newest, stopping once limit entries are found.
+func (a *auditRepository) Query(request models.AuditQueryRequest) ([]models.AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	files, err := a.rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(files)
+
+	entries, err := a.readMatching(filepath.Join(a.dir, logName), request, make([]models.AuditEntry, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if full(entries, request) {
+			break
+		}
+
+		// Entries in a rotated log are older than when it was rotated.
+		rotatedAt, err := time.Parse(rotatedNameFormat, filepath.Base(file))
+		if err == nil && request.From != nil && rotatedAt.Before(*request.From) {
+			break
+		}
+
+		entries, err = a.readMatching(file, request, entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func full(entries []models.AuditEntry, request models.AuditQueryRequest) bool {
+	return request.Limit > 0 && len(entries) >= request.Limit
+}
+
+// readMatching appends the entries in the log at path that match the request
+// to entries, newest first, until the limit is reached.
+func (a *auditRepository) readMatching(path string, request models.AuditQueryRequest, entries []models.AuditEntry) ([]models.AuditEntry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer file.Close()
+
+	err = reverseLines(file, func(line []byte) bool {
+		var entry models.AuditEntry
+		err := json.Unmarshal(line, &entry)
+		if err != nil {
+			a.Logger.Warn("skipping unreadable audit entry", "file", path, "error", err)
+			return true
+		}
+
+		if matches(entry, request) {
+			entries = append(entries, entry)
+		}
+		return !full(entries, request)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	return entries, nil
+}
+
+// reverseLines calls fn with each line of file from the last to the first,
+// reading it backwards a chunk at a time, until fn returns false. Lines are
+// only valid until fn returns.
+func reverseLines(file *os.File, fn func(line []byte) bool) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	chunk := make([]byte, reverseChunkSize)
+	// The end of a line whose start is in an earlier chunk.
+	var rest []byte
+	for offset := info.Size(); offset > 0; {
+		n := min(int64(len(chunk)), offset)
+		offset -= n
+		_, err = file.ReadAt(chunk[:n], offset)
+		if err != nil {
+			return err
+		}
+
+		data := append(chunk[:n:n], rest...)
+		for {
+			i := bytes.LastIndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			if line := data[i+1:]; len(line) > 0 && !fn(line) {
+				return nil
+			}
+			data = data[:i]
+		}
+		rest = append(rest[:0:0], data...)
+	}
+
+	if len(rest) > 0 {
+		fn(rest)
+	}
+	return nil
+}
+
+func matches(entry models.AuditEntry, request models.AuditQueryRequest) bool {
+	if request.ActorId != "" && entry.ActorId != request.ActorId {
+		return false
+	}
+	if request.Username != "" && !strings.EqualFold(entry.Username, request.Username) {
+		return false
+	}
+	if request.From != nil && entry.Time.Before(*request.From) {
+		return false
+	}
+	if request.To != nil && entry.Time.After(*request.To) {
+		return false
+	}
+	return true
+}
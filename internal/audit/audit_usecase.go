@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+	redacted          = "[redacted]"
+	// Longer request values, such as imported files, are cut short.
+	maxSummaryLength = 256
+)
+
+var ErrInvalidQuery = errors.New("invalid audit query")
+
+// Request fields that are never written to the audit log, compared case-insensitively.
+var sensitiveFields = map[string]bool{
+	"password":        true,
+	"currentpassword": true,
+	"newpassword":     true,
+	"code":            true,
+	"token":           true,
+	"refreshtoken":    true,
+	"challengetoken":  true,
+	"secret":          true,
+	"apikey":          true,
+}
+
+type auditUseCase struct {
+	Logger slog.Logger
+	repo   domain.AuditRepository
+	now    func() time.Time
+}
+
+func NewAuditUseCase(repo domain.AuditRepository, logger slog.Logger) domain.AuditUseCase {
+	return &auditUseCase{
+		Logger: logger,
+		repo:   repo,
+		now:    time.Now,
+	}
+}
+
+// Record appends entry to the audit log. Failures are logged rather than
+// returned so auditing never fails the request being audited.
+func (a *auditUseCase) Record(entry models.AuditEntry) {
+	if entry.Id == "" {
+		entry.Id = uuid.New().String()
+	}
+	if entry.Time.IsZero() {
+		entry.Time = a.now().UTC()
+	}
+
+	err := a.repo.Append(entry)
+	if err != nil {
+		a.Logger.Error("failed to write audit entry", "route", entry.Route, "method", entry.Method, "error", err)
+	}
+}
+
+func (a *auditUseCase) Query(request models.AuditQueryRequest) ([]models.AuditEntry, error) {
+	if request.From != nil && request.To != nil && request.To.Before(*request.From) {
+		return nil, errors.Wrap(ErrInvalidQuery, "to must not be before from")
+	}
+	if request.Limit < 0 || request.Limit > maxQueryLimit {
+		return nil, errors.Wrapf(ErrInvalidQuery, "limit must be between 0 and %d", maxQueryLimit)
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultQueryLimit
+	}
+
+	return a.repo.Query(request)
+}
+
+// RoleGated reports whether a route is restricted to particular roles.
+type RoleGated func(key router.HandlerKey) bool
+
+// NewRouterHook returns a router hook that records authentication requests,
+// requests that were denied and requests to routes that are role gated or
+// change data.
+func NewRouterHook(uc domain.AuditUseCase, roleGated RoleGated) router.Hook {
+	return func(key router.HandlerKey, ctx *router.RouterContext) {
+		event, ok := classify(key, ctx.StatusCode, roleGated(key))
+		if !ok {
+			return
+		}
+
+		entry := models.AuditEntry{
+			Event:         event,
+			RemoteAddress: ctx.Sender,
+			Route:         key.Route,
+			Method:        key.Method,
+			StatusCode:    ctx.StatusCode,
+			Summary:       summarise(ctx.Body),
+		}
+		if ctx.User != nil {
+			entry.ActorId = ctx.User.UserId
+			entry.ApiKeyId = ctx.User.ApiKeyId
+			entry.ImpersonatorId = ctx.User.ImpersonatorId
+		} else if username, ok := entry.Summary["username"].(string); ok {
+			entry.Username = username
+		}
+
+		uc.Record(entry)
+	}
+}
+
+func classify(key router.HandlerKey, statusCode int, roleGated bool) (models.AuditEvent, bool) {
+	switch {
+	case statusCode == 401 || statusCode == 403:
+		return models.AuditDenied, true
+	case key.Method != models.GET && (key.Route == "/auth" || strings.HasPrefix(key.Route, "/auth/")):
+		return models.AuditAuthentication, true
+	case roleGated:
+		return models.AuditPrivileged, true
+	case key.Method == models.GET:
+		return "", false
+	default:
+		return models.AuditChange, true
+	}
+}
+
+// summarise returns the request body with any sensitive fields redacted and
+// long values truncated.
+func summarise(body string) map[string]interface{} {
+	var summary map[string]interface{}
+	err := json.Unmarshal([]byte(body), &summary)
+	if err != nil || len(summary) == 0 {
+		return nil
+	}
+
+	redact(summary)
+	return summary
+}
+
+func redact(values map[string]interface{}) {
+	for field, value := range values {
+		if sensitiveFields[strings.ToLower(field)] {
+			values[field] = redacted
+			continue
+		}
+
+		switch value := value.(type) {
+		case string:
+			if utf8.RuneCountInString(value) > maxSummaryLength {
+				values[field] = string([]rune(value)[:maxSummaryLength]) + "...[truncated]"
+			}
+		case map[string]interface{}:
+			redact(value)
+		case []interface{}:
+			for _, item := range value {
+				if item, ok := item.(map[string]interface{}); ok {
+					redact(item)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package audit
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouterHook(t *testing.T) {
+	testCases := []struct {
+		name       string
+		key        router.HandlerKey
+		body       string
+		statusCode int
+		roleGated  bool
+		user       *models.UserClaim
+		expected   *models.AuditEntry
+	}{
+		{
+			name:       "Happy path - change",
+			key:        router.HandlerKey{Route: "/product", Method: models.DELETE},
+			body:       `{"id":"1"}`,
+			statusCode: 200,
+			user:       &models.UserClaim{UserId: "supplier"},
+			expected: &models.AuditEntry{
+				Event:   models.AuditChange,
+				ActorId: "supplier",
+				Summary: map[string]interface{}{"id": "1"},
+			},
+		},
+		{
+			name:       "Happy path - authentication redacts the password",
+			key:        router.HandlerKey{Route: "/auth", Method: models.POST},
+			body:       `{"username":"john","password":"secret"}`,
+			statusCode: 401,
+			expected: &models.AuditEntry{
+				Event:    models.AuditDenied,
+				Username: "john",
+				Summary:  map[string]interface{}{"username": "john", "password": redacted},
+			},
+		},
+		{
+			name:       "Happy path - login is recorded against the user",
+			key:        router.HandlerKey{Route: "/auth", Method: models.POST},
+			body:       `{"username":"john","password":"secret"}`,
+			statusCode: 200,
+			user:       &models.UserClaim{UserId: "1"},
+			expected: &models.AuditEntry{
+				Event:   models.AuditAuthentication,
+				ActorId: "1",
+				Summary: map[string]interface{}{"username": "john", "password": redacted},
+			},
+		},
+		{
+			name:       "Happy path - lockout keys are kept",
+			key:        router.HandlerKey{Route: "/auth/lockouts", Method: models.DELETE},
+			body:       `{"key":"user:john"}`,
+			statusCode: 200,
+			user:       &models.UserClaim{UserId: "admin"},
+			expected: &models.AuditEntry{
+				Event:   models.AuditAuthentication,
+				ActorId: "admin",
+				Summary: map[string]interface{}{"key": "user:john"},
+			},
+		},
+		{
+			name:       "Happy path - nested fields are redacted",
+			key:        router.HandlerKey{Route: "/auth/password", Method: models.PUT},
+			body:       `{"request":{"newPassword":"secret"}}`,
+			statusCode: 200,
+			user:       &models.UserClaim{UserId: "john"},
+			expected: &models.AuditEntry{
+				Event:   models.AuditAuthentication,
+				ActorId: "john",
+				Summary: map[string]interface{}{"request": map[string]interface{}{"newPassword": redacted}},
+			},
+		},
+		{
+			name:       "Happy path - long values are truncated",
+			key:        router.HandlerKey{Route: "/product/import", Method: models.POST},
+			body:       `{"format":"csv","data":"` + strings.Repeat("a", maxSummaryLength+1) + `"}`,
+			statusCode: 200,
+			user:       &models.UserClaim{UserId: "supplier"},
+			expected: &models.AuditEntry{
+				Event:   models.AuditChange,
+				ActorId: "supplier",
+				Summary: map[string]interface{}{"format": "csv", "data": strings.Repeat("a", maxSummaryLength) + "...[truncated]"},
+			},
+		},
+		{
+			name:       "Happy path - role gated read",
+			key:        router.HandlerKey{Route: "/auth/lockouts", Method: models.GET},
+			body:       `{}`,
+			statusCode: 200,
+			roleGated:  true,
+			user:       &models.UserClaim{UserId: "admin"},
+			expected: &models.AuditEntry{
+				Event:   models.AuditPrivileged,
+				ActorId: "admin",
+			},
+		},
+		{
+			name:       "Happy path - denied read",
+			key:        router.HandlerKey{Route: "/product", Method: models.GET},
+			body:       `{"id":"1"}`,
+			statusCode: 403,
+			user:       &models.UserClaim{UserId: "john", ApiKeyId: "key"},
+			expected: &models.AuditEntry{
+				Event:    models.AuditDenied,
+				ActorId:  "john",
+				ApiKeyId: "key",
+				Summary:  map[string]interface{}{"id": "1"},
+			},
+		},
+		{
+			name:       "Happy path - impersonation",
+			key:        router.HandlerKey{Route: "/chat/message", Method: models.POST},
+			body:       `{"chatId":"1"}`,
+			statusCode: 200,
+			user:       &models.UserClaim{UserId: "john", ImpersonatorId: "admin"},
+			expected: &models.AuditEntry{
+				Event:          models.AuditChange,
+				ActorId:        "john",
+				ImpersonatorId: "admin",
+				Summary:        map[string]interface{}{"chatId": "1"},
+			},
+		},
+		{
+			name:       "Happy path - reads are not recorded",
+			key:        router.HandlerKey{Route: "/product", Method: models.GET},
+			body:       `{"id":"1"}`,
+			statusCode: 200,
+			user:       &models.UserClaim{UserId: "john"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc := mocks.NewAuditUseCase(t)
+			hook := NewRouterHook(uc, func(router.HandlerKey) bool { return tc.roleGated })
+
+			if tc.expected != nil {
+				expected := *tc.expected
+				expected.RemoteAddress = "127.0.0.1:5000"
+				expected.Route = tc.key.Route
+				expected.Method = tc.key.Method
+				expected.StatusCode = tc.statusCode
+				uc.On("Record", expected).Return()
+			}
+
+			hook(tc.key, &router.RouterContext{
+				Body:       tc.body,
+				Sender:     "127.0.0.1:5000",
+				StatusCode: tc.statusCode,
+				User:       tc.user,
+			})
+		})
+	}
+}
+
+func TestAuditUseCase_Query(t *testing.T) {
+	from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+
+	testCases := []struct {
+		name        string
+		request     models.AuditQueryRequest
+		expected    models.AuditQueryRequest
+		expectedErr error
+	}{
+		{
+			name:     "Happy path - default limit",
+			request:  models.AuditQueryRequest{ActorId: "john"},
+			expected: models.AuditQueryRequest{ActorId: "john", Limit: defaultQueryLimit},
+		},
+		{
+			name:        "Sad path - to before from",
+			request:     models.AuditQueryRequest{From: &from, To: &to},
+			expectedErr: ErrInvalidQuery,
+		},
+		{
+			name:        "Sad path - limit too large",
+			request:     models.AuditQueryRequest{Limit: maxQueryLimit + 1},
+			expectedErr: ErrInvalidQuery,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewAuditRepository(t)
+			testUc := NewAuditUseCase(repo, *slog.Default())
+
+			if tc.expectedErr == nil {
+				repo.On("Query", tc.expected).Return([]models.AuditEntry{}, nil)
+			}
+
+			_, err := testUc.Query(tc.request)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestRouterHook_LoginsCanBeFound(t *testing.T) {
+	repo, err := newAuditRepository(t.TempDir(), config.Audit{MaxFileSize: 1 << 20, MaxFiles: 2}, *slog.Default())
+	assert.NoError(t, err)
+	uc := NewAuditUseCase(repo, *slog.Default())
+	hook := NewRouterHook(uc, func(router.HandlerKey) bool { return false })
+	login := router.HandlerKey{Route: "/auth", Method: models.POST}
+
+	hook(login, &router.RouterContext{Body: `{"username":"John","password":"wrong"}`, Sender: "10.0.0.1:5000", StatusCode: 401})
+	hook(login, &router.RouterContext{Body: `{"username":"john","password":"right"}`, Sender: "10.0.0.2:5000", StatusCode: 200, User: &models.UserClaim{UserId: "1"}})
+	hook(login, &router.RouterContext{Body: `{"username":"jane","password":"wrong"}`, Sender: "10.0.0.3:5000", StatusCode: 401})
+
+	entries, err := uc.Query(models.AuditQueryRequest{ActorId: "1"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "10.0.0.2:5000", entries[0].RemoteAddress)
+
+	entries, err = uc.Query(models.AuditQueryRequest{Username: "john"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "10.0.0.1:5000", entries[0].RemoteAddress)
+}
+
+func TestAuditRepository(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := newAuditRepository(dir, config.Audit{MaxFileSize: 1, MaxFiles: 2}, *slog.Default())
+	assert.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	// Every entry fills a file, so each append rotates and only the newest two
+	// rotated logs are kept.
+	for i, actor := range []string{"john", "jane", "john", "jane"} {
+		now = now.Add(time.Minute)
+		err = repo.Append(models.AuditEntry{Id: string(rune('a' + i)), Time: now, ActorId: actor})
+		assert.NoError(t, err)
+	}
+
+	files, err := repo.rotatedFiles()
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	entries, err := repo.Query(models.AuditQueryRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d", "c"}, ids(entries))
+
+	entries, err = repo.Query(models.AuditQueryRequest{ActorId: "john"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, ids(entries))
+
+	from := now
+	entries, err = repo.Query(models.AuditQueryRequest{From: &from})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, ids(entries))
+
+	entries, err = repo.Query(models.AuditQueryRequest{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, ids(entries))
+}
+
+func TestAuditRepository_QueryReadsBackwards(t *testing.T) {
+	repo, err := newAuditRepository(t.TempDir(), config.Audit{MaxFileSize: 100 * 1024, MaxFiles: 5}, *slog.Default())
+	assert.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	// Enough entries to rotate and to span several chunks of each log.
+	count := 3000
+	for i := 0; i < count; i++ {
+		now = now.Add(time.Second)
+		err = repo.Append(models.AuditEntry{Id: fmt.Sprint(i), Time: now, ActorId: "john", Route: strings.Repeat("/", 50)})
+		assert.NoError(t, err)
+	}
+	files, err := repo.rotatedFiles()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+
+	entries, err := repo.Query(models.AuditQueryRequest{Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2999", "2998", "2997"}, ids(entries))
+
+	entries, err = repo.Query(models.AuditQueryRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, count)
+	for i, entry := range entries {
+		assert.Equal(t, fmt.Sprint(count-1-i), entry.Id)
+	}
+}
+
+func ids(entries []models.AuditEntry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.Id)
+	}
+	return ids
+}
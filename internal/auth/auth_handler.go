@@ -3,21 +3,43 @@ package auth
 import (
 	"encoding/json"
 	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
 	"github.com/kkcaz/shu-dades-server/internal/router"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
 )
 
 type AuthHandler struct {
-	AuthUseCase domain.AuthUseCase
+	AuthUseCase   domain.AuthUseCase
+	PasswordReset domain.PasswordResetUseCase
+	LoginGuard    domain.LoginGuard
 }
 
-func NewAuthHandler(router *router.RouterUseCase, uc domain.AuthUseCase) {
+func NewAuthHandler(router *router.RouterUseCase, uc domain.AuthUseCase, passwordReset domain.PasswordResetUseCase, loginGuard domain.LoginGuard) {
 	handler := AuthHandler{
-		AuthUseCase: uc,
+		AuthUseCase:   uc,
+		PasswordReset: passwordReset,
+		LoginGuard:    loginGuard,
 	}
 
 	router.AddRoute("/auth", models.POST, handler.Authenticate)
+	router.AddRoute("/auth/refresh", models.POST, handler.Refresh)
+	router.AddRoute("/auth/logout", models.POST, handler.Logout)
+	router.AddRoute("/auth/revoke", models.POST, handler.RevokeUser)
+	router.AddRoute("/auth/impersonate", models.POST, handler.Impersonate)
 	router.AddRoute("/auth/users", models.GET, handler.GetAllUsers)
+	router.AddRoute("/auth/password", models.PUT, handler.ChangePassword)
+	router.AddRoute("/auth/password/reset", models.POST, handler.RequestPasswordReset)
+	router.AddRoute("/auth/password/reset", models.PUT, handler.CompletePasswordReset)
+	router.AddRoute("/auth/lockouts", models.GET, handler.GetLockouts)
+	router.AddRoute("/auth/lockouts", models.DELETE, handler.ClearLockout)
+	router.AddRoute("/auth/2fa", models.POST, handler.CompleteTwoFactor)
+	router.AddRoute("/auth/2fa", models.DELETE, handler.DisableTwoFactor)
+	router.AddRoute("/auth/2fa/enrol", models.POST, handler.EnrolTwoFactor)
+	router.AddRoute("/auth/2fa/enrol", models.PUT, handler.ConfirmTwoFactor)
+	router.AddRoute("/auth/2fa/reset", models.POST, handler.ResetTwoFactor)
+	router.AddRoute("/auth/sessions", models.GET, handler.GetSessions)
+	router.AddRoute("/auth/sessions", models.DELETE, handler.TerminateSession)
 }
 
 func (a AuthHandler) Authenticate(ctx *router.RouterContext) {
@@ -33,17 +55,146 @@ func (a AuthHandler) Authenticate(ctx *router.RouterContext) {
 		return
 	}
 
+	err = a.LoginGuard.Check(authRequest.Username, ctx.Sender)
+	var throttled *LoginThrottledError
+	if errors.As(err, &throttled) {
+		ctx.JSON(429, models.NewErrorResponse(429, throttled.Error()))
+		return
+	}
+
 	userClaim, err := a.AuthUseCase.Authenticate(authRequest.Username, authRequest.Password)
+	if err != nil {
+		a.LoginGuard.Release(authRequest.Username, ctx.Sender)
+	}
+
+	var twoFactorRequired *TwoFactorRequiredError
+	if errors.As(err, &twoFactorRequired) {
+		ctx.JSON(200, models.AuthResponse{
+			StatusCode:                 200,
+			TwoFactorRequired:          true,
+			TwoFactorEnrolmentRequired: twoFactorRequired.Enrolment,
+			ChallengeToken:             twoFactorRequired.ChallengeToken,
+		})
+		return
+	}
+
+	if errors.Is(err, ErrAccountDisabled) {
+		ctx.JSON(403, models.NewErrorResponse(403, "Account disabled"))
+		return
+	}
+
 	if err != nil {
 		ctx.JSON(500, err)
 		return
 	}
 
 	if userClaim == nil {
+		a.LoginGuard.RecordFailure(authRequest.Username, ctx.Sender)
 		ctx.JSON(401, models.NewErrorResponse(401, "Invalid username or password"))
 		return
 	}
 
+	a.LoginGuard.RecordSuccess(userClaim.UserId, authRequest.Username, ctx.Sender)
+	a.AuthUseCase.TouchSession(userClaim.SessionId, ctx.Sender)
+	ctx.User = userClaim
+
+	ctx.JSON(200, models.AuthResponse{
+		StatusCode: 200,
+		UserClaim:  userClaim,
+	})
+}
+
+func (a AuthHandler) Refresh(ctx *router.RouterContext) {
+	var request models.RefreshRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	if request.RefreshToken == "" {
+		ctx.JSON(400, models.NewErrorResponse(400, "Missing refresh token"))
+		return
+	}
+
+	userClaim, err := a.AuthUseCase.Refresh(request.RefreshToken)
+	if err != nil {
+		ctx.JSON(401, models.NewErrorResponse(401, "Invalid or expired refresh token"))
+		return
+	}
+	a.AuthUseCase.TouchSession(userClaim.SessionId, ctx.Sender)
+	ctx.User = userClaim
+
+	ctx.JSON(200, models.AuthResponse{
+		StatusCode: 200,
+		UserClaim:  userClaim,
+	})
+}
+
+func (a AuthHandler) Logout(ctx *router.RouterContext) {
+	token := ctx.GetAuthToken()
+	if token == nil {
+		ctx.JSON(401, models.NewErrorResponse(401, "Unauthorized"))
+		return
+	}
+
+	err := a.AuthUseCase.Logout(*token)
+	if err != nil {
+		ctx.JSON(401, models.NewErrorResponse(401, "Unauthorized"))
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "Logged out"))
+}
+
+func (a AuthHandler) RevokeUser(ctx *router.RouterContext) {
+	var request models.RevokeUserRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	if request.UserId == "" {
+		ctx.JSON(400, models.NewErrorResponse(400, "Missing user id"))
+		return
+	}
+
+	err = a.AuthUseCase.RevokeUser(request.UserId)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "User sessions revoked"))
+}
+
+func (a AuthHandler) Impersonate(ctx *router.RouterContext) {
+	var request models.ImpersonateRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	if request.UserId == "" {
+		ctx.JSON(400, models.NewErrorResponse(400, "Missing user id"))
+		return
+	}
+
+	userClaim, err := a.AuthUseCase.Impersonate(ctx.User.UserId, request.UserId, request.AllowWrites)
+	switch {
+	case errors.Is(err, ErrCannotImpersonate):
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	case errors.Is(err, ErrAccountDisabled):
+		ctx.JSON(403, models.NewErrorResponse(403, "Account disabled"))
+		return
+	case err != nil:
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
 	ctx.JSON(200, models.AuthResponse{
 		StatusCode: 200,
 		UserClaim:  userClaim,
@@ -51,7 +202,19 @@ func (a AuthHandler) Authenticate(ctx *router.RouterContext) {
 }
 
 func (a AuthHandler) GetAllUsers(ctx *router.RouterContext) {
-	users, err := a.AuthUseCase.GetAllUsersInfo()
+	var request models.UserListRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	users, pageInfo, err := a.AuthUseCase.GetAllUsersInfo(ctx.User.UserId, request)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
@@ -60,5 +223,382 @@ func (a AuthHandler) GetAllUsers(ctx *router.RouterContext) {
 	ctx.JSON(200, models.UserListResponse{
 		StatusCode: 200,
 		Users:      users,
+		PageInfo:   pageInfo,
+	})
+}
+
+func (a AuthHandler) GetSessions(ctx *router.RouterContext) {
+	sessions, err := a.AuthUseCase.GetSessions(ctx.User.UserId, ctx.User.SessionId)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.SessionListResponse{
+		StatusCode: 200,
+		Sessions:   sessions,
 	})
 }
+
+func (a AuthHandler) TerminateSession(ctx *router.RouterContext) {
+	var request models.RequestById
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	err = a.AuthUseCase.TerminateSession(ctx.User.UserId, request.Id)
+	if errors.Is(err, ErrSessionNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Session not found"))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "Session terminated"))
+}
+
+func (a AuthHandler) ChangePassword(ctx *router.RouterContext) {
+	var request models.ChangePasswordRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	// Wrong current passwords count against the account like failed logins, so
+	// a stolen session can't be used to guess it.
+	username, ok := a.checkLoginGuard(ctx, ctx.User.UserId)
+	if !ok {
+		return
+	}
+
+	err = a.AuthUseCase.ChangePassword(ctx.User.UserId, ctx.User.SessionId, request.CurrentPassword, request.NewPassword)
+	switch {
+	case errors.Is(err, ErrIncorrectPassword):
+		a.LoginGuard.RecordFailure(username, ctx.Sender)
+	case err == nil:
+		a.LoginGuard.RecordSuccess(ctx.User.UserId, username, ctx.Sender)
+	default:
+		a.LoginGuard.Release(username, ctx.Sender)
+	}
+
+	switch {
+	case errors.Is(err, ErrIncorrectPassword):
+		ctx.JSON(403, models.NewErrorResponse(403, err.Error()))
+		return
+	case errors.Is(err, ErrWeakPassword):
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	case err != nil:
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "Password changed, your other sessions have been logged out"))
+}
+
+func (a AuthHandler) RequestPasswordReset(ctx *router.RouterContext) {
+	var request models.PasswordResetRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	if request.Username == "" {
+		ctx.JSON(400, models.NewErrorResponse(400, "Missing username"))
+		return
+	}
+
+	// Codes can't be requested for accounts that are locked out.
+	err = a.LoginGuard.Check(request.Username, ctx.Sender)
+	var throttled *LoginThrottledError
+	if errors.As(err, &throttled) {
+		ctx.JSON(429, models.NewErrorResponse(429, throttled.Error()))
+		return
+	}
+	a.LoginGuard.Release(request.Username, ctx.Sender)
+
+	err = a.PasswordReset.RequestReset(request.Username)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "If the account exists a reset code has been sent"))
+}
+
+func (a AuthHandler) CompletePasswordReset(ctx *router.RouterContext) {
+	var request models.CompletePasswordResetRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	// Wrong codes count against the account like failed logins.
+	err = a.LoginGuard.Check(request.Username, ctx.Sender)
+	var throttled *LoginThrottledError
+	if errors.As(err, &throttled) {
+		ctx.JSON(429, models.NewErrorResponse(429, throttled.Error()))
+		return
+	}
+
+	err = a.PasswordReset.Reset(request.Username, request.Code, request.NewPassword)
+	if errors.Is(err, ErrInvalidResetCode) {
+		a.LoginGuard.RecordFailure(request.Username, ctx.Sender)
+	} else {
+		a.LoginGuard.Release(request.Username, ctx.Sender)
+	}
+
+	switch {
+	case errors.Is(err, ErrInvalidResetCode):
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	case errors.Is(err, ErrWeakPassword):
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	case err != nil:
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "Password reset"))
+}
+
+func (a AuthHandler) GetLockouts(ctx *router.RouterContext) {
+	ctx.JSON(200, models.LockoutListResponse{
+		StatusCode: 200,
+		Lockouts:   a.LoginGuard.GetLockouts(),
+	})
+}
+
+func (a AuthHandler) ClearLockout(ctx *router.RouterContext) {
+	var request models.ClearLockoutRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	err = a.LoginGuard.ClearLockout(request.Key)
+	if errors.Is(err, ErrLockoutNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Lockout not found"))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "Lockout cleared"))
+}
+
+func (a AuthHandler) CompleteTwoFactor(ctx *router.RouterContext) {
+	var request models.TwoFactorRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	userId, err := a.AuthUseCase.ChallengeUserId(request.ChallengeToken)
+	if err != nil {
+		writeTwoFactorError(ctx, err)
+		return
+	}
+
+	username, ok := a.checkLoginGuard(ctx, userId)
+	if !ok {
+		return
+	}
+
+	userClaim, err := a.AuthUseCase.CompleteTwoFactor(request.ChallengeToken, request.Code)
+	if err != nil {
+		a.recordTwoFactorAttempt(ctx, username, err)
+		writeTwoFactorError(ctx, err)
+		return
+	}
+
+	user, err := a.AuthUseCase.GetUserById(userClaim.UserId)
+	if err == nil {
+		a.LoginGuard.RecordSuccess(user.Id, user.Username, ctx.Sender)
+	} else {
+		a.LoginGuard.Release(username, ctx.Sender)
+	}
+	a.AuthUseCase.TouchSession(userClaim.SessionId, ctx.Sender)
+	ctx.User = userClaim
+
+	ctx.JSON(200, models.AuthResponse{
+		StatusCode: 200,
+		UserClaim:  userClaim,
+	})
+}
+
+func (a AuthHandler) EnrolTwoFactor(ctx *router.RouterContext) {
+	var request models.TwoFactorEnrolRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	userId, ok := a.twoFactorUserId(ctx, request.ChallengeToken)
+	if !ok {
+		return
+	}
+
+	enrolment, err := a.AuthUseCase.EnrolTwoFactor(userId)
+	if err != nil {
+		writeTwoFactorError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.TwoFactorEnrolmentResponse{
+		StatusCode: 200,
+		Enrolment:  *enrolment,
+	})
+}
+
+func (a AuthHandler) ConfirmTwoFactor(ctx *router.RouterContext) {
+	var request models.ConfirmTwoFactorRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	userId, ok := a.twoFactorUserId(ctx, request.ChallengeToken)
+	if !ok {
+		return
+	}
+
+	username, ok := a.checkLoginGuard(ctx, userId)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := a.AuthUseCase.ConfirmTwoFactor(userId, request.Code)
+	a.recordTwoFactorAttempt(ctx, username, err)
+	if err != nil {
+		writeTwoFactorError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.RecoveryCodesResponse{
+		StatusCode:    200,
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+func (a AuthHandler) DisableTwoFactor(ctx *router.RouterContext) {
+	var request models.DisableTwoFactorRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	username, ok := a.checkLoginGuard(ctx, ctx.User.UserId)
+	if !ok {
+		return
+	}
+
+	err = a.AuthUseCase.DisableTwoFactor(ctx.User.UserId, request.Code)
+	a.recordTwoFactorAttempt(ctx, username, err)
+	if err != nil {
+		writeTwoFactorError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "Two-factor authentication disabled"))
+}
+
+func (a AuthHandler) ResetTwoFactor(ctx *router.RouterContext) {
+	var request models.RequestById
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	err = a.AuthUseCase.ResetTwoFactor(request.Id)
+	if err != nil {
+		writeTwoFactorError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "Two-factor authentication reset"))
+}
+
+// twoFactorUserId returns the user enrolling in two-factor authentication,
+// either from their session or, before their first login, from the challenge
+// token issued by /auth. It writes the response itself when there isn't one.
+func (a AuthHandler) twoFactorUserId(ctx *router.RouterContext, challengeToken string) (string, bool) {
+	if ctx.User != nil {
+		if ctx.User.IsApiKey() {
+			ctx.JSON(403, models.NewErrorResponse(403, "Forbidden"))
+			return "", false
+		}
+		return ctx.User.UserId, true
+	}
+
+	userId, err := a.AuthUseCase.ChallengeUserId(challengeToken)
+	if err != nil {
+		ctx.JSON(401, models.NewErrorResponse(401, "Unauthorized"))
+		return "", false
+	}
+	return userId, true
+}
+
+// checkLoginGuard returns userId's username, which wrong two-factor codes and
+// current passwords count against along with wrong logins, so asking for new
+// challenges or using a stolen session doesn't give unlimited guesses. It
+// writes the response itself when the user or the sender's address is locked
+// out.
+func (a AuthHandler) checkLoginGuard(ctx *router.RouterContext, userId string) (string, bool) {
+	user, err := a.AuthUseCase.GetUserById(userId)
+	if err != nil {
+		ctx.JSON(401, models.NewErrorResponse(401, "Unauthorized"))
+		return "", false
+	}
+
+	err = a.LoginGuard.Check(user.Username, ctx.Sender)
+	var throttled *LoginThrottledError
+	if errors.As(err, &throttled) {
+		ctx.JSON(429, models.NewErrorResponse(429, throttled.Error()))
+		return "", false
+	}
+	return user.Username, true
+}
+
+// recordTwoFactorAttempt finishes the attempt checkLoginGuard reserved,
+// counting it as a failure if the code was wrong.
+func (a AuthHandler) recordTwoFactorAttempt(ctx *router.RouterContext, username string, err error) {
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		a.LoginGuard.RecordFailure(username, ctx.Sender)
+		return
+	}
+	a.LoginGuard.Release(username, ctx.Sender)
+}
+
+func writeTwoFactorError(ctx *router.RouterContext, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidChallenge), errors.Is(err, ErrInvalidTwoFactorCode):
+		ctx.JSON(401, models.NewErrorResponse(401, err.Error()))
+	case errors.Is(err, ErrAccountDisabled):
+		ctx.JSON(403, models.NewErrorResponse(403, "Account disabled"))
+	case errors.Is(err, ErrTwoFactorRequired):
+		ctx.JSON(403, models.NewErrorResponse(403, err.Error()))
+	case errors.Is(err, ErrTwoFactorEnabled), errors.Is(err, ErrTwoFactorNotEnrolled):
+		ctx.JSON(409, models.NewErrorResponse(409, err.Error()))
+	default:
+		ctx.JSON(500, models.NewInternalServerError())
+	}
+}
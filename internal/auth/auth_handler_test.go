@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"encoding/json"
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestAuthHandler_Authenticate_SetsUser(t *testing.T) {
+	users := mocks.NewUserRepository(t)
+	testUc := newTestAuthUseCase(t, users, nil)
+	guard, guardUsers, _, _ := newTestLoginGuard(t)
+	handler := AuthHandler{AuthUseCase: testUc, LoginGuard: guard}
+
+	user := models.User{Id: "1", Username: "customer", Password: "password", Role: models.Customer}
+	users.On("GetByUsername", "customer").Return(&user, nil)
+	users.On("Update", mock.AnythingOfType("models.User")).Return(nil).Maybe()
+	guardUsers.On("GetById", "1").Return(&user, nil)
+	guardUsers.On("Update", mock.AnythingOfType("models.User")).Return(nil)
+
+	// The audit log records the login against the user who logged in.
+	ctx := &router.RouterContext{Body: `{"username":"customer","password":"password"}`, Sender: "127.0.0.1:5000"}
+	handler.Authenticate(ctx)
+	assert.Equal(t, 200, ctx.StatusCode)
+	assert.Equal(t, "1", ctx.User.UserId)
+}
+
+func TestAuthHandler_CompleteTwoFactor_LocksOut(t *testing.T) {
+	users := mocks.NewUserRepository(t)
+	testUc := newTestAuthUseCase(t, users, nil)
+	guard, guardUsers, _, now := newTestLoginGuard(t)
+	handler := AuthHandler{AuthUseCase: testUc, LoginGuard: guard}
+
+	secret, err := generateTOTPSecret()
+	assert.NoError(t, err)
+	user := models.User{Id: "1", Username: "customer", Role: models.Customer, TwoFactor: &models.TwoFactor{Secret: secret, Enabled: true}}
+	users.On("GetById", "1").Return(&user, nil)
+	guardUsers.On("GetByUsername", "customer").Return(nil, assert.AnError).Maybe()
+
+	completeTwoFactor := func(code string) int {
+		challenge, err := testUc.issueChallenge(user)
+		assert.NoError(t, err)
+		body, err := json.Marshal(models.TwoFactorRequest{ChallengeToken: challenge, Code: code})
+		assert.NoError(t, err)
+
+		ctx := &router.RouterContext{Body: string(body), Sender: "127.0.0.1:5000"}
+		handler.CompleteTwoFactor(ctx)
+		return ctx.StatusCode
+	}
+
+	// Each wrong code counts against the account, however many challenges are
+	// asked for, until it is locked out.
+	for i := 0; i < testLockoutConfig.MaxAttempts; i++ {
+		assert.Equal(t, 401, completeTwoFactor("not-a-code"))
+		*now = now.Add(10 * time.Second)
+	}
+
+	code, err := totpCode(secret, totpStep(time.Now()))
+	assert.NoError(t, err)
+	assert.Equal(t, 429, completeTwoFactor(code))
+}
+
+func TestAuthHandler_ChangePassword_LocksOut(t *testing.T) {
+	users := mocks.NewUserRepository(t)
+	testUc := newTestAuthUseCase(t, users, nil)
+	guard, guardUsers, _, now := newTestLoginGuard(t)
+	handler := AuthHandler{AuthUseCase: testUc, LoginGuard: guard}
+
+	hash, err := HashPassword("current-password")
+	assert.NoError(t, err)
+	user := models.User{Id: "1", Username: "customer", Password: hash, Role: models.Customer}
+	users.On("GetById", "1").Return(&user, nil)
+	guardUsers.On("GetByUsername", "customer").Return(nil, assert.AnError).Maybe()
+
+	changePassword := func(current string) int {
+		body, err := json.Marshal(models.ChangePasswordRequest{CurrentPassword: current, NewPassword: "new-password"})
+		assert.NoError(t, err)
+
+		ctx := &router.RouterContext{Body: string(body), Sender: "127.0.0.1:5000", User: &models.UserClaim{UserId: "1", SessionId: "session"}}
+		handler.ChangePassword(ctx)
+		return ctx.StatusCode
+	}
+
+	// Wrong current passwords count against the account like failed logins.
+	for i := 0; i < testLockoutConfig.MaxAttempts; i++ {
+		assert.Equal(t, 403, changePassword("wrong-password"))
+		*now = now.Add(10 * time.Second)
+	}
+	assert.Equal(t, 429, changePassword("current-password"))
+}
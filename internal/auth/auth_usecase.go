@@ -1,128 +1,455 @@
 package auth
 
 import (
-	"encoding/json"
-	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/config"
 	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
 	"github.com/pkg/errors"
-	"math/rand"
-	"os"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
-type userData struct {
-	Users []models.User `json:"users"`
-}
+var ErrAccountDisabled = errors.New("account is disabled")
 
 type authUseCase struct {
-	users       []models.User
-	validTokens map[string]*models.User
+	Logger          slog.Logger
+	users           domain.UserRepository
+	revocations     domain.RevocationRepository
+	sessions        domain.SessionRepository
+	apiKeys         domain.ApiKeyUseCase
+	organisations   domain.OrganisationUseCase
+	broadcast       domain.BroadcastUseCase
+	signer          *tokenSigner
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	sessionLifetime time.Duration
+	impersonateTTL  time.Duration
+	twoFactor       config.TwoFactor
+	challenges      *challengeTracker
+	// Held while a refresh token is exchanged so it can only be used once.
+	refreshMu sync.Mutex
 }
 
-func NewAuthUseCase() domain.AuthUseCase {
-	users, err := readUsers()
-	if err != nil {
-		panic(err)
+func NewAuthUseCase(cfg config.Auth, users domain.UserRepository, revocations domain.RevocationRepository, sessions domain.SessionRepository, apiKeys domain.ApiKeyUseCase, organisations domain.OrganisationUseCase, broadcast domain.BroadcastUseCase, logger slog.Logger) domain.AuthUseCase {
+	secret := []byte(cfg.TokenSecret)
+	if len(secret) == 0 {
+		logger.Warn("no token secret configured, generating one - sessions will not survive a restart")
+		var err error
+		secret, err = generateSecret()
+		if err != nil {
+			panic(err)
+		}
 	}
 
 	return &authUseCase{
-		users:       users,
-		validTokens: make(map[string]*models.User),
+		Logger:          logger,
+		users:           users,
+		revocations:     revocations,
+		sessions:        sessions,
+		apiKeys:         apiKeys,
+		organisations:   organisations,
+		broadcast:       broadcast,
+		signer:          newTokenSigner(secret),
+		accessTokenTTL:  cfg.AccessTokenTTL,
+		refreshTokenTTL: cfg.RefreshTokenTTL,
+		sessionLifetime: cfg.SessionLifetime,
+		impersonateTTL:  cfg.ImpersonationTTL,
+		twoFactor:       cfg.TwoFactor,
+		challenges:      newChallengeTracker(),
 	}
 }
 
-func readUsers() ([]models.User, error) {
-	currentDir, err := os.Getwd()
+func (a *authUseCase) Authenticate(username string, password string) (*models.UserClaim, error) {
+	user, err := a.users.GetByUsername(username)
+	if err != nil {
+		// Burn the same amount of time as a real comparison so usernames can't be enumerated.
+		VerifyPassword(string(dummyHash), password)
+		return nil, nil
+	}
+
+	matches, needsUpgrade := VerifyPassword(user.Password, password)
+	if !matches {
+		return nil, nil
+	}
+
+	if user.Disabled {
+		return nil, ErrAccountDisabled
+	}
+
+	if needsUpgrade {
+		err = a.upgradePassword(*user, password)
+		if err != nil {
+			a.Logger.Error("failed to upgrade stored password", "userId", user.Id, "error", err)
+		}
+	}
+
+	if user.TwoFactorEnabled() || a.requiresTwoFactor(user.Role) {
+		challenge, err := a.issueChallenge(*user)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &TwoFactorRequiredError{
+			ChallengeToken: challenge,
+			Enrolment:      !user.TwoFactorEnabled(),
+		}
+	}
+
+	sessionId, err := generateId()
 	if err != nil {
 		return nil, err
 	}
 
-	dat, err := os.ReadFile(fmt.Sprintf("%s/internal/data/auth/users.json", currentDir))
+	return a.issueTokens(sessionId, *user)
+}
+
+// upgradePassword replaces a legacy plaintext password with its hash and persists the change.
+func (a *authUseCase) upgradePassword(user models.User, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	a.Logger.Info("upgrading plaintext password to hash", "userId", user.Id)
+	user.Password = hash
+	return a.users.Update(user)
+}
+
+// ChangePassword replaces userId's password and logs out every session but
+// currentSessionId, so a session taken by someone else doesn't survive it.
+func (a *authUseCase) ChangePassword(userId string, currentSessionId string, currentPassword string, newPassword string) error {
+	user, err := a.users.GetById(userId)
+	if err != nil {
+		return err
+	}
+
+	matches, _ := VerifyPassword(user.Password, currentPassword)
+	if !matches {
+		return ErrIncorrectPassword
+	}
+
+	err = ValidatePassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password, err = HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	a.Logger.Info("changing password", "userId", userId)
+	err = a.users.Update(*user)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range a.sessions.GetByUser(userId) {
+		if session.Id == currentSessionId {
+			continue
+		}
+
+		err = a.revokeSession(session)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// issueTokens signs a new access and refresh token pair for the given session.
+// Each refresh token has its own ID, recorded against the session, so only the
+// latest one issued can be exchanged.
+func (a *authUseCase) issueTokens(sessionId string, user models.User) (*models.UserClaim, error) {
+	now := time.Now().UTC()
+	session := a.nextSession(sessionId, user.Id, now)
+	if !session.ExpiresAt.After(now) {
+		return nil, errExpiredToken
+	}
+
+	claims := tokenClaims{
+		SessionId: sessionId,
+		UserId:    user.Id,
+		Role:      user.Role,
+		Type:      accessToken,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(a.accessTokenTTL).Unix(),
+	}
+
+	token, err := a.signer.Sign(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign access token")
+	}
+
+	session.RefreshTokenId, err = generateId()
 	if err != nil {
 		return nil, err
 	}
 
-	var userData userData
-	err = json.Unmarshal(dat, &userData)
+	claims.Type = refreshToken
+	claims.TokenId = session.RefreshTokenId
+	claims.ExpiresAt = session.ExpiresAt.Unix()
+	refresh, err := a.signer.Sign(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign refresh token")
+	}
+
+	err = a.sessions.Save(session)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save session")
+	}
+
+	return &models.UserClaim{
+		UserId:       user.Id,
+		SessionId:    sessionId,
+		Token:        token,
+		RefreshToken: refresh,
+		Role:         user.Role,
+		ExpiresAt:    now.Add(a.accessTokenTTL),
+	}, nil
+}
+
+// verify checks a token's signature, expiry and type, and that its session
+// has not been revoked.
+func (a *authUseCase) verify(token string, expectedType tokenType) (*tokenClaims, error) {
+	claims, err := a.signer.Verify(token, expectedType)
 	if err != nil {
 		return nil, err
 	}
 
-	return userData.Users, nil
+	if a.revocations.IsSessionRevoked(claims.SessionId) {
+		return nil, errRevokedToken
+	}
+
+	revokedAt := a.revocations.GetUserRevokedAt(claims.UserId)
+	if revokedAt != nil && claims.IssuedAt <= revokedAt.Unix() {
+		return nil, errRevokedToken
+	}
+
+	// Revoking the impersonating admin's sessions also ends their impersonations.
+	if claims.ImpersonatorId != "" {
+		revokedAt = a.revocations.GetUserRevokedAt(claims.ImpersonatorId)
+		if revokedAt != nil && claims.IssuedAt <= revokedAt.Unix() {
+			return nil, errRevokedToken
+		}
+	}
+
+	return claims, nil
 }
 
-func (a *authUseCase) Authenticate(username string, password string) (*models.UserClaim, error) {
-	for _, user := range a.users {
-		if user.Username == username && user.Password == password {
-			token := generateToken()
-			a.validTokens[token] = &user
-
-			return &models.UserClaim{
-				UserId: user.Id,
-				Token:  token,
-				Role:   user.Role,
-			}, nil
+// Refresh exchanges a refresh token for a new token pair. Refresh tokens can
+// only be used once; presenting one that has already been exchanged means it
+// has leaked, so the whole session is revoked.
+func (a *authUseCase) Refresh(token string) (*models.UserClaim, error) {
+	claims, err := a.verify(token, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+
+	session := a.sessions.Get(claims.SessionId)
+	if session == nil {
+		return nil, errRevokedToken
+	}
+
+	if session.RefreshTokenId != claims.TokenId {
+		a.Logger.Warn("refresh token reused, revoking session", "sessionId", session.Id, "userId", session.UserId)
+		err = a.revokeSession(*session)
+		if err != nil {
+			return nil, err
 		}
+		return nil, errRevokedToken
+	}
+
+	// Look the user up again so removed or disabled users can't keep refreshing
+	// and role changes are picked up.
+	user, err := a.GetUserById(claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Disabled {
+		return nil, ErrAccountDisabled
+	}
+
+	return a.issueTokens(claims.SessionId, *user)
+}
+
+func (a *authUseCase) Logout(token string) error {
+	claims, err := a.verify(token, accessToken)
+	if err != nil {
+		return err
+	}
+
+	// The refresh token for this session outlives the access token, so keep the
+	// session on the revocation list until the refresh token would have expired.
+	until := time.Unix(claims.IssuedAt, 0).Add(a.refreshTokenTTL)
+	err = a.revocations.RevokeSession(claims.SessionId, until)
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke session")
+	}
+
+	a.broadcast.RemoveSession(claims.SessionId)
+
+	err = a.sessions.Delete(claims.SessionId)
+	if err != nil {
+		a.Logger.Error("failed to delete session", "sessionId", claims.SessionId, "error", err)
+	}
+	return nil
+}
+
+func (a *authUseCase) RevokeUser(userId string) error {
+	_, err := a.GetUserById(userId)
+	if err != nil {
+		return err
 	}
 
-	return nil, nil
+	err = a.revocations.RevokeUser(userId, time.Now().UTC())
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke user sessions")
+	}
+
+	a.broadcast.RemoveUserSessions(userId)
+
+	err = a.sessions.DeleteByUser(userId)
+	if err != nil {
+		a.Logger.Error("failed to delete user sessions", "userId", userId, "error", err)
+	}
+	return nil
 }
 
 func (a *authUseCase) TokenIsValid(token string) bool {
-	return a.validTokens[token] != nil
+	_, err := a.verify(token, accessToken)
+	return err == nil
 }
 
+// GetUser returns the caller identified by token, which may be either a session
+// access token or an API key.
 func (a *authUseCase) GetUser(token string) (*models.UserClaim, error) {
-	user := a.validTokens[token]
-	if user == nil {
-		return nil, errors.New("invalid token")
+	if models.IsApiKey(token) {
+		return a.apiKeys.Authenticate(token)
+	}
+
+	claims, err := a.verify(token, accessToken)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.UserClaim{
-		UserId: user.Id,
-		Token:  token,
-		Role:   user.Role,
+		UserId:         claims.UserId,
+		SessionId:      claims.SessionId,
+		Token:          token,
+		Role:           claims.Role,
+		ExpiresAt:      claims.expiry(),
+		ImpersonatorId: claims.ImpersonatorId,
+		ReadOnly:       claims.ReadOnly,
 	}, nil
 }
 
-func (a *authUseCase) GetAllUserIds() []string {
+// GetAllUserIds returns the IDs of every user visible to userId.
+func (a *authUseCase) GetAllUserIds(userId string) []string {
 	ids := make([]string, 0)
-	for _, user := range a.users {
-		ids = append(ids, user.Id)
+	users, scope, err := a.visibleUsers(userId)
+	if err != nil {
+		a.Logger.Error("failed to get users", "error", err)
+		return ids
+	}
+
+	for _, user := range users {
+		if scope.CanSeeUser(user) {
+			ids = append(ids, user.Id)
+		}
 	}
 	return ids
 }
 
-var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+// CanSeeUser reports whether userId may see, and so interact with, otherUserId.
+func (a *authUseCase) CanSeeUser(userId string, otherUserId string) bool {
+	scope, err := a.organisations.GetScope(userId)
+	if err != nil {
+		return false
+	}
 
-func generateToken() string {
-	b := make([]rune, 20)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
+	other, err := a.users.GetById(otherUserId)
+	if err != nil {
+		return false
 	}
-	return string(b)
+
+	return scope.CanSeeUser(*other)
 }
 
-func (a *authUseCase) GetUserById(userId string) (*models.User, error) {
-	for _, user := range a.users {
-		if user.Id == userId {
-			return &user, nil
-		}
+func (a *authUseCase) visibleUsers(userId string) ([]models.User, *models.TenantScope, error) {
+	scope, err := a.organisations.GetScope(userId)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil, errors.New("user not found")
+	users, err := a.users.GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return users, scope, nil
 }
 
-func (a *authUseCase) GetAllUsersInfo() ([]models.UserInfo, error) {
+func (a *authUseCase) GetUserById(userId string) (*models.User, error) {
+	return a.users.GetById(userId)
+}
+
+// GetAllUsersInfo returns a page of the users visible to userId, ordered by
+// username. Email addresses are only included for members of the same
+// organisation.
+func (a *authUseCase) GetAllUsersInfo(userId string, request models.UserListRequest) ([]models.UserInfo, models.PageInfo, error) {
+	users, scope, err := a.visibleUsers(userId)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
 	userInfos := make([]models.UserInfo, 0)
-	for _, user := range a.users {
-		userInfo := models.UserInfo{
-			Id:       user.Id,
-			Username: user.Username,
-			Email:    user.Email,
-			Role:     user.Role,
+	for _, user := range users {
+		if !scope.CanSeeUser(user) || (request.Role != "" && user.Role != request.Role) {
+			continue
 		}
-		userInfos = append(userInfos, userInfo)
+
+		info := user.Info()
+		if !scope.CanSeeContactDetails(user) {
+			info.Email = ""
+		}
+		userInfos = append(userInfos, info)
+	}
+
+	slices.SortFunc(userInfos, func(a, b models.UserInfo) int {
+		return keyOfUser(a).compare(keyOfUser(b))
+	})
+
+	if !pagination.UsesCursor(request.PageRequest) {
+		page, pageInfo := pagination.Offset(userInfos, request.PageNumber, request.PageSize)
+		return page, pageInfo, nil
+	}
+
+	return pagination.Paginate(userInfos, request.PageRequest, pagination.List(request.Role), keyOfUser, func(user models.UserInfo, key userKey) bool {
+		return keyOfUser(user).compare(key) > 0
+	})
+}
+
+// userKey orders users by username, ignoring case.
+type userKey struct {
+	Username string `json:"u"`
+	Id       string `json:"i"`
+}
+
+func keyOfUser(user models.UserInfo) userKey {
+	return userKey{Username: strings.ToLower(user.Username), Id: user.Id}
+}
+
+func (k userKey) compare(other userKey) int {
+	if c := strings.Compare(k.Username, other.Username); c != 0 {
+		return c
 	}
-	return userInfos, nil
+	return strings.Compare(k.Id, other.Id)
 }
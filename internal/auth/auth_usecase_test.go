@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAuthUseCase_GetAllUsersInfo(t *testing.T) {
+	users := []models.User{
+		{Id: "1", Username: "zoe", Role: models.Customer},
+		{Id: "2", Username: "Adam", Role: models.Supplier},
+		{Id: "3", Username: "bob", Role: models.Customer},
+		{Id: "4", Username: "carol", Role: models.Customer},
+	}
+
+	testCases := []struct {
+		name          string
+		request       models.UserListRequest
+		expectedNames []string
+		expectedInfo  models.PageInfo
+		expectedErr   error
+	}{
+		{
+			name:          "Happy path - first page",
+			request:       models.UserListRequest{PageRequest: models.PageRequest{Limit: 2}},
+			expectedNames: []string{"Adam", "bob"},
+			expectedInfo:  models.PageInfo{HasMore: true, Total: 4},
+		},
+		{
+			name:          "Happy path - role",
+			request:       models.UserListRequest{Role: models.Customer},
+			expectedNames: []string{"bob", "carol", "zoe"},
+			expectedInfo:  models.PageInfo{Total: 3},
+		},
+		{
+			name:          "Happy path - page number",
+			request:       models.UserListRequest{PageNumber: 2, PageSize: 3},
+			expectedNames: []string{"zoe"},
+			expectedInfo:  models.PageInfo{Total: 4},
+		},
+		{
+			name:          "Happy path - unpaged",
+			request:       models.UserListRequest{},
+			expectedNames: []string{"Adam", "bob", "carol", "zoe"},
+			expectedInfo:  models.PageInfo{Total: 4},
+		},
+		{
+			name:        "Sad path - invalid cursor",
+			request:     models.UserListRequest{PageRequest: models.PageRequest{Cursor: "invalid"}},
+			expectedErr: pagination.ErrInvalidCursor,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := mocks.NewUserRepository(t)
+			organisations := mocks.NewOrganisationUseCase(t)
+			testUc := newTestAuthUseCase(t, userRepo, nil)
+			testUc.organisations = organisations
+
+			userRepo.On("GetAll").Return(users, nil)
+			organisations.On("GetScope", "1").Return(&models.TenantScope{All: true}, nil)
+
+			result, pageInfo, err := testUc.GetAllUsersInfo("1", tc.request)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			names := make([]string, 0, len(result))
+			for _, user := range result {
+				names = append(names, user.Username)
+			}
+			assert.Equal(t, tc.expectedNames, names)
+			pageInfo.NextCursor = ""
+			assert.Equal(t, tc.expectedInfo, pageInfo)
+		})
+	}
+}
+
+func TestAuthUseCase_GetAllUsersInfo_Cursor(t *testing.T) {
+	users := []models.User{
+		{Id: "1", Username: "adam"},
+		{Id: "2", Username: "bob"},
+		{Id: "3", Username: "carol"},
+	}
+	userRepo := mocks.NewUserRepository(t)
+	organisations := mocks.NewOrganisationUseCase(t)
+	testUc := newTestAuthUseCase(t, userRepo, nil)
+	testUc.organisations = organisations
+	organisations.On("GetScope", "1").Return(&models.TenantScope{All: true}, nil)
+	userRepo.On("GetAll").Return(func() []models.User { return users }, nil)
+
+	first, pageInfo, err := testUc.GetAllUsersInfo("1", models.UserListRequest{PageRequest: models.PageRequest{Limit: 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, "adam", first[0].Username)
+
+	// A user signing up before the cursor doesn't repeat anyone.
+	users = append(users, models.User{Id: "4", Username: "aaron"})
+	next, pageInfo, err := testUc.GetAllUsersInfo("1", models.UserListRequest{PageRequest: models.PageRequest{Cursor: pageInfo.NextCursor, Limit: 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", next[0].Username)
+	assert.Equal(t, 4, pageInfo.Total)
+
+	// Cursors are tied to the role they were listed with.
+	_, _, err = testUc.GetAllUsersInfo("1", models.UserListRequest{Role: models.Admin, PageRequest: models.PageRequest{Cursor: pageInfo.NextCursor}})
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var ErrCannotImpersonate = errors.New("user cannot be impersonated")
+
+// Impersonate issues adminId an access token acting as userId, so support can
+// see what the user sees without their password. The token is read only unless
+// allowWrites is set and expires after the impersonation TTL; no refresh token
+// is issued and no session is recorded against the user.
+func (a *authUseCase) Impersonate(adminId string, userId string, allowWrites bool) (*models.UserClaim, error) {
+	if adminId == userId {
+		return nil, errors.Wrap(ErrCannotImpersonate, "admins cannot impersonate themselves")
+	}
+
+	user, err := a.GetUserById(userId)
+	if err != nil {
+		return nil, errors.Wrapf(ErrCannotImpersonate, "user %s does not exist", userId)
+	}
+
+	if user.Role == models.Admin {
+		return nil, errors.Wrap(ErrCannotImpersonate, "admins cannot be impersonated")
+	}
+
+	if user.Disabled {
+		return nil, ErrAccountDisabled
+	}
+
+	sessionId, err := generateId()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	claims := tokenClaims{
+		SessionId:      sessionId,
+		UserId:         user.Id,
+		Role:           user.Role,
+		Type:           accessToken,
+		IssuedAt:       now.Unix(),
+		ExpiresAt:      now.Add(a.impersonateTTL).Unix(),
+		ImpersonatorId: adminId,
+		ReadOnly:       !allowWrites,
+	}
+
+	token, err := a.signer.Sign(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign impersonation token")
+	}
+
+	a.Logger.Info("starting impersonation session", "adminId", adminId, "userId", user.Id, "sessionId", sessionId, "readOnly", claims.ReadOnly)
+	return &models.UserClaim{
+		UserId:         user.Id,
+		SessionId:      sessionId,
+		Token:          token,
+		Role:           user.Role,
+		ExpiresAt:      claims.expiry(),
+		ImpersonatorId: adminId,
+		ReadOnly:       claims.ReadOnly,
+	}, nil
+}
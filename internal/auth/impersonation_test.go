@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAuthUseCase_Impersonate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		userId      string
+		user        *models.User
+		allowWrites bool
+		expectedErr error
+	}{
+		{
+			name:   "Happy path - read only",
+			userId: "customer",
+			user:   &models.User{Id: "customer", Role: models.Customer},
+		},
+		{
+			name:        "Happy path - with writes",
+			userId:      "customer",
+			user:        &models.User{Id: "customer", Role: models.Customer},
+			allowWrites: true,
+		},
+		{
+			name:        "Sad path - admin",
+			userId:      "other-admin",
+			user:        &models.User{Id: "other-admin", Role: models.Admin},
+			expectedErr: ErrCannotImpersonate,
+		},
+		{
+			name:        "Sad path - self",
+			userId:      "admin",
+			expectedErr: ErrCannotImpersonate,
+		},
+		{
+			name:        "Sad path - missing user",
+			userId:      "missing",
+			expectedErr: ErrCannotImpersonate,
+		},
+		{
+			name:        "Sad path - disabled user",
+			userId:      "customer",
+			user:        &models.User{Id: "customer", Role: models.Customer, Disabled: true},
+			expectedErr: ErrAccountDisabled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := mocks.NewUserRepository(t)
+			testUc := newTestAuthUseCase(t, users, nil)
+			testUc.impersonateTTL = time.Minute
+
+			if tc.user != nil {
+				users.On("GetById", tc.userId).Return(tc.user, nil)
+			} else if tc.userId != "admin" {
+				users.On("GetById", tc.userId).Return(nil, assert.AnError)
+			}
+
+			claim, err := testUc.Impersonate("admin", tc.userId, tc.allowWrites)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Empty(t, claim.RefreshToken)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), claim.ExpiresAt, 2*time.Second)
+
+			user, err := testUc.GetUser(claim.Token)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.userId, user.UserId)
+			assert.Equal(t, "admin", user.ImpersonatorId)
+			assert.Equal(t, !tc.allowWrites, user.ReadOnly)
+
+			// Revoking the admin's sessions ends the impersonation.
+			err = testUc.revocations.RevokeUser("admin", time.Now().UTC().Add(time.Second))
+			assert.NoError(t, err)
+			assert.False(t, testUc.TokenIsValid(claim.Token))
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	usernameKeyPrefix = "username:"
+	addressKeyPrefix  = "address:"
+	maxKnownAddresses = 20
+)
+
+var ErrLockoutNotFound = errors.New("lockout not found")
+
+// LoginThrottledError is returned when logins are blocked for a username or address.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+type loginAttempts struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+	// Attempts Check has let through that haven't finished yet, and when the
+	// last of them started.
+	pending     int
+	lastPending time.Time
+}
+
+// loginGuard counts failed logins per username and per source address. Each
+// failure delays the next attempt for twice as long as the last until the
+// maximum is reached, at which point the username or address is locked out.
+// Check reserves each attempt until it is recorded, so attempts made in
+// parallel can't get past the maximum before their failures are counted.
+type loginGuard struct {
+	Users        domain.UserRepository
+	Notification domain.NotificationUseCase
+	Logger       slog.Logger
+	cfg          config.Lockout
+	now          func() time.Time
+
+	mu       sync.Mutex
+	attempts map[string]*loginAttempts
+}
+
+func NewLoginGuard(cfg config.Lockout, users domain.UserRepository, notification domain.NotificationUseCase, logger slog.Logger) domain.LoginGuard {
+	return &loginGuard{
+		Users:        users,
+		Notification: notification,
+		Logger:       logger,
+		cfg:          cfg,
+		now:          time.Now,
+		attempts:     make(map[string]*loginAttempts),
+	}
+}
+
+func (l *loginGuard) Check(username string, remoteAddr string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	keys := loginKeys(username, remoteAddr)
+	var blockedUntil time.Time
+	inFlight := false
+	for _, key := range keys {
+		attempts := l.current(key, now)
+		if attempts == nil {
+			continue
+		}
+		if attempts.blockedUntil.After(blockedUntil) {
+			blockedUntil = attempts.blockedUntil
+		}
+		if attempts.failures+attempts.pending >= l.maxAttemptsFor(key) {
+			inFlight = true
+		}
+	}
+
+	if now.Before(blockedUntil) {
+		return &LoginThrottledError{RetryAfter: blockedUntil.Sub(now)}
+	}
+	// Every attempt left is already being made, so wait to see how they end.
+	if inFlight {
+		return &LoginThrottledError{RetryAfter: l.cfg.BaseDelay}
+	}
+
+	for _, key := range keys {
+		attempts := l.current(key, now)
+		if attempts == nil {
+			attempts = &loginAttempts{}
+			l.attempts[key] = attempts
+		}
+		attempts.pending++
+		attempts.lastPending = now
+	}
+	return nil
+}
+
+func (l *loginGuard) RecordFailure(username string, remoteAddr string) {
+	l.mu.Lock()
+	now := l.now()
+	l.finish(loginKeys(username, remoteAddr))
+	usernameFailures := l.fail(usernameKeyPrefix+username, l.cfg.MaxAttempts, now)
+	l.fail(addressKeyPrefix+addressHost(remoteAddr), l.cfg.MaxAddressAttempts, now)
+	l.mu.Unlock()
+
+	l.Logger.Warn("failed login attempt", "username", username, "remoteAddress", remoteAddr, "failures", usernameFailures)
+
+	if usernameFailures == l.cfg.NotifyAfter || usernameFailures == l.cfg.MaxAttempts {
+		l.notifyFailures(username, usernameFailures)
+	}
+}
+
+func (l *loginGuard) RecordSuccess(userId string, username string, remoteAddr string) {
+	l.mu.Lock()
+	l.finish(loginKeys(username, remoteAddr))
+	delete(l.attempts, usernameKeyPrefix+username)
+	l.mu.Unlock()
+
+	err := l.recordAddress(userId, addressHost(remoteAddr))
+	if err != nil {
+		l.Logger.Error("failed to record login address", "userId", userId, "error", err)
+	}
+}
+
+func (l *loginGuard) Release(username string, remoteAddr string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.finish(loginKeys(username, remoteAddr))
+}
+
+// Prune forgets the attempts that have expired, so usernames and addresses
+// that are never tried again don't stay in memory.
+func (l *loginGuard) Prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key := range l.attempts {
+		l.current(key, now)
+	}
+}
+
+func (l *loginGuard) GetLockouts() []models.Lockout {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	lockouts := make([]models.Lockout, 0)
+	for key := range l.attempts {
+		attempts := l.current(key, now)
+		if attempts == nil || attempts.failures == 0 {
+			continue
+		}
+
+		lockouts = append(lockouts, models.Lockout{
+			Key:          key,
+			Failures:     attempts.failures,
+			LastFailure:  attempts.lastFailure,
+			BlockedUntil: attempts.blockedUntil,
+			Locked:       attempts.failures >= l.maxAttemptsFor(key) && now.Before(attempts.blockedUntil),
+		})
+	}
+
+	sort.Slice(lockouts, func(i, j int) bool {
+		return lockouts[i].LastFailure.After(lockouts[j].LastFailure)
+	})
+	return lockouts
+}
+
+func (l *loginGuard) ClearLockout(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.attempts[key]; !ok {
+		return ErrLockoutNotFound
+	}
+
+	l.Logger.Info("clearing login lockout", "key", key)
+	delete(l.attempts, key)
+	return nil
+}
+
+// current returns the attempts for key, forgetting them once the last failure is
+// older than the lockout duration and no attempt is pending. Attempts that
+// are still pending after the lockout duration are assumed to have been
+// abandoned. The caller must hold the lock.
+func (l *loginGuard) current(key string, now time.Time) *loginAttempts {
+	attempts, ok := l.attempts[key]
+	if !ok {
+		return nil
+	}
+
+	if now.Sub(attempts.lastPending) > l.cfg.Duration {
+		attempts.pending = 0
+	}
+	if attempts.pending == 0 && now.Sub(attempts.lastFailure) > l.cfg.Duration && !now.Before(attempts.blockedUntil) {
+		delete(l.attempts, key)
+		return nil
+	}
+	return attempts
+}
+
+// finish ends an attempt reserved by Check for each of keys. The caller must
+// hold the lock.
+func (l *loginGuard) finish(keys []string) {
+	for _, key := range keys {
+		attempts, ok := l.attempts[key]
+		if ok && attempts.pending > 0 {
+			attempts.pending--
+		}
+	}
+}
+
+// fail records a failure for key and returns the number of consecutive failures.
+// The caller must hold the lock.
+func (l *loginGuard) fail(key string, maxAttempts int, now time.Time) int {
+	attempts := l.current(key, now)
+	if attempts == nil {
+		attempts = &loginAttempts{}
+		l.attempts[key] = attempts
+	}
+
+	attempts.failures++
+	attempts.lastFailure = now
+	if attempts.failures >= maxAttempts {
+		attempts.blockedUntil = now.Add(l.cfg.Duration)
+	} else {
+		delay := l.cfg.BaseDelay << (attempts.failures - 1)
+		attempts.blockedUntil = now.Add(min(delay, l.cfg.Duration))
+	}
+
+	return attempts.failures
+}
+
+func (l *loginGuard) maxAttemptsFor(key string) int {
+	if strings.HasPrefix(key, addressKeyPrefix) {
+		return l.cfg.MaxAddressAttempts
+	}
+	return l.cfg.MaxAttempts
+}
+
+func (l *loginGuard) notifyFailures(username string, failures int) {
+	user, err := l.Users.GetByUsername(username)
+	if err != nil {
+		return
+	}
+
+	message := fmt.Sprintf("There have been %d failed attempts to log in to your account.", failures)
+	if failures >= l.cfg.MaxAttempts {
+		message += fmt.Sprintf(" Logins have been locked for %s.", l.cfg.Duration)
+	}
+
+	err = l.Notification.Add(user.Id, message)
+	if err != nil {
+		l.Logger.Error("failed to notify user of failed logins", "userId", user.Id, "error", err)
+	}
+}
+
+// recordAddress remembers the address a user logged in from, notifying them if
+// it hasn't been seen before. The very first login isn't reported.
+func (l *loginGuard) recordAddress(userId string, address string) error {
+	user, err := l.Users.GetById(userId)
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(user.KnownAddresses, address) {
+		return nil
+	}
+
+	if len(user.KnownAddresses) > 0 {
+		err = l.Notification.Add(user.Id, fmt.Sprintf("New login to your account from %s.", address))
+		if err != nil {
+			l.Logger.Error("failed to notify user of new login address", "userId", user.Id, "error", err)
+		}
+	}
+
+	user.KnownAddresses = append(user.KnownAddresses, address)
+	if len(user.KnownAddresses) > maxKnownAddresses {
+		user.KnownAddresses = user.KnownAddresses[len(user.KnownAddresses)-maxKnownAddresses:]
+	}
+	return l.Users.Update(*user)
+}
+
+func loginKeys(username string, remoteAddr string) []string {
+	return []string{usernameKeyPrefix + username, addressKeyPrefix + addressHost(remoteAddr)}
+}
+
+// addressHost strips the port from an address, as each connection uses a new one.
+func addressHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+var testLockoutConfig = config.Lockout{
+	MaxAttempts:        3,
+	MaxAddressAttempts: 5,
+	NotifyAfter:        2,
+	BaseDelay:          time.Second,
+	Duration:           time.Minute,
+}
+
+func newTestLoginGuard(t *testing.T) (*loginGuard, *mocks.UserRepository, *mocks.NotificationUseCase, *time.Time) {
+	users := mocks.NewUserRepository(t)
+	notification := mocks.NewNotificationUseCase(t)
+	guard := NewLoginGuard(testLockoutConfig, users, notification, *slog.Default()).(*loginGuard)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+	return guard, users, notification, &now
+}
+
+func TestLoginGuard_ProgressiveDelayAndLockout(t *testing.T) {
+	guard, users, notification, now := newTestLoginGuard(t)
+	users.On("GetByUsername", "customer").Return(&models.User{Id: "1"}, nil)
+	notification.On("Add", "1", mock.AnythingOfType("string")).Return(nil).Twice()
+
+	assert.NoError(t, guard.Check("customer", "10.0.0.1:5000"))
+
+	guard.RecordFailure("customer", "10.0.0.1:5000")
+	var throttled *LoginThrottledError
+	assert.ErrorAs(t, guard.Check("customer", "10.0.0.1:5001"), &throttled)
+	assert.Equal(t, time.Second, throttled.RetryAfter)
+
+	*now = now.Add(time.Second)
+	assert.NoError(t, guard.Check("customer", "10.0.0.1:5001"))
+
+	guard.RecordFailure("customer", "10.0.0.1:5001")
+	assert.ErrorAs(t, guard.Check("customer", "10.0.0.2:5000"), &throttled)
+	assert.Equal(t, 2*time.Second, throttled.RetryAfter)
+
+	*now = now.Add(2 * time.Second)
+	guard.RecordFailure("customer", "10.0.0.1:5002")
+	assert.ErrorAs(t, guard.Check("customer", "10.0.0.2:5000"), &throttled)
+	assert.Equal(t, time.Minute, throttled.RetryAfter)
+
+	lockouts := guard.GetLockouts()
+	assert.Len(t, lockouts, 2)
+	for _, lockout := range lockouts {
+		if lockout.Key == "username:customer" {
+			assert.True(t, lockout.Locked)
+			assert.Equal(t, 3, lockout.Failures)
+		}
+	}
+
+	assert.NoError(t, guard.ClearLockout("username:customer"))
+	assert.ErrorIs(t, guard.ClearLockout("username:customer"), ErrLockoutNotFound)
+
+	// The address still has a delay from its last failure, a different address doesn't.
+	assert.Error(t, guard.Check("customer", "10.0.0.1:5003"))
+	assert.NoError(t, guard.Check("customer", "10.0.0.2:5000"))
+
+	*now = now.Add(2 * time.Minute)
+	assert.NoError(t, guard.Check("customer", "10.0.0.1:5003"))
+	assert.Empty(t, guard.GetLockouts())
+}
+
+func TestLoginGuard_RecordSuccess(t *testing.T) {
+	testCases := []struct {
+		name           string
+		knownAddresses []string
+		address        string
+		expectNotify   bool
+		expectUpdate   bool
+	}{
+		{
+			name:           "Known address",
+			knownAddresses: []string{"10.0.0.1"},
+			address:        "10.0.0.1:5000",
+		},
+		{
+			name:           "New address",
+			knownAddresses: []string{"10.0.0.1"},
+			address:        "10.0.0.2:5000",
+			expectNotify:   true,
+			expectUpdate:   true,
+		},
+		{
+			name:         "First login",
+			address:      "10.0.0.1:5000",
+			expectUpdate: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			guard, users, notification, _ := newTestLoginGuard(t)
+			users.On("GetById", "1").Return(&models.User{Id: "1", KnownAddresses: tc.knownAddresses}, nil)
+			if tc.expectNotify {
+				notification.On("Add", "1", "New login to your account from 10.0.0.2.").Return(nil)
+			}
+			if tc.expectUpdate {
+				users.On("Update", mock.MatchedBy(func(user models.User) bool {
+					return user.KnownAddresses[len(user.KnownAddresses)-1] == addressHost(tc.address)
+				})).Return(nil)
+			}
+
+			guard.RecordFailure("customer", tc.address)
+			guard.RecordSuccess("1", "customer", tc.address)
+
+			for _, lockout := range guard.GetLockouts() {
+				assert.NotEqual(t, "username:customer", lockout.Key)
+			}
+		})
+	}
+}
+
+func TestLoginGuard_ParallelAttempts(t *testing.T) {
+	guard, _, _, _ := newTestLoginGuard(t)
+
+	// Attempts in flight count towards the maximum before they fail.
+	for i := 0; i < testLockoutConfig.MaxAttempts; i++ {
+		assert.NoError(t, guard.Check("customer", "10.0.0.1:5000"))
+	}
+	var throttled *LoginThrottledError
+	assert.ErrorAs(t, guard.Check("customer", "10.0.0.2:5000"), &throttled)
+	assert.Equal(t, testLockoutConfig.BaseDelay, throttled.RetryAfter)
+
+	// Attempts that end without failing give their place back.
+	guard.Release("customer", "10.0.0.1:5000")
+	assert.NoError(t, guard.Check("customer", "10.0.0.2:5000"))
+	assert.Empty(t, guard.GetLockouts())
+}
+
+func TestLoginGuard_Prune(t *testing.T) {
+	guard, _, _, now := newTestLoginGuard(t)
+
+	guard.RecordFailure("customer", "10.0.0.1:5000")
+	assert.NoError(t, guard.Check("other", "10.0.0.2:5000"))
+	assert.Len(t, guard.attempts, 4)
+
+	// Failures and abandoned attempts are forgotten once they have expired.
+	*now = now.Add(time.Minute / 2)
+	guard.Prune()
+	assert.Len(t, guard.attempts, 4)
+
+	*now = now.Add(time.Minute)
+	guard.Prune()
+	assert.Empty(t, guard.attempts)
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"github.com/google/uuid"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"strings"
+)
+
+const (
+	minPasswordLength = 8
+	// The username of the admin created from the bootstrap password.
+	bootstrapAdminUsername = "admin"
+)
+
+var passwordCost = bcrypt.DefaultCost
+
+var (
+	ErrWeakPassword      = errors.Errorf("password must be at least %d characters", minPasswordLength)
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+)
+
+// dummyHash is compared against when a username does not exist so that
+// failed lookups take as long as failed password checks.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// HashPassword returns a salted bcrypt hash of password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash password")
+	}
+	return string(hash), nil
+}
+
+// ValidatePassword checks a new password meets the password policy.
+func ValidatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// IsHashed reports whether a stored password is a bcrypt hash rather than a
+// legacy plaintext value.
+func IsHashed(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil && strings.HasPrefix(stored, "$2")
+}
+
+// VerifyPassword checks password against the stored value. The second return
+// value is true when the stored value is legacy plaintext and should be
+// replaced with a hash now that the password is known to be correct.
+func VerifyPassword(stored string, password string) (bool, bool) {
+	if IsHashed(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil, false
+	}
+
+	// Run a bcrypt comparison anyway so plaintext entries are not measurably faster.
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+	matches := subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	return matches, matches
+}
+
+// HashUserPasswords hashes every plaintext password held in the repository.
+// It returns the number of passwords that were hashed.
+func HashUserPasswords(users domain.UserRepository) (int, error) {
+	allUsers, err := users.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	hashed := 0
+	for _, user := range allUsers {
+		if IsHashed(user.Password) {
+			continue
+		}
+
+		hash, err := HashPassword(user.Password)
+		if err != nil {
+			return hashed, errors.Wrapf(err, "failed to hash password for user %s", user.Username)
+		}
+
+		user.Password = hash
+		err = users.Update(user)
+		if err != nil {
+			return hashed, errors.Wrapf(err, "failed to save user %s", user.Username)
+		}
+		hashed++
+	}
+
+	return hashed, nil
+}
+
+// BootstrapAdmin creates an admin with adminPassword when there are no admins,
+// so a new deployment can be administered without shipping a default account.
+// Outside development it refuses admins whose password is stored in plaintext,
+// as they are most likely left over from seed data.
+func BootstrapAdmin(users domain.UserRepository, adminPassword string, development bool, logger slog.Logger) error {
+	allUsers, err := users.GetAll()
+	if err != nil {
+		return err
+	}
+
+	admins := 0
+	for _, user := range allUsers {
+		if user.Role != models.Admin {
+			continue
+		}
+		admins++
+
+		if !development && !IsHashed(user.Password) {
+			return errors.Errorf("admin %s has a plaintext password, hash it with cmd/hash-passwords or remove the account", user.Username)
+		}
+	}
+
+	if admins > 0 {
+		return nil
+	}
+	if adminPassword == "" {
+		logger.Warn("there are no admins, set ADMIN_PASSWORD to create one")
+		return nil
+	}
+
+	err = ValidatePassword(adminPassword)
+	if err != nil {
+		return errors.Wrap(err, "invalid ADMIN_PASSWORD")
+	}
+
+	hash, err := HashPassword(adminPassword)
+	if err != nil {
+		return err
+	}
+
+	user := models.User{
+		Id:       uuid.New().String(),
+		Username: bootstrapAdminUsername,
+		Password: hash,
+		Role:     models.Admin,
+	}
+	err = users.Create(user)
+	if err != nil {
+		return errors.Wrap(err, "failed to create admin")
+	}
+
+	logger.Info("created admin", "userId", user.Id, "username", user.Username)
+	return nil
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/pkg/errors"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const maxResetAttempts = 5
+
+var ErrInvalidResetCode = errors.New("invalid or expired reset code")
+
+type resetCode struct {
+	hash      [sha256.Size]byte
+	expiresAt time.Time
+	attempts  int
+}
+
+type passwordResetUseCase struct {
+	Users  domain.UserRepository
+	Auth   domain.AuthUseCase
+	Sender domain.ResetCodeSender
+	Logger slog.Logger
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	codes map[string]*resetCode
+}
+
+func NewPasswordResetUseCase(users domain.UserRepository, auth domain.AuthUseCase, sender domain.ResetCodeSender, ttl time.Duration, logger slog.Logger) domain.PasswordResetUseCase {
+	return &passwordResetUseCase{
+		Users:  users,
+		Auth:   auth,
+		Sender: sender,
+		Logger: logger,
+		ttl:    ttl,
+		now:    time.Now,
+		codes:  make(map[string]*resetCode),
+	}
+}
+
+// RequestReset issues a new code for the user, replacing any outstanding one. It
+// doesn't report unknown usernames so it can't be used to discover accounts.
+// Wrong guesses at the code it replaces still count against the new one, so
+// asking for codes again doesn't give more guesses.
+func (p *passwordResetUseCase) RequestReset(username string) error {
+	user, err := p.Users.GetByUsername(username)
+	if err != nil {
+		p.Logger.Info("password reset requested for unknown user", "username", username)
+		return nil
+	}
+
+	if user.Disabled {
+		p.Logger.Info("password reset requested for disabled user", "userId", user.Id)
+		return nil
+	}
+
+	code, err := generateResetCode()
+	if err != nil {
+		return err
+	}
+
+	now := p.now()
+	expiresAt := now.Add(p.ttl)
+	p.mu.Lock()
+	attempts := 0
+	if outstanding, ok := p.codes[user.Id]; ok && now.Before(outstanding.expiresAt) {
+		attempts = outstanding.attempts
+	}
+	p.codes[user.Id] = &resetCode{
+		hash:      sha256.Sum256([]byte(code)),
+		expiresAt: expiresAt,
+		attempts:  attempts,
+	}
+	p.mu.Unlock()
+
+	err = p.Sender.SendResetCode(*user, code, expiresAt)
+	if err != nil {
+		return errors.Wrap(err, "failed to send reset code")
+	}
+
+	return nil
+}
+
+func (p *passwordResetUseCase) Reset(username string, code string, newPassword string) error {
+	err := ValidatePassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user, err := p.Users.GetByUsername(username)
+	if err != nil {
+		return ErrInvalidResetCode
+	}
+
+	err = p.consumeCode(user.Id, code)
+	if err != nil {
+		return err
+	}
+
+	user.Password, err = HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = p.Users.Update(*user)
+	if err != nil {
+		return err
+	}
+
+	p.Logger.Info("password reset", "userId", user.Id)
+
+	// Whoever had the old password shouldn't keep their sessions.
+	return p.Auth.RevokeUser(user.Id)
+}
+
+// consumeCode checks code against the outstanding code for the user. Codes are
+// removed once used or expired, and stop working after too many wrong guesses.
+// They are kept until they expire so the guesses count against any code that
+// replaces them.
+func (p *passwordResetUseCase) consumeCode(userId string, code string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	outstanding, ok := p.codes[userId]
+	if !ok {
+		return ErrInvalidResetCode
+	}
+
+	if !p.now().Before(outstanding.expiresAt) {
+		delete(p.codes, userId)
+		return ErrInvalidResetCode
+	}
+
+	if outstanding.attempts >= maxResetAttempts {
+		return ErrInvalidResetCode
+	}
+
+	hash := sha256.Sum256([]byte(code))
+	if subtle.ConstantTimeCompare(hash[:], outstanding.hash[:]) != 1 {
+		outstanding.attempts++
+		return ErrInvalidResetCode
+	}
+
+	delete(p.codes, userId)
+	return nil
+}
+
+// generateResetCode returns a random eight digit code.
+func generateResetCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(100000000))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate reset code")
+	}
+	return fmt.Sprintf("%08d", n.Int64()), nil
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestPasswordResetUseCase_Reset(t *testing.T) {
+	testCases := []struct {
+		name        string
+		code        func(issued string) string
+		advance     time.Duration
+		newPassword string
+		expectedErr error
+	}{
+		{
+			name:        "Happy path",
+			code:        func(issued string) string { return issued },
+			newPassword: "new-password",
+		},
+		{
+			name:        "Sad path - wrong code",
+			code:        func(issued string) string { return "not-the-code" },
+			newPassword: "new-password",
+			expectedErr: ErrInvalidResetCode,
+		},
+		{
+			name:        "Sad path - expired code",
+			code:        func(issued string) string { return issued },
+			advance:     time.Hour,
+			newPassword: "new-password",
+			expectedErr: ErrInvalidResetCode,
+		},
+		{
+			name:        "Sad path - weak password",
+			code:        func(issued string) string { return issued },
+			newPassword: "short",
+			expectedErr: ErrWeakPassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := mocks.NewUserRepository(t)
+			authUc := mocks.NewAuthUseCase(t)
+			sender := mocks.NewResetCodeSender(t)
+			testUc := NewPasswordResetUseCase(users, authUc, sender, 15*time.Minute, *slog.Default()).(*passwordResetUseCase)
+
+			now := time.Now()
+			testUc.now = func() time.Time { return now }
+
+			user := &models.User{Id: "1", Username: "customer", Password: "old-password"}
+			users.On("GetByUsername", "customer").Return(user, nil)
+
+			var issued string
+			sender.On("SendResetCode", *user, mock.AnythingOfType("string"), now.Add(15*time.Minute)).
+				Run(func(args mock.Arguments) { issued = args.String(1) }).
+				Return(nil)
+
+			err := testUc.RequestReset("customer")
+			assert.NoError(t, err)
+			assert.Len(t, issued, 8)
+
+			if tc.expectedErr == nil {
+				users.On("Update", mock.MatchedBy(func(updated models.User) bool {
+					matches, _ := VerifyPassword(updated.Password, tc.newPassword)
+					return updated.Id == "1" && matches
+				})).Return(nil)
+				authUc.On("RevokeUser", "1").Return(nil)
+			}
+
+			now = now.Add(tc.advance)
+			err = testUc.Reset("customer", tc.code(issued), tc.newPassword)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			// Codes are single use.
+			err = testUc.Reset("customer", issued, tc.newPassword)
+			assert.ErrorIs(t, err, ErrInvalidResetCode)
+		})
+	}
+}
+
+func TestPasswordResetUseCase_LimitsAttempts(t *testing.T) {
+	users := mocks.NewUserRepository(t)
+	sender := mocks.NewResetCodeSender(t)
+	testUc := NewPasswordResetUseCase(users, nil, sender, 15*time.Minute, *slog.Default())
+
+	user := &models.User{Id: "1", Username: "customer"}
+	users.On("GetByUsername", "customer").Return(user, nil)
+
+	var issued string
+	sender.On("SendResetCode", *user, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { issued = args.String(1) }).
+		Return(nil)
+
+	assert.NoError(t, testUc.RequestReset("customer"))
+
+	for i := 0; i < maxResetAttempts; i++ {
+		err := testUc.Reset("customer", "wrong", "new-password")
+		assert.ErrorIs(t, err, ErrInvalidResetCode)
+	}
+
+	err := testUc.Reset("customer", issued, "new-password")
+	assert.ErrorIs(t, err, ErrInvalidResetCode)
+
+	// Asking for another code doesn't give more guesses.
+	assert.NoError(t, testUc.RequestReset("customer"))
+	err = testUc.Reset("customer", issued, "new-password")
+	assert.ErrorIs(t, err, ErrInvalidResetCode)
+}
+
+func TestPasswordResetUseCase_RequestReset_UnknownUser(t *testing.T) {
+	users := mocks.NewUserRepository(t)
+	sender := mocks.NewResetCodeSender(t)
+	testUc := NewPasswordResetUseCase(users, nil, sender, 15*time.Minute, *slog.Default())
+
+	users.On("GetByUsername", "nobody").Return(nil, assert.AnError)
+
+	err := testUc.RequestReset("nobody")
+	assert.NoError(t, err)
+}
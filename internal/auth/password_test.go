@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"testing"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	hash, err := HashPassword("password")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name            string
+		stored          string
+		password        string
+		expectedMatch   bool
+		expectedUpgrade bool
+	}{
+		{
+			name:            "Happy path - hashed",
+			stored:          hash,
+			password:        "password",
+			expectedMatch:   true,
+			expectedUpgrade: false,
+		},
+		{
+			name:            "Happy path - plaintext",
+			stored:          "password",
+			password:        "password",
+			expectedMatch:   true,
+			expectedUpgrade: true,
+		},
+		{
+			name:            "Sad path - hashed",
+			stored:          hash,
+			password:        "wrong",
+			expectedMatch:   false,
+			expectedUpgrade: false,
+		},
+		{
+			name:            "Sad path - plaintext",
+			stored:          "password",
+			password:        "wrong",
+			expectedMatch:   false,
+			expectedUpgrade: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matches, needsUpgrade := VerifyPassword(tc.stored, tc.password)
+			assert.Equal(t, tc.expectedMatch, matches)
+			assert.Equal(t, tc.expectedUpgrade, needsUpgrade)
+		})
+	}
+}
+
+func TestAuthUseCase_Authenticate(t *testing.T) {
+	hash, err := HashPassword("password")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name            string
+		user            *models.User
+		userErr         error
+		password        string
+		expectedClaim   bool
+		expectedErr     error
+		expectedUpgrade bool
+	}{
+		{
+			name:          "Happy path - hashed password",
+			user:          &models.User{Id: "1", Username: "supplier", Password: hash, Role: models.Supplier},
+			password:      "password",
+			expectedClaim: true,
+		},
+		{
+			name:            "Happy path - plaintext password is upgraded",
+			user:            &models.User{Id: "1", Username: "supplier", Password: "password", Role: models.Supplier},
+			password:        "password",
+			expectedClaim:   true,
+			expectedUpgrade: true,
+		},
+		{
+			name:     "Sad path - wrong password",
+			user:     &models.User{Id: "1", Username: "supplier", Password: hash, Role: models.Supplier},
+			password: "wrong",
+		},
+		{
+			name:     "Sad path - unknown user",
+			userErr:  assert.AnError,
+			password: "password",
+		},
+		{
+			name:        "Sad path - disabled user",
+			user:        &models.User{Id: "1", Username: "supplier", Password: hash, Role: models.Supplier, Disabled: true},
+			password:    "password",
+			expectedErr: ErrAccountDisabled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := mocks.NewUserRepository(t)
+			testUc := newTestAuthUseCase(t, users, nil)
+
+			users.On("GetByUsername", "supplier").Return(tc.user, tc.userErr)
+			if tc.expectedUpgrade {
+				users.On("Update", mock.MatchedBy(func(user models.User) bool {
+					matches, _ := VerifyPassword(user.Password, tc.password)
+					return IsHashed(user.Password) && matches
+				})).Return(nil)
+			}
+
+			claim, err := testUc.Authenticate("supplier", tc.password)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedClaim, claim != nil)
+		})
+	}
+}
+
+func TestAuthUseCase_ChangePassword(t *testing.T) {
+	hash, err := HashPassword("password")
+	assert.NoError(t, err)
+	user := models.User{Id: "1", Username: "supplier", Password: hash, Role: models.Supplier}
+
+	users := mocks.NewUserRepository(t)
+	broadcast := mocks.NewBroadcastUseCase(t)
+	testUc := newTestAuthUseCase(t, users, broadcast)
+
+	current, err := testUc.issueTokens("current", user)
+	assert.NoError(t, err)
+	other, err := testUc.issueTokens("other", user)
+	assert.NoError(t, err)
+
+	users.On("GetById", "1").Return(&user, nil)
+	err = testUc.ChangePassword("1", "current", "wrong", "new password")
+	assert.ErrorIs(t, err, ErrIncorrectPassword)
+	assert.True(t, testUc.TokenIsValid(other.Token))
+
+	users.On("Update", mock.MatchedBy(func(user models.User) bool {
+		matches, _ := VerifyPassword(user.Password, "new password")
+		return matches
+	})).Return(nil)
+	broadcast.On("RemoveSession", "other").Return()
+
+	// Every session but the one changing the password is logged out.
+	err = testUc.ChangePassword("1", "current", "password", "new password")
+	assert.NoError(t, err)
+	assert.True(t, testUc.TokenIsValid(current.Token))
+	assert.False(t, testUc.TokenIsValid(other.Token))
+	_, err = testUc.Refresh(other.RefreshToken)
+	assert.ErrorIs(t, err, errRevokedToken)
+}
+
+func TestHashUserPasswords(t *testing.T) {
+	hash, err := HashPassword("already")
+	assert.NoError(t, err)
+
+	users := mocks.NewUserRepository(t)
+	users.On("GetAll").Return([]models.User{
+		{Id: "1", Password: "password"},
+		{Id: "2", Password: hash},
+	}, nil)
+	users.On("Update", mock.MatchedBy(func(user models.User) bool {
+		return user.Id == "1" && IsHashed(user.Password)
+	})).Return(nil).Once()
+
+	hashed, err := HashUserPasswords(users)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hashed)
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	hash, err := HashPassword("already")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		users         []models.User
+		adminPassword string
+		development   bool
+		expectCreate  bool
+		expectErr     bool
+	}{
+		{
+			name:          "Happy path - creates the first admin",
+			users:         []models.User{{Id: "1", Password: "password", Role: models.Customer}},
+			adminPassword: "admin-password",
+			expectCreate:  true,
+		},
+		{
+			name:          "Happy path - keeps an existing admin",
+			users:         []models.User{{Id: "1", Password: hash, Role: models.Admin}},
+			adminPassword: "admin-password",
+		},
+		{
+			name: "Happy path - no admin without a password",
+		},
+		{
+			name:        "Happy path - plaintext admin in development",
+			users:       []models.User{{Id: "1", Username: "admin", Password: "password", Role: models.Admin}},
+			development: true,
+		},
+		{
+			name:      "Sad path - plaintext admin outside development",
+			users:     []models.User{{Id: "1", Username: "admin", Password: "password", Role: models.Admin}},
+			expectErr: true,
+		},
+		{
+			name:          "Sad path - weak password",
+			adminPassword: "short",
+			expectErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := mocks.NewUserRepository(t)
+			users.On("GetAll").Return(tc.users, nil)
+			if tc.expectCreate {
+				users.On("Create", mock.MatchedBy(func(user models.User) bool {
+					matches, _ := VerifyPassword(user.Password, tc.adminPassword)
+					return user.Username == "admin" && user.Role == models.Admin && IsHashed(user.Password) && matches
+				})).Return(nil)
+			}
+
+			err := BootstrapAdmin(users, tc.adminPassword, tc.development, *slog.Default())
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type notificationResetCodeSender struct {
+	Notification domain.NotificationUseCase
+}
+
+// NewNotificationResetCodeSender delivers reset codes as in-app notifications.
+func NewNotificationResetCodeSender(notification domain.NotificationUseCase) domain.ResetCodeSender {
+	return &notificationResetCodeSender{
+		Notification: notification,
+	}
+}
+
+func (n *notificationResetCodeSender) SendResetCode(user models.User, code string, expiresAt time.Time) error {
+	return n.Notification.Add(user.Id, resetCodeMessage(code, expiresAt))
+}
+
+type mailResetCodeSender struct {
+	Mailer domain.Mailer
+}
+
+// NewMailResetCodeSender delivers reset codes by email.
+func NewMailResetCodeSender(mailer domain.Mailer) domain.ResetCodeSender {
+	return &mailResetCodeSender{
+		Mailer: mailer,
+	}
+}
+
+func (m *mailResetCodeSender) SendResetCode(user models.User, code string, expiresAt time.Time) error {
+	if user.Email == "" {
+		return errors.Errorf("user %s has no email address", user.Id)
+	}
+	return m.Mailer.Send(user.Email, "Your password reset code", resetCodeMessage(code, expiresAt))
+}
+
+func resetCodeMessage(code string, expiresAt time.Time) string {
+	return fmt.Sprintf("Your password reset code is %s. It expires at %s.", code, expiresAt.UTC().Format(time.RFC1123))
+}
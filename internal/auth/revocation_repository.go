@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// revocationData is the on-disk format of the revocation list. Only revoked
+// sessions are recorded, and only until the tokens they cover would have expired.
+type revocationData struct {
+	// Session ID to the time after which its tokens would have expired anyway.
+	Sessions map[string]time.Time `json:"sessions"`
+	// User ID to the time all of their sessions were revoked.
+	Users map[string]time.Time `json:"users"`
+}
+
+type revocationRepository struct {
+	Logger slog.Logger
+	path   string
+	mu     sync.RWMutex
+	data   revocationData
+}
+
+func NewRevocationRepository(logger slog.Logger) domain.RevocationRepository {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	repo, err := newRevocationRepository(fmt.Sprintf("%s/internal/data/auth/revocations.json", currentDir), logger)
+	if err != nil {
+		panic(err)
+	}
+
+	return repo
+}
+
+func newRevocationRepository(path string, logger slog.Logger) (*revocationRepository, error) {
+	data := revocationData{
+		Sessions: make(map[string]time.Time),
+		Users:    make(map[string]time.Time),
+	}
+
+	err := storage.ReadJSON(path, &data)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if data.Sessions == nil {
+		data.Sessions = make(map[string]time.Time)
+	}
+	if data.Users == nil {
+		data.Users = make(map[string]time.Time)
+	}
+
+	return &revocationRepository{
+		Logger: logger,
+		path:   path,
+		data:   data,
+	}, nil
+}
+
+func (r *revocationRepository) RevokeSession(sessionId string, until time.Time) error {
+	r.Logger.Info("revoking session", "sessionId", sessionId)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data.Sessions[sessionId] = until
+	return r.save()
+}
+
+func (r *revocationRepository) RevokeUser(userId string, at time.Time) error {
+	r.Logger.Info("revoking all sessions for user", "userId", userId)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data.Users[userId] = at
+	return r.save()
+}
+
+func (r *revocationRepository) IsSessionRevoked(sessionId string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.data.Sessions[sessionId]
+	return ok
+}
+
+func (r *revocationRepository) GetUserRevokedAt(userId string) *time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	revokedAt, ok := r.data.Users[userId]
+	if !ok {
+		return nil
+	}
+	return &revokedAt
+}
+
+// save prunes session entries whose tokens have expired and writes the list to
+// disk. The caller must hold the write lock.
+func (r *revocationRepository) save() error {
+	now := time.Now()
+	for sessionId, until := range r.data.Sessions {
+		if now.After(until) {
+			delete(r.data.Sessions, sessionId)
+		}
+	}
+
+	return storage.WriteJSON(r.path, r.data)
+}
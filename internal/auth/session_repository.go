@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Last seen times are only persisted when they've moved by at least this much,
+// so an active session doesn't rewrite the file on every request.
+const lastSeenResolution = time.Minute
+
+type sessionData struct {
+	Sessions map[string]models.Session `json:"sessions"`
+}
+
+type sessionRepository struct {
+	Logger slog.Logger
+	path   string
+	mu     sync.RWMutex
+	data   sessionData
+}
+
+func NewSessionRepository(logger slog.Logger) domain.SessionRepository {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	repo, err := newSessionRepository(fmt.Sprintf("%s/internal/data/auth/sessions.json", currentDir), logger)
+	if err != nil {
+		panic(err)
+	}
+
+	return repo
+}
+
+func newSessionRepository(path string, logger slog.Logger) (*sessionRepository, error) {
+	data := sessionData{
+		Sessions: make(map[string]models.Session),
+	}
+
+	err := storage.ReadJSON(path, &data)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if data.Sessions == nil {
+		data.Sessions = make(map[string]models.Session)
+	}
+
+	return &sessionRepository{
+		Logger: logger,
+		path:   path,
+		data:   data,
+	}, nil
+}
+
+func (s *sessionRepository) Save(session models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Sessions[session.Id] = session
+	return s.save()
+}
+
+func (s *sessionRepository) Get(sessionId string) *models.Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.data.Sessions[sessionId]
+	if !ok || time.Now().After(session.ExpiresAt) {
+		return nil
+	}
+	return &session
+}
+
+func (s *sessionRepository) GetByUser(userId string) []models.Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	sessions := make([]models.Session, 0)
+	for _, session := range s.data.Sessions {
+		if session.UserId == userId && !now.After(session.ExpiresAt) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+func (s *sessionRepository) Touch(sessionId string, at time.Time, remoteAddress string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.data.Sessions[sessionId]
+	if !ok {
+		return nil
+	}
+
+	persist := session.RemoteAddress != remoteAddress || at.Sub(session.LastSeenAt) >= lastSeenResolution
+	session.LastSeenAt = at
+	session.RemoteAddress = remoteAddress
+	s.data.Sessions[sessionId] = session
+
+	if !persist {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *sessionRepository) Delete(sessionId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data.Sessions, sessionId)
+	return s.save()
+}
+
+func (s *sessionRepository) DeleteByUser(userId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sessionId, session := range s.data.Sessions {
+		if session.UserId == userId {
+			delete(s.data.Sessions, sessionId)
+		}
+	}
+	return s.save()
+}
+
+// save prunes expired sessions and writes the rest to disk. The caller must
+// hold the write lock.
+func (s *sessionRepository) save() error {
+	now := time.Now()
+	for sessionId, session := range s.data.Sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.data.Sessions, sessionId)
+		}
+	}
+
+	return storage.WriteJSON(s.path, s.data)
+}
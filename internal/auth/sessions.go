@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"slices"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// nextSession returns the session a token pair issued at issuedAt is recorded
+// against. Refreshing keeps the session's original creation time, and its
+// expiry is never extended past the maximum session lifetime.
+func (a *authUseCase) nextSession(sessionId string, userId string, issuedAt time.Time) models.Session {
+	session := models.Session{
+		Id:         sessionId,
+		UserId:     userId,
+		CreatedAt:  issuedAt,
+		LastSeenAt: issuedAt,
+	}
+
+	existing := a.sessions.Get(sessionId)
+	if existing != nil {
+		session.CreatedAt = existing.CreatedAt
+		session.RemoteAddress = existing.RemoteAddress
+	}
+
+	session.ExpiresAt = issuedAt.Add(a.refreshTokenTTL)
+	if a.sessionLifetime > 0 {
+		session.ExpiresAt = minTime(session.ExpiresAt, session.CreatedAt.Add(a.sessionLifetime))
+	}
+	return session
+}
+
+func minTime(a time.Time, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// GetSessions returns the active sessions belonging to userId, most recently
+// seen first. currentSessionId marks the session making the request.
+func (a *authUseCase) GetSessions(userId string, currentSessionId string) ([]models.SessionInfo, error) {
+	sessions := a.sessions.GetByUser(userId)
+	slices.SortFunc(sessions, func(x, y models.Session) int {
+		return y.LastSeenAt.Compare(x.LastSeenAt)
+	})
+
+	infos := make([]models.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		if a.revocations.IsSessionRevoked(session.Id) {
+			continue
+		}
+
+		infos = append(infos, models.SessionInfo{
+			Id:            session.Id,
+			CreatedAt:     session.CreatedAt,
+			LastSeenAt:    session.LastSeenAt,
+			RemoteAddress: session.RemoteAddress,
+			Subscribed:    a.broadcast.IsSessionConnected(session.Id),
+			Current:       session.Id == currentSessionId,
+		})
+	}
+	return infos, nil
+}
+
+// TouchSession records that the session was just used from remoteAddress.
+func (a *authUseCase) TouchSession(sessionId string, remoteAddress string) {
+	err := a.sessions.Touch(sessionId, time.Now().UTC(), remoteAddress)
+	if err != nil {
+		a.Logger.Error("failed to update session", "sessionId", sessionId, "error", err)
+	}
+}
+
+// TerminateSession revokes one of userId's sessions and drops its broadcast
+// subscription. Sessions belonging to other users are reported as not found.
+func (a *authUseCase) TerminateSession(userId string, sessionId string) error {
+	session := a.sessions.Get(sessionId)
+	if session == nil || session.UserId != userId {
+		return ErrSessionNotFound
+	}
+
+	return a.revokeSession(*session)
+}
+
+// revokeSession revokes a session's tokens, drops its broadcast subscription
+// and forgets it.
+func (a *authUseCase) revokeSession(session models.Session) error {
+	err := a.revocations.RevokeSession(session.Id, session.ExpiresAt)
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke session")
+	}
+
+	a.broadcast.RemoveSession(session.Id)
+	return a.sessions.Delete(session.Id)
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAuthUseCase_Sessions(t *testing.T) {
+	user := models.User{Id: "1", Role: models.Customer}
+
+	testCases := []struct {
+		name        string
+		userId      string
+		expectedErr error
+	}{
+		{
+			name:   "Happy path",
+			userId: "1",
+		},
+		{
+			name:        "Sad path - another user's session",
+			userId:      "2",
+			expectedErr: ErrSessionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			broadcast := mocks.NewBroadcastUseCase(t)
+			testUc := newTestAuthUseCase(t, mocks.NewUserRepository(t), broadcast)
+
+			first, err := testUc.issueTokens("first", user)
+			assert.NoError(t, err)
+			second, err := testUc.issueTokens("second", user)
+			assert.NoError(t, err)
+			testUc.TouchSession("second", "127.0.0.1:5000")
+
+			broadcast.On("IsSessionConnected", "first").Return(false)
+			broadcast.On("IsSessionConnected", "second").Return(true)
+
+			sessions, err := testUc.GetSessions(user.Id, "first")
+			assert.NoError(t, err)
+			assert.Len(t, sessions, 2)
+			for _, session := range sessions {
+				assert.Equal(t, session.Id == "first", session.Current)
+				assert.Equal(t, session.Id == "second", session.Subscribed)
+				if session.Id == "second" {
+					assert.Equal(t, "127.0.0.1:5000", session.RemoteAddress)
+				}
+			}
+
+			if tc.expectedErr == nil {
+				broadcast.On("RemoveSession", "second").Return()
+			}
+
+			err = testUc.TerminateSession(tc.userId, "second")
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			sessions, err = testUc.GetSessions(user.Id, "first")
+			assert.NoError(t, err)
+			assert.Len(t, sessions, 1)
+			assert.True(t, testUc.TokenIsValid(first.Token))
+			assert.False(t, testUc.TokenIsValid(second.Token))
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenSigner_Verify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	signer := newTokenSigner([]byte("secret"))
+	signer.now = func() time.Time { return now }
+
+	claims := tokenClaims{
+		SessionId: "session",
+		UserId:    "user",
+		Role:      models.Supplier,
+		Type:      accessToken,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Minute).Unix(),
+	}
+	token, err := signer.Sign(claims)
+	assert.NoError(t, err)
+
+	expired := claims
+	expired.ExpiresAt = now.Add(-time.Second).Unix()
+	expiredToken, err := signer.Sign(expired)
+	assert.NoError(t, err)
+
+	otherToken, err := newTokenSigner([]byte("other")).Sign(claims)
+	assert.NoError(t, err)
+
+	payload, signature, _ := strings.Cut(token, ".")
+	tamperedToken := strings.ToUpper(payload[:1]) + strings.ToLower(payload[1:]) + "." + signature
+
+	testCases := []struct {
+		name         string
+		token        string
+		expectedType tokenType
+		expectedErr  error
+	}{
+		{
+			name:         "Happy path",
+			token:        token,
+			expectedType: accessToken,
+		},
+		{
+			name:         "Sad path - wrong type",
+			token:        token,
+			expectedType: refreshToken,
+			expectedErr:  errInvalidToken,
+		},
+		{
+			name:         "Sad path - expired",
+			token:        expiredToken,
+			expectedType: accessToken,
+			expectedErr:  errExpiredToken,
+		},
+		{
+			name:         "Sad path - signed with another secret",
+			token:        otherToken,
+			expectedType: accessToken,
+			expectedErr:  errInvalidToken,
+		},
+		{
+			name:         "Sad path - tampered payload",
+			token:        tamperedToken,
+			expectedType: accessToken,
+			expectedErr:  errInvalidToken,
+		},
+		{
+			name:         "Sad path - malformed",
+			token:        "not-a-token",
+			expectedType: accessToken,
+			expectedErr:  errInvalidToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := signer.Verify(tc.token, tc.expectedType)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, claims, *result)
+		})
+	}
+}
+
+func newTestAuthUseCase(t *testing.T, users domain.UserRepository, broadcast domain.BroadcastUseCase) *authUseCase {
+	logger := slog.Default()
+	revocations, err := newRevocationRepository(filepath.Join(t.TempDir(), "revocations.json"), *logger)
+	assert.NoError(t, err)
+	sessions, err := newSessionRepository(filepath.Join(t.TempDir(), "sessions.json"), *logger)
+	assert.NoError(t, err)
+
+	return &authUseCase{
+		Logger:          *logger,
+		users:           users,
+		revocations:     revocations,
+		sessions:        sessions,
+		broadcast:       broadcast,
+		signer:          newTokenSigner([]byte("secret")),
+		accessTokenTTL:  time.Minute,
+		refreshTokenTTL: time.Hour,
+		twoFactor:       config.TwoFactor{Issuer: "DADES", ChallengeTTL: time.Minute},
+		challenges:      newChallengeTracker(),
+	}
+}
+
+func TestAuthUseCase_Refresh(t *testing.T) {
+	user := models.User{
+		Id:   "1",
+		Role: models.Customer,
+	}
+	disabled := user
+	disabled.Disabled = true
+
+	testCases := []struct {
+		name        string
+		user        *models.User
+		userErr     error
+		expectedErr bool
+	}{
+		{
+			name: "Happy path",
+			user: &user,
+		},
+		{
+			name:        "Sad path - user removed",
+			userErr:     assert.AnError,
+			expectedErr: true,
+		},
+		{
+			name:        "Sad path - user disabled",
+			user:        &disabled,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := mocks.NewUserRepository(t)
+			testUc := newTestAuthUseCase(t, users, nil)
+
+			claim, err := testUc.issueTokens("session", user)
+			assert.NoError(t, err)
+			assert.True(t, testUc.TokenIsValid(claim.Token))
+			assert.False(t, testUc.TokenIsValid(claim.RefreshToken))
+
+			_, err = testUc.Refresh(claim.Token)
+			assert.Error(t, err)
+
+			users.On("GetById", "1").Return(tc.user, tc.userErr)
+
+			refreshed, err := testUc.Refresh(claim.RefreshToken)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "1", refreshed.UserId)
+
+			claims, err := testUc.GetUser(refreshed.Token)
+			assert.NoError(t, err)
+			assert.Equal(t, models.Customer, claims.Role)
+		})
+	}
+}
+
+func TestAuthUseCase_RefreshRotation(t *testing.T) {
+	user := models.User{Id: "1", Role: models.Customer}
+	users := mocks.NewUserRepository(t)
+	broadcast := mocks.NewBroadcastUseCase(t)
+	testUc := newTestAuthUseCase(t, users, broadcast)
+	users.On("GetById", "1").Return(&user, nil)
+
+	claim, err := testUc.issueTokens("session", user)
+	assert.NoError(t, err)
+	refreshed, err := testUc.Refresh(claim.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, claim.RefreshToken, refreshed.RefreshToken)
+
+	// Using a refresh token a second time ends the session.
+	broadcast.On("RemoveSession", "session").Return().Once()
+	_, err = testUc.Refresh(claim.RefreshToken)
+	assert.ErrorIs(t, err, errRevokedToken)
+	_, err = testUc.Refresh(refreshed.RefreshToken)
+	assert.ErrorIs(t, err, errRevokedToken)
+	assert.False(t, testUc.TokenIsValid(refreshed.Token))
+}
+
+func TestAuthUseCase_SessionLifetime(t *testing.T) {
+	user := models.User{Id: "1", Role: models.Customer}
+	testUc := newTestAuthUseCase(t, mocks.NewUserRepository(t), nil)
+	testUc.sessionLifetime = 90 * time.Minute
+
+	_, err := testUc.issueTokens("session", user)
+	assert.NoError(t, err)
+	session := testUc.sessions.Get("session")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), session.ExpiresAt, time.Minute)
+
+	// Refreshing later can't extend the session past its lifetime.
+	session.CreatedAt = session.CreatedAt.Add(-time.Hour)
+	assert.NoError(t, testUc.sessions.Save(*session))
+	_, err = testUc.issueTokens("session", user)
+	assert.NoError(t, err)
+	session = testUc.sessions.Get("session")
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), session.ExpiresAt, time.Minute)
+
+	session.CreatedAt = session.CreatedAt.Add(-time.Hour)
+	assert.NoError(t, testUc.sessions.Save(*session))
+	_, err = testUc.issueTokens("session", user)
+	assert.ErrorIs(t, err, errExpiredToken)
+}
+
+func TestAuthUseCase_Logout(t *testing.T) {
+	user := models.User{
+		Id:   "1",
+		Role: models.Customer,
+	}
+	broadcast := mocks.NewBroadcastUseCase(t)
+	testUc := newTestAuthUseCase(t, mocks.NewUserRepository(t), broadcast)
+
+	claim, err := testUc.issueTokens("session", user)
+	assert.NoError(t, err)
+	other, err := testUc.issueTokens("other", user)
+	assert.NoError(t, err)
+
+	broadcast.On("RemoveSession", "session").Return()
+
+	err = testUc.Logout(claim.Token)
+	assert.NoError(t, err)
+	assert.False(t, testUc.TokenIsValid(claim.Token))
+	_, err = testUc.Refresh(claim.RefreshToken)
+	assert.ErrorIs(t, err, errRevokedToken)
+
+	assert.True(t, testUc.TokenIsValid(other.Token))
+
+	err = testUc.Logout(claim.Token)
+	assert.Error(t, err)
+}
+
+func TestAuthUseCase_RevokeUser(t *testing.T) {
+	user := models.User{
+		Id:   "1",
+		Role: models.Customer,
+	}
+
+	testCases := []struct {
+		name    string
+		userErr error
+	}{
+		{
+			name: "Happy path",
+		},
+		{
+			name:    "Sad path - user not found",
+			userErr: assert.AnError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := mocks.NewUserRepository(t)
+			broadcast := mocks.NewBroadcastUseCase(t)
+			testUc := newTestAuthUseCase(t, users, broadcast)
+
+			claim, err := testUc.issueTokens("session", user)
+			assert.NoError(t, err)
+
+			if tc.userErr != nil {
+				users.On("GetById", user.Id).Return(nil, tc.userErr)
+			} else {
+				users.On("GetById", user.Id).Return(&user, nil)
+				broadcast.On("RemoveUserSessions", user.Id).Return()
+			}
+
+			err = testUc.RevokeUser(user.Id)
+			if tc.userErr != nil {
+				assert.Error(t, err)
+				assert.True(t, testUc.TokenIsValid(claim.Token))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.False(t, testUc.TokenIsValid(claim.Token))
+		})
+	}
+}
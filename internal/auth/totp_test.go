@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTotpCode(t *testing.T) {
+	// Test vectors from RFC 6238 appendix B, truncated to six digits.
+	secret := base32NoPadding.EncodeToString([]byte("12345678901234567890"))
+
+	testCases := []struct {
+		name     string
+		time     int64
+		expected string
+	}{
+		{name: "59", time: 59, expected: "287082"},
+		{name: "1111111109", time: 1111111109, expected: "081804"},
+		{name: "1234567890", time: 1234567890, expected: "005924"},
+		{name: "20000000000", time: 20000000000, expected: "353130"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := totpCode(secret, totpStep(time.Unix(tc.time, 0)))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, code)
+		})
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	assert.NoError(t, err)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		codeAt      time.Time
+		expectMatch bool
+	}{
+		{name: "Current step", codeAt: now, expectMatch: true},
+		{name: "Previous step", codeAt: now.Add(-totpPeriod), expectMatch: true},
+		{name: "Next step", codeAt: now.Add(totpPeriod), expectMatch: true},
+		{name: "Too old", codeAt: now.Add(-2 * totpPeriod)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := totpCode(secret, totpStep(tc.codeAt))
+			assert.NoError(t, err)
+
+			step, ok := matchTOTP(secret, code, now)
+			assert.Equal(t, tc.expectMatch, ok)
+			if ok {
+				assert.Equal(t, totpStep(tc.codeAt), step)
+			}
+		})
+	}
+}
+
+func TestProvisioningUri(t *testing.T) {
+	uri, err := url.Parse(provisioningUri("DADES", "supplier", "SECRET"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/DADES:supplier", uri.Path)
+	assert.Equal(t, "SECRET", uri.Query().Get("secret"))
+	assert.Equal(t, "DADES", uri.Query().Get("issuer"))
+}
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const challengeToken tokenType = "challenge"
+
+// Wrong codes allowed against a single challenge before the user has to enter
+// their password again.
+const maxChallengeAttempts = 5
+
+var (
+	ErrInvalidChallenge     = errors.New("two-factor challenge is invalid or has expired")
+	ErrInvalidTwoFactorCode = errors.New("two-factor code is incorrect")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorRequired    = errors.New("two-factor authentication is required for this account")
+)
+
+// TwoFactorRequiredError is returned by Authenticate when the password was
+// correct but the user must also provide a one-time code, or enrol first.
+type TwoFactorRequiredError struct {
+	ChallengeToken string
+	Enrolment      bool
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	if e.Enrolment {
+		return "two-factor enrolment required"
+	}
+	return "two-factor code required"
+}
+
+// challengeTracker remembers how many codes have been tried against each
+// outstanding challenge, and which have been used, until they expire.
+type challengeTracker struct {
+	mu         sync.Mutex
+	challenges map[string]*challengeState
+}
+
+type challengeState struct {
+	attempts int
+	used     bool
+	expires  time.Time
+}
+
+func newChallengeTracker() *challengeTracker {
+	return &challengeTracker{
+		challenges: make(map[string]*challengeState),
+	}
+}
+
+// attempt records an attempt against a challenge and reports whether it may go ahead.
+func (c *challengeTracker) attempt(id string, expires time.Time, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for challengeId, state := range c.challenges {
+		if now.After(state.expires) {
+			delete(c.challenges, challengeId)
+		}
+	}
+
+	state, ok := c.challenges[id]
+	if !ok {
+		state = &challengeState{expires: expires}
+		c.challenges[id] = state
+	}
+
+	if state.used || state.attempts >= maxChallengeAttempts {
+		return false
+	}
+	state.attempts++
+	return true
+}
+
+func (c *challengeTracker) complete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if state, ok := c.challenges[id]; ok {
+		state.used = true
+	}
+}
+
+func (a *authUseCase) requiresTwoFactor(role models.Role) bool {
+	return slices.Contains(a.twoFactor.RequiredRoles, string(role))
+}
+
+// issueChallenge signs a short lived token proving the user has entered their password.
+func (a *authUseCase) issueChallenge(user models.User) (string, error) {
+	challengeId, err := generateId()
+	if err != nil {
+		return "", err
+	}
+
+	now := a.signer.now().UTC()
+	token, err := a.signer.Sign(tokenClaims{
+		SessionId: challengeId,
+		UserId:    user.Id,
+		Role:      user.Role,
+		Type:      challengeToken,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(a.twoFactor.ChallengeTTL).Unix(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign challenge token")
+	}
+	return token, nil
+}
+
+func (a *authUseCase) ChallengeUserId(token string) (string, error) {
+	claims, err := a.verify(token, challengeToken)
+	if err != nil {
+		return "", ErrInvalidChallenge
+	}
+	return claims.UserId, nil
+}
+
+func (a *authUseCase) CompleteTwoFactor(token string, code string) (*models.UserClaim, error) {
+	claims, err := a.verify(token, challengeToken)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	if !a.challenges.attempt(claims.SessionId, claims.expiry(), a.signer.now()) {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := a.users.GetById(claims.UserId)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	if user.Disabled {
+		return nil, ErrAccountDisabled
+	}
+
+	if !user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	err = a.checkSecondFactor(user, code)
+	if err != nil {
+		return nil, err
+	}
+	a.challenges.complete(claims.SessionId)
+
+	sessionId, err := generateId()
+	if err != nil {
+		return nil, err
+	}
+
+	return a.issueTokens(sessionId, *user)
+}
+
+// checkSecondFactor accepts either a one-time code or an unused recovery code,
+// and records its use so it can't be used again.
+func (a *authUseCase) checkSecondFactor(user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+	twoFactor := *user.TwoFactor
+
+	step, ok := matchTOTP(twoFactor.Secret, code, a.signer.now())
+	if ok {
+		if step <= twoFactor.LastUsedStep {
+			return ErrInvalidTwoFactorCode
+		}
+		twoFactor.LastUsedStep = step
+	} else {
+		hash := hashRecoveryCode(code)
+		index := slices.IndexFunc(twoFactor.RecoveryCodes, func(stored string) bool {
+			return subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1
+		})
+		if index < 0 {
+			return ErrInvalidTwoFactorCode
+		}
+
+		a.Logger.Info("recovery code used", "userId", user.Id, "remaining", len(twoFactor.RecoveryCodes)-1)
+		twoFactor.RecoveryCodes = slices.Delete(slices.Clone(twoFactor.RecoveryCodes), index, index+1)
+	}
+
+	user.TwoFactor = &twoFactor
+	return a.users.Update(*user)
+}
+
+func (a *authUseCase) EnrolTwoFactor(userId string) (*models.TwoFactorEnrolment, error) {
+	user, err := a.users.GetById(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	a.Logger.Info("starting two-factor enrolment", "userId", userId)
+	user.TwoFactor = &models.TwoFactor{Secret: secret}
+	err = a.users.Update(*user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorEnrolment{
+		Secret:          secret,
+		ProvisioningUri: provisioningUri(a.twoFactor.Issuer, user.Username, secret),
+	}, nil
+}
+
+func (a *authUseCase) ConfirmTwoFactor(userId string, code string) ([]string, error) {
+	user, err := a.users.GetById(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactor == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if user.TwoFactor.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := matchTOTP(user.TwoFactor.Secret, strings.TrimSpace(code), a.signer.now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	a.Logger.Info("enabling two-factor authentication", "userId", userId)
+	user.TwoFactor = &models.TwoFactor{
+		Secret:        user.TwoFactor.Secret,
+		Enabled:       true,
+		RecoveryCodes: hashes,
+		LastUsedStep:  step,
+	}
+	err = a.users.Update(*user)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (a *authUseCase) DisableTwoFactor(userId string, code string) error {
+	user, err := a.users.GetById(userId)
+	if err != nil {
+		return err
+	}
+
+	if !user.TwoFactorEnabled() {
+		return ErrTwoFactorNotEnrolled
+	}
+	if a.requiresTwoFactor(user.Role) {
+		return ErrTwoFactorRequired
+	}
+
+	err = a.checkSecondFactor(user, code)
+	if err != nil {
+		return err
+	}
+
+	a.Logger.Info("disabling two-factor authentication", "userId", userId)
+	user.TwoFactor = nil
+	return a.users.Update(*user)
+}
+
+func (a *authUseCase) ResetTwoFactor(userId string) error {
+	user, err := a.users.GetById(userId)
+	if err != nil {
+		return err
+	}
+
+	a.Logger.Info("resetting two-factor authentication", "userId", userId)
+	user.TwoFactor = nil
+	return a.users.Update(*user)
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestAuthUseCase_TwoFactor(t *testing.T) {
+	hash, err := HashPassword("password")
+	assert.NoError(t, err)
+
+	users := mocks.NewUserRepository(t)
+	testUc := newTestAuthUseCase(t, users, nil)
+	testUc.twoFactor.RequiredRoles = []string{string(models.Supplier)}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	testUc.signer.now = func() time.Time { return now }
+
+	// The repository mock stores whatever was last saved.
+	user := models.User{Id: "1", Username: "supplier", Password: hash, Role: models.Supplier}
+	users.On("GetByUsername", "supplier").Return(func(string) *models.User { current := user; return &current }, nil)
+	users.On("GetById", "1").Return(func(string) *models.User { current := user; return &current }, nil)
+	users.On("Update", mock.AnythingOfType("models.User")).Run(func(args mock.Arguments) {
+		user = args.Get(0).(models.User)
+	}).Return(nil)
+
+	// A supplier must enrol before they can log in.
+	_, err = testUc.Authenticate("supplier", "password")
+	var required *TwoFactorRequiredError
+	assert.ErrorAs(t, err, &required)
+	assert.True(t, required.Enrolment)
+
+	userId, err := testUc.ChallengeUserId(required.ChallengeToken)
+	assert.NoError(t, err)
+	enrolment, err := testUc.EnrolTwoFactor(userId)
+	assert.NoError(t, err)
+	assert.Contains(t, enrolment.ProvisioningUri, enrolment.Secret)
+
+	_, err = testUc.ConfirmTwoFactor(userId, "000000")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	code, err := totpCode(enrolment.Secret, totpStep(now))
+	assert.NoError(t, err)
+	recoveryCodes, err := testUc.ConfirmTwoFactor(userId, code)
+	assert.NoError(t, err)
+	assert.Len(t, recoveryCodes, recoveryCodeCount)
+	assert.NotContains(t, user.TwoFactor.RecoveryCodes, recoveryCodes[0])
+
+	// Logging in now asks for a code, and the code used to enrol can't be replayed.
+	_, err = testUc.Authenticate("supplier", "password")
+	assert.ErrorAs(t, err, &required)
+	assert.False(t, required.Enrolment)
+
+	_, err = testUc.CompleteTwoFactor(required.ChallengeToken, code)
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	now = now.Add(totpPeriod)
+	code, err = totpCode(enrolment.Secret, totpStep(now))
+	assert.NoError(t, err)
+	claim, err := testUc.CompleteTwoFactor(required.ChallengeToken, code)
+	assert.NoError(t, err)
+	assert.True(t, testUc.TokenIsValid(claim.Token))
+
+	_, err = testUc.CompleteTwoFactor(required.ChallengeToken, code)
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+	// Recovery codes work once.
+	_, err = testUc.Authenticate("supplier", "password")
+	assert.ErrorAs(t, err, &required)
+	_, err = testUc.CompleteTwoFactor(required.ChallengeToken, recoveryCodes[0])
+	assert.NoError(t, err)
+
+	_, err = testUc.Authenticate("supplier", "password")
+	assert.ErrorAs(t, err, &required)
+	_, err = testUc.CompleteTwoFactor(required.ChallengeToken, recoveryCodes[0])
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	// Suppliers can't turn it off while it's required.
+	assert.ErrorIs(t, testUc.DisableTwoFactor("1", recoveryCodes[1]), ErrTwoFactorRequired)
+}
+
+func TestAuthUseCase_CompleteTwoFactor_LimitsAttempts(t *testing.T) {
+	users := mocks.NewUserRepository(t)
+	testUc := newTestAuthUseCase(t, users, nil)
+
+	secret, err := generateTOTPSecret()
+	assert.NoError(t, err)
+	user := models.User{Id: "1", Role: models.Customer, TwoFactor: &models.TwoFactor{Secret: secret, Enabled: true}}
+	users.On("GetById", "1").Return(&user, nil)
+
+	challenge, err := testUc.issueChallenge(user)
+	assert.NoError(t, err)
+
+	for i := 0; i < maxChallengeAttempts; i++ {
+		_, err = testUc.CompleteTwoFactor(challenge, "not-a-code")
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	}
+
+	code, err := totpCode(secret, totpStep(time.Now()))
+	assert.NoError(t, err)
+	_, err = testUc.CompleteTwoFactor(challenge, code)
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+}
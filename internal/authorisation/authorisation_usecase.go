@@ -0,0 +1,154 @@
+package authorisation
+
+import (
+	"encoding/json"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// OwnerLookup returns the ID of the user that owns a resource, or an empty
+// string if the resource does not exist or has no owner.
+type OwnerLookup func(resourceId string) (string, error)
+
+type authorisationUseCase struct {
+	Policy map[router.HandlerKey]Rule
+	Owners map[Resource]OwnerLookup
+	Auth   domain.AuthUseCase
+	Logger slog.Logger
+}
+
+func NewAuthorisationUseCase(policy map[router.HandlerKey]Rule, owners map[Resource]OwnerLookup, auth domain.AuthUseCase, logger slog.Logger) domain.AuthorisationUseCase {
+	return &authorisationUseCase{
+		Policy: policy,
+		Owners: owners,
+		Auth:   auth,
+		Logger: logger,
+	}
+}
+
+func (a *authorisationUseCase) Authorise(route string, method models.RequestType, token *string, body string) (*models.UserClaim, error) {
+	rule, ok := a.Policy[router.HandlerKey{Route: route, Method: method}]
+	if !ok {
+		a.Logger.Warn("denying request to route without an authorisation rule", "route", route, "method", method)
+		return nil, domain.ErrForbidden
+	}
+
+	if token == nil {
+		if rule.Public {
+			return nil, nil
+		}
+		return nil, domain.ErrUnauthorised
+	}
+
+	userClaim, err := a.Auth.GetUser(*token)
+	if err != nil {
+		if rule.Public {
+			return nil, nil
+		}
+		return nil, domain.ErrUnauthorised
+	}
+
+	if rule.Public {
+		return userClaim, nil
+	}
+
+	if userClaim.IsApiKey() && !slices.ContainsFunc(rule.Scopes, userClaim.HasScope) {
+		a.Logger.Info("denying request for api key scope", "route", route, "method", method, "apiKeyId", userClaim.ApiKeyId)
+		return nil, domain.ErrForbidden
+	}
+
+	if userClaim.IsImpersonated() && !impersonationAllows(route, method, *userClaim) {
+		a.Logger.Info("denying request for impersonation session", "route", route, "method", method, "userId", userClaim.UserId, "impersonatorId", userClaim.ImpersonatorId)
+		return nil, domain.ErrForbidden
+	}
+
+	if userClaim.Role == models.Admin {
+		return userClaim, nil
+	}
+
+	if len(rule.Roles) > 0 && !slices.Contains(rule.Roles, userClaim.Role) {
+		a.Logger.Info("denying request for role", "route", route, "method", method, "userId", userClaim.UserId, "role", userClaim.Role)
+		return nil, domain.ErrForbidden
+	}
+
+	if rule.Resource != "" {
+		err = a.checkOwnership(rule.Resource, userClaim, body)
+		if err != nil {
+			a.Logger.Info("denying request for resource", "route", route, "method", method, "userId", userClaim.UserId, "error", err)
+			return nil, err
+		}
+	}
+
+	return userClaim, nil
+}
+
+// impersonationAllows reports whether an admin impersonating a user may call the
+// route. Read only sessions may only call GET routes, and no impersonation
+// session may change the user's credentials, though any may log out.
+func impersonationAllows(route string, method models.RequestType, userClaim models.UserClaim) bool {
+	switch {
+	case method == models.GET:
+		return true
+	case route == "/auth/logout":
+		return true
+	case userClaim.ReadOnly:
+		return false
+	default:
+		return route != "/auth" && !strings.HasPrefix(route, "/auth/")
+	}
+}
+
+func (a *authorisationUseCase) checkOwnership(resource Resource, userClaim *models.UserClaim, body string) error {
+	lookup, ok := a.Owners[resource]
+	if !ok {
+		return errors.Wrapf(domain.ErrForbidden, "no owner lookup for resource %s", resource)
+	}
+
+	var request models.RequestById
+	err := json.Unmarshal([]byte(body), &request)
+	if err != nil || request.Id == "" {
+		return errors.Wrap(domain.ErrForbidden, "request does not identify a resource")
+	}
+
+	ownerId, err := lookup(request.Id)
+	if err != nil {
+		return err
+	}
+
+	if ownerId != userClaim.UserId {
+		return domain.ErrForbidden
+	}
+
+	return nil
+}
+
+// NewRouterGuard adapts an AuthorisationUseCase into a router guard that rejects
+// requests with 401 or 403 and otherwise attaches the caller to the context,
+// recording the request against the caller's session.
+func NewRouterGuard(uc domain.AuthorisationUseCase, auth domain.AuthUseCase) router.Guard {
+	return func(key router.HandlerKey, ctx *router.RouterContext) bool {
+		userClaim, err := uc.Authorise(key.Route, key.Method, ctx.GetAuthToken(), ctx.Body)
+		switch {
+		case errors.Is(err, domain.ErrUnauthorised):
+			ctx.JSON(401, models.NewErrorResponse(401, "Unauthorized"))
+			return false
+		case errors.Is(err, domain.ErrForbidden):
+			ctx.JSON(403, models.NewErrorResponse(403, "Forbidden"))
+			return false
+		case err != nil:
+			ctx.JSON(500, models.NewInternalServerError())
+			return false
+		}
+
+		ctx.User = userClaim
+		if userClaim != nil && userClaim.SessionId != "" {
+			auth.TouchSession(userClaim.SessionId, ctx.Sender)
+		}
+		return true
+	}
+}
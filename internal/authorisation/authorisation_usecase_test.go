@@ -0,0 +1,211 @@
+package authorisation
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+func TestAuthorisationUseCase_Authorise(t *testing.T) {
+	policy := map[router.HandlerKey]Rule{
+		{Route: "/public", Method: models.GET}:        public,
+		{Route: "/any", Method: models.GET}:           anyUser,
+		{Route: "/supplier", Method: models.POST}:     supplierOnly,
+		{Route: "/product", Method: models.PUT}:       ownProductOnly,
+		{Route: "/admin", Method: models.DELETE}:      adminOnly,
+		{Route: "/read", Method: models.GET}:          anyUser.withScopes(models.ProductsRead),
+		{Route: "/any", Method: models.POST}:          anyUser,
+		{Route: "/auth/password", Method: models.PUT}: anyUser,
+	}
+	owners := map[Resource]OwnerLookup{
+		ProductResource: func(id string) (string, error) {
+			if id == "owned" {
+				return "supplier", nil
+			}
+			return "someone-else", nil
+		},
+	}
+
+	supplier := &models.UserClaim{UserId: "supplier", Role: models.Supplier}
+	customer := &models.UserClaim{UserId: "customer", Role: models.Customer}
+	admin := &models.UserClaim{UserId: "admin", Role: models.Admin}
+	readKey := &models.UserClaim{UserId: "supplier", Role: models.Supplier, ApiKeyId: "key", Scopes: []models.ApiKeyScope{models.ProductsRead}}
+	impersonated := &models.UserClaim{UserId: "customer", Role: models.Customer, ImpersonatorId: "admin", ReadOnly: true}
+	impersonatedWrites := &models.UserClaim{UserId: "customer", Role: models.Customer, ImpersonatorId: "admin"}
+	adminKey := &models.UserClaim{UserId: "admin", Role: models.Admin, ApiKeyId: "key", Scopes: []models.ApiKeyScope{models.StockAdjust}}
+
+	testCases := []struct {
+		name        string
+		route       string
+		method      models.RequestType
+		token       string
+		claim       *models.UserClaim
+		body        string
+		expectedErr error
+	}{
+		{
+			name:   "Happy path - public without token",
+			route:  "/public",
+			method: models.GET,
+		},
+		{
+			name:   "Happy path - any user",
+			route:  "/any",
+			method: models.GET,
+			token:  "customer",
+			claim:  customer,
+		},
+		{
+			name:   "Happy path - supplier role",
+			route:  "/supplier",
+			method: models.POST,
+			token:  "supplier",
+			claim:  supplier,
+		},
+		{
+			name:   "Happy path - supplier owns product",
+			route:  "/product",
+			method: models.PUT,
+			token:  "supplier",
+			claim:  supplier,
+			body:   `{"id":"owned"}`,
+		},
+		{
+			name:   "Happy path - admin bypasses roles and ownership",
+			route:  "/product",
+			method: models.PUT,
+			token:  "admin",
+			claim:  admin,
+			body:   `{"id":"not-owned"}`,
+		},
+		{
+			name:   "Happy path - api key with scope",
+			route:  "/read",
+			method: models.GET,
+			token:  "dk_read",
+			claim:  readKey,
+		},
+		{
+			name:        "Sad path - api key on route without scopes",
+			route:       "/any",
+			method:      models.GET,
+			token:       "dk_read",
+			claim:       readKey,
+			expectedErr: domain.ErrForbidden,
+		},
+		{
+			name:        "Sad path - admin api key without scope",
+			route:       "/read",
+			method:      models.GET,
+			token:       "dk_admin",
+			claim:       adminKey,
+			expectedErr: domain.ErrForbidden,
+		},
+		{
+			name:        "Sad path - missing token",
+			route:       "/any",
+			method:      models.GET,
+			expectedErr: domain.ErrUnauthorised,
+		},
+		{
+			name:        "Sad path - invalid token",
+			route:       "/any",
+			method:      models.GET,
+			token:       "invalid",
+			expectedErr: domain.ErrUnauthorised,
+		},
+		{
+			name:        "Sad path - customer calling supplier route",
+			route:       "/supplier",
+			method:      models.POST,
+			token:       "customer",
+			claim:       customer,
+			expectedErr: domain.ErrForbidden,
+		},
+		{
+			name:        "Sad path - supplier does not own product",
+			route:       "/product",
+			method:      models.PUT,
+			token:       "supplier",
+			claim:       supplier,
+			body:        `{"id":"not-owned"}`,
+			expectedErr: domain.ErrForbidden,
+		},
+		{
+			name:        "Sad path - supplier calling admin route",
+			route:       "/admin",
+			method:      models.DELETE,
+			token:       "supplier",
+			claim:       supplier,
+			expectedErr: domain.ErrForbidden,
+		},
+		{
+			name:   "Happy path - read only impersonation reads",
+			route:  "/any",
+			method: models.GET,
+			token:  "impersonated",
+			claim:  impersonated,
+		},
+		{
+			name:        "Sad path - read only impersonation writes",
+			route:       "/any",
+			method:      models.POST,
+			token:       "impersonated",
+			claim:       impersonated,
+			expectedErr: domain.ErrForbidden,
+		},
+		{
+			name:   "Happy path - impersonation with writes",
+			route:  "/any",
+			method: models.POST,
+			token:  "impersonatedWrites",
+			claim:  impersonatedWrites,
+		},
+		{
+			name:        "Sad path - impersonation changes credentials",
+			route:       "/auth/password",
+			method:      models.PUT,
+			token:       "impersonatedWrites",
+			claim:       impersonatedWrites,
+			expectedErr: domain.ErrForbidden,
+		},
+		{
+			name:        "Sad path - route missing from policy",
+			route:       "/unknown",
+			method:      models.GET,
+			token:       "admin",
+			expectedErr: domain.ErrForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auth := mocks.NewAuthUseCase(t)
+			testUc := NewAuthorisationUseCase(policy, owners, auth, *slog.Default())
+
+			var token *string
+			if tc.token != "" {
+				token = &tc.token
+				if tc.claim != nil {
+					auth.On("GetUser", tc.token).Return(tc.claim, nil).Maybe()
+				} else {
+					auth.On("GetUser", tc.token).Return(nil, assert.AnError).Maybe()
+				}
+			}
+
+			claim, err := testUc.Authorise(tc.route, tc.method, token, tc.body)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, claim)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.claim, claim)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package authorisation
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+)
+
+type Resource string
+
+const (
+	ProductResource Resource = "product"
+)
+
+// Rule describes who may call a route. Admins may call every route that isn't
+// public and are never subject to ownership checks.
+type Rule struct {
+	// Public routes can be called without a token.
+	Public bool
+
+	// Roles allowed to call the route. Empty means any authenticated user.
+	Roles []models.Role
+
+	// Resource the route acts upon, identified by the "id" field of the request
+	// body. When set, the caller must own the resource.
+	Resource Resource
+
+	// Scopes an API key must hold one of to call the route. API keys are
+	// refused on routes without any scopes.
+	Scopes []models.ApiKeyScope
+}
+
+// withScopes returns a copy of the rule that API keys holding one of scopes may
+// also call.
+func (r Rule) withScopes(scopes ...models.ApiKeyScope) Rule {
+	r.Scopes = scopes
+	return r
+}
+
+var (
+	anyUser        = Rule{}
+	public         = Rule{Public: true}
+	adminOnly      = Rule{Roles: []models.Role{models.Admin}}
+	supplierOnly   = Rule{Roles: []models.Role{models.Supplier}}
+	ownProductOnly = Rule{Roles: []models.Role{models.Supplier}, Resource: ProductResource}
+)
+
+// Policy is the authorisation rule for every route. Routes missing from the
+// policy are denied.
+var Policy = map[router.HandlerKey]Rule{
+	{Route: "/auth", Method: models.POST}:             public,
+	{Route: "/auth/refresh", Method: models.POST}:     public,
+	{Route: "/auth/logout", Method: models.POST}:      anyUser,
+	{Route: "/auth/impersonate", Method: models.POST}: adminOnly,
+	{Route: "/auth/revoke", Method: models.POST}:      adminOnly,
+	{Route: "/auth/users", Method: models.GET}:        anyUser,
+
+	{Route: "/auth/password", Method: models.PUT}:        anyUser,
+	{Route: "/auth/password/reset", Method: models.POST}: public,
+	{Route: "/auth/password/reset", Method: models.PUT}:  public,
+	{Route: "/auth/lockouts", Method: models.GET}:        adminOnly,
+	{Route: "/auth/lockouts", Method: models.DELETE}:     adminOnly,
+	{Route: "/auth/2fa", Method: models.POST}:            public,
+	{Route: "/auth/2fa", Method: models.DELETE}:          anyUser,
+	{Route: "/auth/2fa/enrol", Method: models.POST}:      public,
+	{Route: "/auth/2fa/enrol", Method: models.PUT}:       public,
+	{Route: "/auth/2fa/reset", Method: models.POST}:      adminOnly,
+	{Route: "/auth/sessions", Method: models.GET}:        anyUser,
+	{Route: "/auth/sessions", Method: models.DELETE}:     anyUser,
+
+	{Route: "/user", Method: models.POST}:         adminOnly,
+	{Route: "/user", Method: models.PUT}:          adminOnly,
+	{Route: "/user", Method: models.DELETE}:       adminOnly,
+	{Route: "/user/disable", Method: models.POST}: adminOnly,
+	{Route: "/user/role", Method: models.PUT}:     adminOnly,
+
+	{Route: "/broadcast/subscribe", Method: models.POST}: public,
+	{Route: "/broadcast/user", Method: models.POST}:      anyUser,
+	{Route: "/broadcast/user", Method: models.DELETE}:    public,
+
+	{Route: "/organisation", Method: models.GET}:     adminOnly,
+	{Route: "/organisation/all", Method: models.GET}: adminOnly,
+	{Route: "/organisation", Method: models.POST}:    adminOnly,
+	{Route: "/organisation", Method: models.PUT}:     adminOnly,
+	{Route: "/organisation", Method: models.DELETE}:  adminOnly,
+
+	{Route: "/audit", Method: models.GET}: adminOnly,
+
+	{Route: "/apikey", Method: models.POST}:    adminOnly,
+	{Route: "/apikey/all", Method: models.GET}: adminOnly,
+	{Route: "/apikey", Method: models.DELETE}:  adminOnly,
+
+	{Route: "/product", Method: models.GET}:                      anyUser.withScopes(models.ProductsRead),
+	{Route: "/product/all", Method: models.GET}:                  anyUser.withScopes(models.ProductsRead),
+	{Route: "/product/search", Method: models.GET}:               anyUser.withScopes(models.ProductsRead),
+	{Route: "/product", Method: models.POST}:                     supplierOnly.withScopes(models.ProductsWrite),
+	{Route: "/product", Method: models.PUT}:                      ownProductOnly.withScopes(models.ProductsWrite, models.StockAdjust),
+	{Route: "/product", Method: models.DELETE}:                   ownProductOnly.withScopes(models.ProductsWrite),
+	{Route: "/product/subscribe", Method: models.POST}:           anyUser,
+	{Route: "/product/unsubscribe", Method: models.POST}:         anyUser,
+	{Route: "/product/subscriptions", Method: models.GET}:        anyUser,
+	{Route: "/product/stock", Method: models.POST}:               ownProductOnly.withScopes(models.StockAdjust, models.ProductsWrite),
+	{Route: "/product/stock", Method: models.GET}:                ownProductOnly.withScopes(models.ProductsRead),
+	{Route: "/product/threshold", Method: models.PUT}:            anyUser,
+	{Route: "/product/thresholds", Method: models.GET}:           anyUser,
+	{Route: "/product/import", Method: models.POST}:              supplierOnly.withScopes(models.ProductsWrite),
+	{Route: "/product/stock/level", Method: models.GET}:          anyUser.withScopes(models.ProductsRead),
+	{Route: "/product/reservation", Method: models.POST}:         anyUser,
+	{Route: "/product/reservation", Method: models.PUT}:          anyUser,
+	{Route: "/product/reservation/convert", Method: models.POST}: anyUser,
+	{Route: "/product/reservation/release", Method: models.POST}: anyUser,
+	{Route: "/product/reservations", Method: models.GET}:         anyUser,
+	{Route: "/product/export", Method: models.GET}:               supplierOnly.withScopes(models.ProductsRead),
+
+	{Route: "/notification", Method: models.GET}:      anyUser,
+	{Route: "/notification", Method: models.DELETE}:   anyUser,
+	{Route: "/notification/all", Method: models.POST}: supplierOnly,
+
+	{Route: "/chat/thumbnails", Method: models.GET}: anyUser,
+	{Route: "/chat", Method: models.GET}:            anyUser,
+	{Route: "/chat/messages", Method: models.GET}:   anyUser,
+	{Route: "/chat", Method: models.POST}:           anyUser,
+	{Route: "/chat/message", Method: models.POST}:   anyUser,
+}
+
+// IsRoleGated reports whether the route may only be called by particular roles.
+func IsRoleGated(key router.HandlerKey) bool {
+	rule, ok := Policy[key]
+	return ok && len(rule.Roles) > 0
+}
@@ -45,11 +45,11 @@ func (b *BroadcastHandler) RegisterUser(ctx *routerUc.RouterContext) {
 
 	userClaim, err := b.Auth.GetUser(*token)
 	if err != nil {
-		ctx.JSON(500, models.NewInternalServerError())
+		ctx.JSON(401, models.NewErrorResponse(401, "Unauthorized"))
 		return
 	}
 
-	b.BroadcastUseCase.RegisterUser(ctx.Sender, userClaim.UserId)
+	b.BroadcastUseCase.RegisterUser(ctx.Sender, userClaim.UserId, userClaim.SessionId)
 	ctx.JSON(200, models.NewSuccessResponse(200, "Registered user"))
 }
 
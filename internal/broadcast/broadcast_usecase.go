@@ -7,19 +7,28 @@ import (
 	"github.com/pkg/errors"
 	"log/slog"
 	"net"
+	"sync"
+	"time"
 )
 
+// How long to wait when connecting to a client to publish a message.
+const publishTimeout = 5 * time.Second
+
+// BroadcastUseCase tracks the connections subscribed to broadcasts. Connections
+// are registered and removed from each connection's own goroutine, so every
+// access goes through mu.
 type BroadcastUseCase struct {
 	Logger      slog.Logger
-	Connections []models.BroadcastConnection
 	Encryption  domain.EncryptionUseCase
+	mu          sync.RWMutex
+	connections []models.BroadcastConnection
 }
 
 func NewBroadcastUseCase(logger slog.Logger, encryption domain.EncryptionUseCase) *BroadcastUseCase {
 	return &BroadcastUseCase{
 		Logger:      logger,
 		Encryption:  encryption,
-		Connections: make([]models.BroadcastConnection, 0),
+		connections: make([]models.BroadcastConnection, 0),
 	}
 }
 
@@ -35,13 +44,12 @@ func (b *BroadcastUseCase) PublishToUsers(message string, eventType string, user
 
 	for _, user := range users {
 		b.Logger.Info("sending message to user", "message", message, "user", user)
-		for _, conn := range b.Connections {
-			if conn.UserId == user {
-				err := b.publishMessage(messageBytes, conn.SubscribeAddress)
-				if err != nil {
-					b.Logger.Error("failed to publish message", "error", err)
-					continue
-				}
+		// Publishing dials out to the client, so don't hold the lock while doing it.
+		for _, address := range b.subscribeAddresses(user) {
+			err := b.publishMessage(messageBytes, address)
+			if err != nil {
+				b.Logger.Error("failed to publish message", "error", err)
+				continue
 			}
 		}
 	}
@@ -49,12 +57,26 @@ func (b *BroadcastUseCase) PublishToUsers(message string, eventType string, user
 	return nil
 }
 
+func (b *BroadcastUseCase) subscribeAddresses(userId string) []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var addresses []string
+	for _, conn := range b.connections {
+		if conn.UserId == userId {
+			addresses = append(addresses, conn.SubscribeAddress)
+		}
+	}
+	return addresses
+}
+
 func (b *BroadcastUseCase) publishMessage(msg []byte, address string) error {
 	b.Logger.Info("sending message", "message", string(msg), "remoteAddress", address)
-	connClient, err := net.Dial("tcp", address)
+	connClient, err := net.DialTimeout("tcp", address, publishTimeout)
 	if err != nil {
 		return errors.Wrapf(err, "failed to dial connection: ")
 	}
+	defer connClient.Close()
 
 	encrypted, err := b.Encryption.Encrypt(string(msg))
 	if err != nil {
@@ -71,7 +93,10 @@ func (b *BroadcastUseCase) publishMessage(msg []byte, address string) error {
 
 func (b *BroadcastUseCase) AddConnection(subscribeAddress string, publishAddress string) {
 	b.Logger.Info("adding connection to broadcast use case", "subscribeAddress", subscribeAddress, "publishAddress", publishAddress)
-	b.Connections = append(b.Connections, models.BroadcastConnection{
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.connections = append(b.connections, models.BroadcastConnection{
 		SubscribeAddress: subscribeAddress,
 		PublishAddress:   publishAddress,
 	})
@@ -79,27 +104,67 @@ func (b *BroadcastUseCase) AddConnection(subscribeAddress string, publishAddress
 
 func (b *BroadcastUseCase) RemoveConnection(addr string) {
 	b.Logger.Info("removing connection from broadcast use case", "address", addr)
-	for i, conn := range b.Connections {
-		if conn.PublishAddress == addr || conn.SubscribeAddress == addr {
-			b.Connections = append(b.Connections[:i], b.Connections[i+1:]...)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	connections := make([]models.BroadcastConnection, 0, len(b.connections))
+	for _, conn := range b.connections {
+		if conn.PublishAddress != addr && conn.SubscribeAddress != addr {
+			connections = append(connections, conn)
 		}
 	}
+	b.connections = connections
 }
 
-func (b *BroadcastUseCase) RegisterUser(addr string, userId string) {
+func (b *BroadcastUseCase) RegisterUser(addr string, userId string, sessionId string) {
 	b.Logger.Info("registering user to broadcast use case", "address", addr, "userId", userId)
-	for i, conn := range b.Connections {
-		if conn.PublishAddress == addr {
-			b.Connections[i].UserId = userId
-		}
-	}
+	b.updateConnections(func(conn models.BroadcastConnection) bool {
+		return conn.PublishAddress == addr
+	}, userId, sessionId)
 }
 
 func (b *BroadcastUseCase) RemoveUser(addr string) {
 	b.Logger.Info("removing user from broadcast use case", "address", addr)
-	for i, conn := range b.Connections {
-		if conn.PublishAddress == addr {
-			b.Connections[i].UserId = ""
+	b.updateConnections(func(conn models.BroadcastConnection) bool {
+		return conn.PublishAddress == addr
+	}, "", "")
+}
+
+func (b *BroadcastUseCase) RemoveSession(sessionId string) {
+	b.Logger.Info("removing session from broadcast use case", "sessionId", sessionId)
+	b.updateConnections(func(conn models.BroadcastConnection) bool {
+		return conn.SessionId == sessionId
+	}, "", "")
+}
+
+func (b *BroadcastUseCase) RemoveUserSessions(userId string) {
+	b.Logger.Info("removing all sessions for user from broadcast use case", "userId", userId)
+	b.updateConnections(func(conn models.BroadcastConnection) bool {
+		return conn.UserId == userId
+	}, "", "")
+}
+
+// updateConnections sets the user and session of every connection matching match.
+func (b *BroadcastUseCase) updateConnections(match func(conn models.BroadcastConnection) bool, userId string, sessionId string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, conn := range b.connections {
+		if match(conn) {
+			b.connections[i].UserId = userId
+			b.connections[i].SessionId = sessionId
+		}
+	}
+}
+
+func (b *BroadcastUseCase) IsSessionConnected(sessionId string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, conn := range b.connections {
+		if conn.SessionId == sessionId {
+			return true
 		}
 	}
+	return false
 }
@@ -3,8 +3,10 @@ package chat
 import (
 	"encoding/json"
 	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
 	"github.com/kkcaz/shu-dades-server/internal/router"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
 )
 
 type ChatHandler struct {
@@ -20,6 +22,7 @@ func NewChatHandler(router *router.RouterUseCase, chatUseCase domain.ChatUseCase
 
 	router.AddRoute("/chat/thumbnails", models.GET, handler.GetChatThumbnails)
 	router.AddRoute("/chat", models.GET, handler.GetChat)
+	router.AddRoute("/chat/messages", models.GET, handler.GetMessages)
 	router.AddRoute("/chat", models.POST, handler.CreateChat)
 	router.AddRoute("/chat/message", models.POST, handler.SendMessage)
 }
@@ -69,7 +72,12 @@ func (c ChatHandler) GetChat(ctx *router.RouterContext) {
 		return
 	}
 
-	chat, err := c.ChatUseCase.GetChat(request.Id)
+	chat, err := c.ChatUseCase.GetChat(userClaim.UserId, request.Id)
+	if errors.Is(err, ErrNotParticipant) {
+		ctx.JSON(403, models.NewErrorResponse(403, "Forbidden"))
+		return
+	}
+
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
@@ -80,17 +88,46 @@ func (c ChatHandler) GetChat(ctx *router.RouterContext) {
 		return
 	}
 
-	for _, participant := range chat.Participants {
-		if participant.UserId == userClaim.UserId {
-			ctx.JSON(200, models.ChatResponse{
-				StatusCode: 200,
-				Chat:       chat,
-			})
-			return
-		}
+	ctx.JSON(200, models.ChatResponse{
+		StatusCode: 200,
+		Chat:       chat,
+	})
+}
+
+func (c ChatHandler) GetMessages(ctx *router.RouterContext) {
+	var request models.ChatMessagesRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	messages, pageInfo, err := c.ChatUseCase.GetMessages(ctx.User.UserId, request)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if errors.Is(err, ErrNotParticipant) {
+		ctx.JSON(403, models.NewErrorResponse(403, "Forbidden"))
+		return
+	}
+
+	if errors.Is(err, ErrChatNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Chat not found"))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
 	}
 
-	ctx.JSON(403, models.NewErrorResponse(403, "Forbidden"))
+	ctx.JSON(200, models.ChatMessagesResponse{
+		StatusCode: 200,
+		Messages:   messages,
+		PageInfo:   pageInfo,
+	})
 }
 
 func (c ChatHandler) CreateChat(ctx *router.RouterContext) {
@@ -101,7 +138,12 @@ func (c ChatHandler) CreateChat(ctx *router.RouterContext) {
 		return
 	}
 
-	chat, err := c.ChatUseCase.CreateChat(request.UserIds)
+	chat, err := c.ChatUseCase.CreateChat(ctx.User.UserId, request.UserIds)
+	if errors.Is(err, ErrParticipantNotVisible) {
+		ctx.JSON(403, models.NewErrorResponse(403, err.Error()))
+		return
+	}
+
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
@@ -134,6 +176,11 @@ func (c ChatHandler) SendMessage(ctx *router.RouterContext) {
 	}
 
 	err = c.ChatUseCase.SendMessage(request.ChatId, request.Message, userClaim.UserId)
+	if errors.Is(err, ErrNotParticipant) {
+		ctx.JSON(403, models.NewErrorResponse(403, "Forbidden"))
+		return
+	}
+
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
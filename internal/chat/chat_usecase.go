@@ -4,12 +4,20 @@ import (
 	"encoding/json"
 	"github.com/google/uuid"
 	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
 	"github.com/pkg/errors"
 	"log/slog"
+	"slices"
 	"time"
 )
 
+var (
+	ErrChatNotFound          = errors.New("chat not found")
+	ErrNotParticipant        = errors.New("user is not a participant in the chat")
+	ErrParticipantNotVisible = errors.New("participant is outside of the user's organisations")
+)
+
 type chatUseCase struct {
 	Repository domain.ChatRepository
 	Auth       domain.AuthUseCase
@@ -45,21 +53,73 @@ func (c *chatUseCase) GetChatThumbnails(userId string) ([]models.ChatThumbnail,
 	return userThumbnails, nil
 }
 
-func (c *chatUseCase) GetChat(chatId string) (*models.Chat, error) {
+// GetChat returns the chat if it exists, or ErrNotParticipant if userId isn't part of it.
+func (c *chatUseCase) GetChat(userId string, chatId string) (*models.Chat, error) {
 	chat, err := c.Repository.GetChat(chatId)
 	if err != nil {
 		return nil, err
 	}
 
+	if chat != nil && !isParticipant(*chat, userId) {
+		return nil, ErrNotParticipant
+	}
+
 	return chat, nil
 }
 
+// GetMessages returns a page of the chat's messages, newest first. Messages are
+// only ever appended, so their positions in the chat are stable keys for
+// paging while new messages arrive.
+func (c *chatUseCase) GetMessages(userId string, request models.ChatMessagesRequest) ([]models.Message, models.PageInfo, error) {
+	chat, err := c.GetChat(userId, request.ChatId)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	if chat == nil {
+		return nil, models.PageInfo{}, ErrChatNotFound
+	}
+
+	positions := make([]int, len(chat.Messages))
+	for i := range positions {
+		positions[i] = len(chat.Messages) - 1 - i
+	}
+
+	page, pageInfo, err := pagination.Paginate(positions, request.PageRequest, pagination.List(request.ChatId), func(position int) int {
+		return position
+	}, func(position int, key int) bool {
+		return position < key
+	})
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	messages := make([]models.Message, 0, len(page))
+	for _, position := range page {
+		messages = append(messages, chat.Messages[position])
+	}
+	return messages, pageInfo, nil
+}
+
+func isParticipant(chat models.Chat, userId string) bool {
+	for _, participant := range chat.Participants {
+		if participant.UserId == userId {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *chatUseCase) GetChatParticipantIds(chatId string) ([]string, error) {
-	chat, err := c.GetChat(chatId)
+	chat, err := c.Repository.GetChat(chatId)
 	if err != nil {
 		return nil, err
 	}
 
+	if chat == nil {
+		return nil, errors.Errorf("chat %s not found", chatId)
+	}
+
 	var participantIds = make([]string, 0)
 	for _, participant := range chat.Participants {
 		participantIds = append(participantIds, participant.UserId)
@@ -68,9 +128,19 @@ func (c *chatUseCase) GetChatParticipantIds(chatId string) ([]string, error) {
 	return participantIds, nil
 }
 
-func (c *chatUseCase) CreateChat(userIds []string) (*models.Chat, error) {
+// CreateChat starts a chat between the creator and userIds, who must all be
+// visible to the creator.
+func (c *chatUseCase) CreateChat(creatorId string, userIds []string) (*models.Chat, error) {
+	if !slices.Contains(userIds, creatorId) {
+		userIds = append([]string{creatorId}, userIds...)
+	}
+
 	participants := make([]models.Participant, 0)
 	for _, userId := range userIds {
+		if !c.Auth.CanSeeUser(creatorId, userId) {
+			return nil, ErrParticipantNotVisible
+		}
+
 		user, err := c.Auth.GetUserById(userId)
 		if err != nil {
 			return nil, err
@@ -103,6 +173,15 @@ func (c *chatUseCase) CreateChat(userIds []string) (*models.Chat, error) {
 }
 
 func (c *chatUseCase) SendMessage(chatId string, content string, userId string) error {
+	chat, err := c.GetChat(userId, chatId)
+	if err != nil {
+		return err
+	}
+
+	if chat == nil {
+		return errors.Errorf("chat %s not found", chatId)
+	}
+
 	user, err := c.Auth.GetUserById(userId)
 	if err != nil {
 		return err
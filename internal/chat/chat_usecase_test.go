@@ -70,17 +70,37 @@ func TestChatUseCase_GetChatThumbnails(t *testing.T) {
 
 func TestChatUseCase_GetChat(t *testing.T) {
 	testCases := []struct {
-		name     string
-		chatId   string
-		expected *models.Chat
-		err      error
+		name        string
+		chatId      string
+		userId      string
+		chat        *models.Chat
+		expected    *models.Chat
+		err         error
+		expectedErr error
 	}{
 		{
 			name:   "Happy path",
 			chatId: "test",
+			userId: "test",
+			chat: &models.Chat{
+				Id:           "test",
+				Participants: []models.Participant{{UserId: "test"}},
+			},
 			expected: &models.Chat{
-				Id: "test",
+				Id:           "test",
+				Participants: []models.Participant{{UserId: "test"}},
+			},
+		},
+		{
+			name:   "Sad path - not a participant",
+			chatId: "test",
+			userId: "john",
+			chat: &models.Chat{
+				Id:           "test",
+				Participants: []models.Participant{{UserId: "test"}},
 			},
+			expected:    nil,
+			expectedErr: ErrNotParticipant,
 		},
 		{
 			name:     "Sad path",
@@ -98,10 +118,13 @@ func TestChatUseCase_GetChat(t *testing.T) {
 			logger := slog.Default()
 			testUc := NewChatUseCase(repo, auth, broadcast, *logger)
 
-			repo.On("GetChat", tc.chatId).Return(tc.expected, tc.err)
+			repo.On("GetChat", tc.chatId).Return(tc.chat, tc.err)
 
-			result, err := testUc.GetChat(tc.chatId)
+			result, err := testUc.GetChat(tc.userId, tc.chatId)
 			assert.Equal(t, tc.expected, result)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			}
 			if err != nil {
 				assert.Error(t, err)
 			}
@@ -158,11 +181,44 @@ func TestChatUseCase_GetChatParticipantIds(t *testing.T) {
 	}
 }
 
+func TestChatUseCase_GetMessages(t *testing.T) {
+	chat := &models.Chat{
+		Id:           "test",
+		Participants: []models.Participant{{UserId: "test"}},
+		Messages:     []models.Message{{Content: "1"}, {Content: "2"}, {Content: "3"}},
+	}
+
+	repo := mocks.NewChatRepository(t)
+	testUc := NewChatUseCase(repo, nil, nil, *slog.Default())
+	repo.On("GetChat", "test").Return(chat, nil)
+	repo.On("GetChat", "missing").Return(nil, nil)
+
+	messages, pageInfo, err := testUc.GetMessages("test", models.ChatMessagesRequest{ChatId: "test", PageRequest: models.PageRequest{Limit: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Message{{Content: "3"}, {Content: "2"}}, messages)
+	assert.True(t, pageInfo.HasMore)
+	assert.Equal(t, 3, pageInfo.Total)
+
+	// New messages don't shift older pages.
+	chat.Messages = append(chat.Messages, models.Message{Content: "4"})
+	messages, pageInfo, err = testUc.GetMessages("test", models.ChatMessagesRequest{ChatId: "test", PageRequest: models.PageRequest{Cursor: pageInfo.NextCursor, Limit: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Message{{Content: "1"}}, messages)
+	assert.False(t, pageInfo.HasMore)
+
+	_, _, err = testUc.GetMessages("john", models.ChatMessagesRequest{ChatId: "test"})
+	assert.ErrorIs(t, err, ErrNotParticipant)
+
+	_, _, err = testUc.GetMessages("test", models.ChatMessagesRequest{ChatId: "missing"})
+	assert.ErrorIs(t, err, ErrChatNotFound)
+}
+
 func TestChatUseCase_CreateChat(t *testing.T) {
 	testCases := []struct {
 		name       string
 		userIds    []string
 		user       *models.User
+		hidden     bool
 		authErr    error
 		expected   *models.Chat
 		createErr  error
@@ -186,6 +242,11 @@ func TestChatUseCase_CreateChat(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "Sad path - participant in another organisation",
+			userIds: []string{"test", "other"},
+			hidden:  true,
+		},
 		{
 			name:       "Sad path - auth error",
 			userIds:    []string{"test"},
@@ -229,15 +290,25 @@ func TestChatUseCase_CreateChat(t *testing.T) {
 			logger := slog.Default()
 			testUc := NewChatUseCase(repo, auth, broadcast, *logger)
 
-			auth.On("GetUserById", mock.AnythingOfType("string")).Return(tc.user, tc.authErr)
-			if tc.authErr == nil {
+			auth.On("CanSeeUser", "test", "test").Return(true)
+			if tc.hidden {
+				auth.On("GetUserById", "test").Return(&models.User{Id: "test"}, nil)
+				auth.On("CanSeeUser", "test", "other").Return(false)
+			} else {
+				auth.On("GetUserById", mock.AnythingOfType("string")).Return(tc.user, tc.authErr)
+			}
+			if tc.authErr == nil && !tc.hidden {
 				repo.On("CreateChat", mock.AnythingOfType("models.Chat")).Return(tc.createErr)
 				if tc.createErr == nil {
 					broadcast.On("PublishToUsers", mock.AnythingOfType("string"), "newChat", tc.userIds).Return(tc.publishErr)
 				}
 			}
 
-			result, err := testUc.CreateChat(tc.userIds)
+			result, err := testUc.CreateChat("test", tc.userIds)
+			if tc.hidden {
+				assert.ErrorIs(t, err, ErrParticipantNotVisible)
+				return
+			}
 			if tc.createErr == nil && tc.authErr == nil && tc.publishErr == nil {
 				assert.Equal(t, tc.expected.Participants, result.Participants)
 				assert.Equal(t, 0, len(result.Messages))
@@ -294,16 +365,16 @@ func TestChatUseCase_SendMessage(t *testing.T) {
 			auth.On("GetUserById", mock.AnythingOfType("string")).Return(&models.User{
 				Id: tc.userId,
 			}, nil)
+			repo.On("GetChat", tc.chatId).Return(&models.Chat{
+				Id: tc.chatId,
+				Participants: []models.Participant{
+					{
+						UserId: tc.userId,
+					},
+				},
+			}, nil)
 			repo.On("AddMessage", tc.chatId, mock.AnythingOfType("models.Message")).Return(tc.addErr)
 			if tc.addErr == nil {
-				repo.On("GetChat", tc.chatId).Return(&models.Chat{
-					Id: tc.chatId,
-					Participants: []models.Participant{
-						{
-							UserId: tc.userId,
-						},
-					},
-				}, nil)
 				broadcast.On("PublishToUsers", mock.AnythingOfType("string"), "message", mock.AnythingOfType("[]string")).Return(tc.notifyErr)
 			}
 
@@ -6,12 +6,18 @@ import (
 	"github.com/pkg/errors"
 	"log/slog"
 	"os"
+	"time"
 )
 
 const defaultConfigPath = "development-config.yaml"
 
 type Config struct {
 	Service Service `yaml:"service"`
+	Auth    Auth    `yaml:"auth"`
+	Mail    Mail    `yaml:"mail"`
+	Audit   Audit   `yaml:"audit"`
+	Storage Storage `yaml:"storage"`
+	Product Product `yaml:"product"`
 }
 
 type Service struct {
@@ -19,6 +25,90 @@ type Service struct {
 	Host       string `yaml:"host" env:"HOST" env-default:"localhost"`
 	Port       string `yaml:"port" env:"PORT" env-default:"8080"`
 	SocketType string `yaml:"socketType" env:"SOCKET_TYPE" env-default:"tcp"`
+
+	// Either "development" or "production". Production refuses to start without
+	// the secrets that development can do without.
+	Environment string `yaml:"environment" env:"ENVIRONMENT" env-default:"production"`
+}
+
+type Auth struct {
+	// Secret used to sign session tokens, which must be set outside development.
+	// In development a random secret is generated on start up when it's empty,
+	// meaning tokens will not survive a restart.
+	TokenSecret     string        `yaml:"tokenSecret" env:"TOKEN_SECRET"`
+	AccessTokenTTL  time.Duration `yaml:"accessTokenTtl" env:"ACCESS_TOKEN_TTL" env-default:"15m"`
+	RefreshTokenTTL time.Duration `yaml:"refreshTokenTtl" env:"REFRESH_TOKEN_TTL" env-default:"168h"`
+	// How long a session can be kept alive by refreshing from when it was
+	// first issued, after which the user must log in again.
+	SessionLifetime time.Duration `yaml:"sessionLifetime" env:"SESSION_LIFETIME" env-default:"720h"`
+
+	// How password reset codes are delivered, either "notification" or "mail".
+	ResetChannel string        `yaml:"resetChannel" env:"RESET_CHANNEL" env-default:"notification"`
+	ResetCodeTTL time.Duration `yaml:"resetCodeTtl" env:"RESET_CODE_TTL" env-default:"15m"`
+
+	// Password for an admin named "admin", created on start up when there are
+	// no admins. Nothing is created when it's empty.
+	AdminPassword string `yaml:"adminPassword" env:"ADMIN_PASSWORD"`
+
+	// How long an admin's impersonation session lasts. They can't be refreshed.
+	ImpersonationTTL time.Duration `yaml:"impersonationTtl" env:"IMPERSONATION_TTL" env-default:"30m"`
+
+	Lockout   Lockout   `yaml:"lockout"`
+	TwoFactor TwoFactor `yaml:"twoFactor"`
+}
+
+type TwoFactor struct {
+	// Shown in authenticator apps next to the username.
+	Issuer string `yaml:"issuer" env:"TWO_FACTOR_ISSUER" env-default:"DADES"`
+	// Roles that must enrol in two-factor authentication before they can log in.
+	RequiredRoles []string `yaml:"requiredRoles" env:"TWO_FACTOR_REQUIRED_ROLES" env-separator:","`
+	// How long a user has to enter their code after entering their password.
+	ChallengeTTL time.Duration `yaml:"challengeTtl" env:"TWO_FACTOR_CHALLENGE_TTL" env-default:"5m"`
+}
+
+type Lockout struct {
+	// Failed attempts after which a username or address is locked out.
+	MaxAttempts int `yaml:"maxAttempts" env:"LOCKOUT_MAX_ATTEMPTS" env-default:"5"`
+	// Failed attempts from a single address, across any usernames, after which it is locked out.
+	MaxAddressAttempts int `yaml:"maxAddressAttempts" env:"LOCKOUT_MAX_ADDRESS_ATTEMPTS" env-default:"20"`
+	// Failed attempts after which the account owner is notified.
+	NotifyAfter int `yaml:"notifyAfter" env:"LOCKOUT_NOTIFY_AFTER" env-default:"3"`
+	// Delay after the first failure, doubled for every further failure.
+	BaseDelay time.Duration `yaml:"baseDelay" env:"LOCKOUT_BASE_DELAY" env-default:"1s"`
+	// How long a lockout lasts, and how long failures are remembered for.
+	Duration time.Duration `yaml:"duration" env:"LOCKOUT_DURATION" env-default:"15m"`
+}
+
+type Storage struct {
+	// The bolt database products, subscriptions, chats and notifications are
+	// kept in. When it doesn't exist it is created and seeded from the JSON files
+	// under internal/data, which are also its import and export format.
+	BoltPath string `yaml:"boltPath" env:"STORAGE_BOLT_PATH" env-default:"internal/data/dades.db"`
+}
+
+type Product struct {
+	// How far stock must rise above a low stock threshold before falling to it
+	// alerts again, so stock hovering around a threshold doesn't alert repeatedly.
+	LowStockMargin int `yaml:"lowStockMargin" env:"PRODUCT_LOW_STOCK_MARGIN" env-default:"5"`
+	// How long stock is held for when a reservation doesn't say.
+	ReservationTTL time.Duration `yaml:"reservationTtl" env:"PRODUCT_RESERVATION_TTL" env-default:"24h"`
+	// The longest a reservation can be held for from when it's placed or extended.
+	MaxReservationTTL time.Duration `yaml:"maxReservationTtl" env:"PRODUCT_MAX_RESERVATION_TTL" env-default:"168h"`
+}
+
+type Audit struct {
+	// Size in bytes after which the audit log is rotated.
+	MaxFileSize int64 `yaml:"maxFileSize" env:"AUDIT_MAX_FILE_SIZE" env-default:"10485760"`
+	// Rotated audit logs to keep, after which the oldest are deleted.
+	MaxFiles int `yaml:"maxFiles" env:"AUDIT_MAX_FILES" env-default:"10"`
+}
+
+type Mail struct {
+	Host     string `yaml:"host" env:"MAIL_HOST" env-default:"localhost"`
+	Port     string `yaml:"port" env:"MAIL_PORT" env-default:"25"`
+	From     string `yaml:"from" env:"MAIL_FROM" env-default:"no-reply@dades.local"`
+	Username string `yaml:"username" env:"MAIL_USERNAME"`
+	Password string `yaml:"password" env:"MAIL_PASSWORD"`
 }
 
 func GetConfig() (*Config, error) {
@@ -46,6 +136,15 @@ func (c *Config) ReadConfig() error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to read config from %s", configPath)
 	}
+	return c.validate()
+}
+
+const Development = "development"
+
+func (c *Config) validate() error {
+	if c.Service.Environment != Development && c.Auth.TokenSecret == "" {
+		return errors.New("TOKEN_SECRET must be set outside development")
+	}
 	return nil
 }
 
@@ -8,16 +8,18 @@ import (
 )
 
 type cronManager struct {
-	scheduler *gocron.Scheduler
-	logger    slog.Logger
-	product   domain.ProductUseCase
+	scheduler  *gocron.Scheduler
+	logger     slog.Logger
+	product    domain.ProductUseCase
+	loginGuard domain.LoginGuard
 }
 
-func NewCronManager(product domain.ProductUseCase, logger slog.Logger) domain.CronManager {
+func NewCronManager(product domain.ProductUseCase, loginGuard domain.LoginGuard, logger slog.Logger) domain.CronManager {
 	return &cronManager{
-		scheduler: gocron.NewScheduler(time.UTC),
-		logger:    logger,
-		product:   product,
+		scheduler:  gocron.NewScheduler(time.UTC),
+		logger:     logger,
+		product:    product,
+		loginGuard: loginGuard,
 	}
 }
 
@@ -27,16 +29,27 @@ func (c *cronManager) Start() {
 	c.scheduler.Every(1).Hour().Do(func() {
 		err := c.product.SendProductNotifications("hourly")
 		if err != nil {
-			c.logger.Error("failed whilst sending hourly product notifications: %s", err.Error())
+			c.logger.Error("failed whilst sending hourly product notifications", "error", err)
 		}
 	})
 
 	c.scheduler.Every(1).Day().At("09:00").Do(func() {
 		err := c.product.SendProductNotifications("daily")
 		if err != nil {
-			c.logger.Error("failed whilst sending daily product notifications: %s", err.Error())
+			c.logger.Error("failed whilst sending daily product notifications", "error", err)
 		}
 	})
 
+	c.scheduler.Every(1).Minute().Do(func() {
+		err := c.product.ExpireReservations()
+		if err != nil {
+			c.logger.Error("failed whilst expiring reservations", "error", err)
+		}
+	})
+
+	c.scheduler.Every(1).Minute().Do(func() {
+		c.loginGuard.Prune()
+	})
+
 	c.scheduler.StartAsync()
 }
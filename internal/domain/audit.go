@@ -0,0 +1,14 @@
+package domain
+
+import "github.com/kkcaz/shu-dades-server/pkg/models"
+
+type AuditRepository interface {
+	Append(entry models.AuditEntry) error
+	// Query returns up to limit entries matching the request, newest first.
+	Query(request models.AuditQueryRequest) ([]models.AuditEntry, error)
+}
+
+type AuditUseCase interface {
+	Record(entry models.AuditEntry)
+	Query(request models.AuditQueryRequest) ([]models.AuditEntry, error)
+}
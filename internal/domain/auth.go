@@ -3,6 +3,7 @@ package domain
 import (
 	"github.com/kkcaz/shu-dades-server/pkg/models"
 	"net"
+	"time"
 )
 
 type AuthHandler interface {
@@ -11,9 +12,81 @@ type AuthHandler interface {
 
 type AuthUseCase interface {
 	Authenticate(username string, password string) (*models.UserClaim, error)
+	Refresh(refreshToken string) (*models.UserClaim, error)
+	Logout(token string) error
+	// ChangePassword logs out every one of userId's sessions but currentSessionId.
+	ChangePassword(userId string, currentSessionId string, currentPassword string, newPassword string) error
+	RevokeUser(userId string) error
+	// Impersonate issues adminId a time limited access token acting as userId.
+	Impersonate(adminId string, userId string, allowWrites bool) (*models.UserClaim, error)
+	GetSessions(userId string, currentSessionId string) ([]models.SessionInfo, error)
+	TouchSession(sessionId string, remoteAddress string)
+	TerminateSession(userId string, sessionId string) error
+	CompleteTwoFactor(challengeToken string, code string) (*models.UserClaim, error)
+	ChallengeUserId(challengeToken string) (string, error)
+	EnrolTwoFactor(userId string) (*models.TwoFactorEnrolment, error)
+	ConfirmTwoFactor(userId string, code string) ([]string, error)
+	DisableTwoFactor(userId string, code string) error
+	ResetTwoFactor(userId string) error
 	TokenIsValid(token string) bool
 	GetUser(token string) (*models.UserClaim, error)
 	GetUserById(userId string) (*models.User, error)
-	GetAllUserIds() []string
-	GetAllUsersInfo() ([]models.UserInfo, error)
+	GetAllUserIds(userId string) []string
+	CanSeeUser(userId string, otherUserId string) bool
+	GetAllUsersInfo(userId string, request models.UserListRequest) ([]models.UserInfo, models.PageInfo, error)
+}
+
+type RevocationRepository interface {
+	RevokeSession(sessionId string, until time.Time) error
+	RevokeUser(userId string, at time.Time) error
+	IsSessionRevoked(sessionId string) bool
+	GetUserRevokedAt(userId string) *time.Time
+}
+
+type PasswordResetUseCase interface {
+	RequestReset(username string) error
+	Reset(username string, code string, newPassword string) error
+}
+
+type ResetCodeSender interface {
+	SendResetCode(user models.User, code string, expiresAt time.Time) error
+}
+
+type LoginGuard interface {
+	// Check returns an error if logins for the username or address are currently
+	// blocked. Otherwise it reserves the attempt, which must then be finished
+	// with RecordFailure, RecordSuccess or Release.
+	Check(username string, remoteAddr string) error
+	RecordFailure(username string, remoteAddr string)
+	RecordSuccess(userId string, username string, remoteAddr string)
+	// Release finishes an attempt that neither failed nor succeeded.
+	Release(username string, remoteAddr string)
+	// Prune forgets attempts that no longer count towards a delay or lockout.
+	Prune()
+	GetLockouts() []models.Lockout
+	ClearLockout(key string) error
+}
+
+type SessionRepository interface {
+	Save(session models.Session) error
+	Get(sessionId string) *models.Session
+	GetByUser(userId string) []models.Session
+	Touch(sessionId string, at time.Time, remoteAddress string) error
+	Delete(sessionId string) error
+	DeleteByUser(userId string) error
+}
+
+type ApiKeyRepository interface {
+	GetAll() ([]models.ApiKey, error)
+	GetByHash(hash string) (*models.ApiKey, error)
+	Create(key models.ApiKey) error
+	Delete(id string) error
+	SetLastUsed(id string, at time.Time) error
+}
+
+type ApiKeyUseCase interface {
+	Create(request models.CreateApiKeyRequest) (string, *models.ApiKeyInfo, error)
+	List(userId string) ([]models.ApiKeyInfo, error)
+	Revoke(id string) error
+	Authenticate(key string) (*models.UserClaim, error)
 }
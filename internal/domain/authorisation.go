@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnauthorised = errors.New("unauthorised")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+type AuthorisationUseCase interface {
+	// Authorise checks the caller may call the route with the given body. It returns
+	// the caller's claim, which is nil for public routes called without a token.
+	Authorise(route string, method models.RequestType, token *string, body string) (*models.UserClaim, error)
+}
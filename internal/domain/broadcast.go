@@ -3,6 +3,9 @@ package domain
 type BroadcastUseCase interface {
 	PublishToUsers(message string, eventType string, users []string) error
 	AddConnection(subscribeAddress string, publishAddress string)
-	RegisterUser(addr string, userId string)
+	RegisterUser(addr string, userId string, sessionId string)
 	RemoveUser(addr string)
+	RemoveSession(sessionId string)
+	RemoveUserSessions(userId string)
+	IsSessionConnected(sessionId string) bool
 }
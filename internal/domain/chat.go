@@ -4,9 +4,10 @@ import "github.com/kkcaz/shu-dades-server/pkg/models"
 
 type ChatUseCase interface {
 	GetChatThumbnails(userId string) ([]models.ChatThumbnail, error)
-	GetChat(chatId string) (*models.Chat, error)
+	GetChat(userId string, chatId string) (*models.Chat, error)
+	GetMessages(userId string, request models.ChatMessagesRequest) ([]models.Message, models.PageInfo, error)
 	GetChatParticipantIds(chatId string) ([]string, error)
-	CreateChat(participants []string) (*models.Chat, error)
+	CreateChat(creatorId string, participants []string) (*models.Chat, error)
 	SendMessage(chatId string, message string, userId string) error
 }
 
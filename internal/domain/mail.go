@@ -0,0 +1,5 @@
+package domain
+
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
@@ -0,0 +1,123 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ApiKeyRepository is an autogenerated mock type for the ApiKeyRepository type
+type ApiKeyRepository struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: key
+func (_m *ApiKeyRepository) Create(key models.ApiKey) error {
+	ret := _m.Called(key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.ApiKey) error); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: id
+func (_m *ApiKeyRepository) Delete(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields:
+func (_m *ApiKeyRepository) GetAll() ([]models.ApiKey, error) {
+	ret := _m.Called()
+
+	var r0 []models.ApiKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]models.ApiKey, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []models.ApiKey); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ApiKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByHash provides a mock function with given fields: hash
+func (_m *ApiKeyRepository) GetByHash(hash string) (*models.ApiKey, error) {
+	ret := _m.Called(hash)
+
+	var r0 *models.ApiKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*models.ApiKey, error)); ok {
+		return rf(hash)
+	}
+	if rf, ok := ret.Get(0).(func(string) *models.ApiKey); ok {
+		r0 = rf(hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ApiKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetLastUsed provides a mock function with given fields: id, at
+func (_m *ApiKeyRepository) SetLastUsed(id string, at time.Time) error {
+	ret := _m.Called(id, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) error); ok {
+		r0 = rf(id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewApiKeyRepository creates a new instance of ApiKeyRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewApiKeyRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ApiKeyRepository {
+	mock := &ApiKeyRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
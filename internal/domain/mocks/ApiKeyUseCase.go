@@ -0,0 +1,126 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ApiKeyUseCase is an autogenerated mock type for the ApiKeyUseCase type
+type ApiKeyUseCase struct {
+	mock.Mock
+}
+
+// Authenticate provides a mock function with given fields: key
+func (_m *ApiKeyUseCase) Authenticate(key string) (*models.UserClaim, error) {
+	ret := _m.Called(key)
+
+	var r0 *models.UserClaim
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*models.UserClaim, error)); ok {
+		return rf(key)
+	}
+	if rf, ok := ret.Get(0).(func(string) *models.UserClaim); ok {
+		r0 = rf(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UserClaim)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: request
+func (_m *ApiKeyUseCase) Create(request models.CreateApiKeyRequest) (string, *models.ApiKeyInfo, error) {
+	ret := _m.Called(request)
+
+	var r0 string
+	var r1 *models.ApiKeyInfo
+	var r2 error
+	if rf, ok := ret.Get(0).(func(models.CreateApiKeyRequest) (string, *models.ApiKeyInfo, error)); ok {
+		return rf(request)
+	}
+	if rf, ok := ret.Get(0).(func(models.CreateApiKeyRequest) string); ok {
+		r0 = rf(request)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(models.CreateApiKeyRequest) *models.ApiKeyInfo); ok {
+		r1 = rf(request)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*models.ApiKeyInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(models.CreateApiKeyRequest) error); ok {
+		r2 = rf(request)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// List provides a mock function with given fields: userId
+func (_m *ApiKeyUseCase) List(userId string) ([]models.ApiKeyInfo, error) {
+	ret := _m.Called(userId)
+
+	var r0 []models.ApiKeyInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.ApiKeyInfo, error)); ok {
+		return rf(userId)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.ApiKeyInfo); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ApiKeyInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: id
+func (_m *ApiKeyUseCase) Revoke(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewApiKeyUseCase creates a new instance of ApiKeyUseCase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewApiKeyUseCase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ApiKeyUseCase {
+	mock := &ApiKeyUseCase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
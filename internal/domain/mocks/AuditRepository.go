@@ -0,0 +1,67 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditRepository is an autogenerated mock type for the AuditRepository type
+type AuditRepository struct {
+	mock.Mock
+}
+
+// Append provides a mock function with given fields: entry
+func (_m *AuditRepository) Append(entry models.AuditEntry) error {
+	ret := _m.Called(entry)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.AuditEntry) error); ok {
+		r0 = rf(entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Query provides a mock function with given fields: request
+func (_m *AuditRepository) Query(request models.AuditQueryRequest) ([]models.AuditEntry, error) {
+	ret := _m.Called(request)
+
+	var r0 []models.AuditEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.AuditQueryRequest) ([]models.AuditEntry, error)); ok {
+		return rf(request)
+	}
+	if rf, ok := ret.Get(0).(func(models.AuditQueryRequest) []models.AuditEntry); ok {
+		r0 = rf(request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AuditEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.AuditQueryRequest) error); ok {
+		r1 = rf(request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewAuditRepository creates a new instance of AuditRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditRepository {
+	mock := &AuditRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,58 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditUseCase is an autogenerated mock type for the AuditUseCase type
+type AuditUseCase struct {
+	mock.Mock
+}
+
+// Query provides a mock function with given fields: request
+func (_m *AuditUseCase) Query(request models.AuditQueryRequest) ([]models.AuditEntry, error) {
+	ret := _m.Called(request)
+
+	var r0 []models.AuditEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.AuditQueryRequest) ([]models.AuditEntry, error)); ok {
+		return rf(request)
+	}
+	if rf, ok := ret.Get(0).(func(models.AuditQueryRequest) []models.AuditEntry); ok {
+		r0 = rf(request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AuditEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.AuditQueryRequest) error); ok {
+		r1 = rf(request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Record provides a mock function with given fields: entry
+func (_m *AuditUseCase) Record(entry models.AuditEntry) {
+	_m.Called(entry)
+}
+
+// NewAuditUseCase creates a new instance of AuditUseCase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditUseCase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditUseCase {
+	mock := &AuditUseCase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
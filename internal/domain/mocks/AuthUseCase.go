@@ -38,41 +38,218 @@ func (_m *AuthUseCase) Authenticate(username string, password string) (*models.U
 	return r0, r1
 }
 
-// GetAllUserIds provides a mock function with given fields:
-func (_m *AuthUseCase) GetAllUserIds() []string {
-	ret := _m.Called()
+// CanSeeUser provides a mock function with given fields: userId, otherUserId
+func (_m *AuthUseCase) CanSeeUser(userId string, otherUserId string) bool {
+	ret := _m.Called(userId, otherUserId)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(userId, otherUserId)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// ChallengeUserId provides a mock function with given fields: challengeToken
+func (_m *AuthUseCase) ChallengeUserId(challengeToken string) (string, error) {
+	ret := _m.Called(challengeToken)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(challengeToken)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(challengeToken)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(challengeToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePassword provides a mock function with given fields: userId, currentSessionId, currentPassword, newPassword
+func (_m *AuthUseCase) ChangePassword(userId string, currentSessionId string, currentPassword string, newPassword string) error {
+	ret := _m.Called(userId, currentSessionId, currentPassword, newPassword)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string) error); ok {
+		r0 = rf(userId, currentSessionId, currentPassword, newPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CompleteTwoFactor provides a mock function with given fields: challengeToken, code
+func (_m *AuthUseCase) CompleteTwoFactor(challengeToken string, code string) (*models.UserClaim, error) {
+	ret := _m.Called(challengeToken, code)
+
+	var r0 *models.UserClaim
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*models.UserClaim, error)); ok {
+		return rf(challengeToken, code)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *models.UserClaim); ok {
+		r0 = rf(challengeToken, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UserClaim)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(challengeToken, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConfirmTwoFactor provides a mock function with given fields: userId, code
+func (_m *AuthUseCase) ConfirmTwoFactor(userId string, code string) ([]string, error) {
+	ret := _m.Called(userId, code)
 
 	var r0 []string
-	if rf, ok := ret.Get(0).(func() []string); ok {
-		r0 = rf()
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]string, error)); ok {
+		return rf(userId, code)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []string); ok {
+		r0 = rf(userId, code)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]string)
 		}
 	}
 
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userId, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DisableTwoFactor provides a mock function with given fields: userId, code
+func (_m *AuthUseCase) DisableTwoFactor(userId string, code string) error {
+	ret := _m.Called(userId, code)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(userId, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
 	return r0
 }
 
-// GetAllUsersInfo provides a mock function with given fields:
-func (_m *AuthUseCase) GetAllUsersInfo() ([]models.UserInfo, error) {
-	ret := _m.Called()
+// EnrolTwoFactor provides a mock function with given fields: userId
+func (_m *AuthUseCase) EnrolTwoFactor(userId string) (*models.TwoFactorEnrolment, error) {
+	ret := _m.Called(userId)
 
-	var r0 []models.UserInfo
+	var r0 *models.TwoFactorEnrolment
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]models.UserInfo, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(string) (*models.TwoFactorEnrolment, error)); ok {
+		return rf(userId)
+	}
+	if rf, ok := ret.Get(0).(func(string) *models.TwoFactorEnrolment); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TwoFactorEnrolment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllUserIds provides a mock function with given fields: userId
+func (_m *AuthUseCase) GetAllUserIds(userId string) []string {
+	ret := _m.Called(userId)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// GetAllUsersInfo provides a mock function with given fields: userId, request
+func (_m *AuthUseCase) GetAllUsersInfo(userId string, request models.UserListRequest) ([]models.UserInfo, models.PageInfo, error) {
+	ret := _m.Called(userId, request)
+
+	var r0 []models.UserInfo
+	var r1 models.PageInfo
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, models.UserListRequest) ([]models.UserInfo, models.PageInfo, error)); ok {
+		return rf(userId, request)
 	}
-	if rf, ok := ret.Get(0).(func() []models.UserInfo); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(string, models.UserListRequest) []models.UserInfo); ok {
+		r0 = rf(userId, request)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.UserInfo)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(string, models.UserListRequest) models.PageInfo); ok {
+		r1 = rf(userId, request)
+	} else {
+		r1 = ret.Get(1).(models.PageInfo)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, models.UserListRequest) error); ok {
+		r2 = rf(userId, request)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetSessions provides a mock function with given fields: userId, currentSessionId
+func (_m *AuthUseCase) GetSessions(userId string, currentSessionId string) ([]models.SessionInfo, error) {
+	ret := _m.Called(userId, currentSessionId)
+
+	var r0 []models.SessionInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]models.SessionInfo, error)); ok {
+		return rf(userId, currentSessionId)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []models.SessionInfo); ok {
+		r0 = rf(userId, currentSessionId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.SessionInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userId, currentSessionId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -132,6 +309,114 @@ func (_m *AuthUseCase) GetUserById(userId string) (*models.User, error) {
 	return r0, r1
 }
 
+// Impersonate provides a mock function with given fields: adminId, userId, allowWrites
+func (_m *AuthUseCase) Impersonate(adminId string, userId string, allowWrites bool) (*models.UserClaim, error) {
+	ret := _m.Called(adminId, userId, allowWrites)
+
+	var r0 *models.UserClaim
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, bool) (*models.UserClaim, error)); ok {
+		return rf(adminId, userId, allowWrites)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, bool) *models.UserClaim); ok {
+		r0 = rf(adminId, userId, allowWrites)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UserClaim)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, bool) error); ok {
+		r1 = rf(adminId, userId, allowWrites)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Logout provides a mock function with given fields: token
+func (_m *AuthUseCase) Logout(token string) error {
+	ret := _m.Called(token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Refresh provides a mock function with given fields: refreshToken
+func (_m *AuthUseCase) Refresh(refreshToken string) (*models.UserClaim, error) {
+	ret := _m.Called(refreshToken)
+
+	var r0 *models.UserClaim
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*models.UserClaim, error)); ok {
+		return rf(refreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(string) *models.UserClaim); ok {
+		r0 = rf(refreshToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UserClaim)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(refreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetTwoFactor provides a mock function with given fields: userId
+func (_m *AuthUseCase) ResetTwoFactor(userId string) error {
+	ret := _m.Called(userId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUser provides a mock function with given fields: userId
+func (_m *AuthUseCase) RevokeUser(userId string) error {
+	ret := _m.Called(userId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TerminateSession provides a mock function with given fields: userId, sessionId
+func (_m *AuthUseCase) TerminateSession(userId string, sessionId string) error {
+	ret := _m.Called(userId, sessionId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(userId, sessionId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // TokenIsValid provides a mock function with given fields: token
 func (_m *AuthUseCase) TokenIsValid(token string) bool {
 	ret := _m.Called(token)
@@ -146,6 +431,11 @@ func (_m *AuthUseCase) TokenIsValid(token string) bool {
 	return r0
 }
 
+// TouchSession provides a mock function with given fields: sessionId, remoteAddress
+func (_m *AuthUseCase) TouchSession(sessionId string, remoteAddress string) {
+	_m.Called(sessionId, remoteAddress)
+}
+
 // NewAuthUseCase creates a new instance of AuthUseCase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewAuthUseCase(t interface {
@@ -14,6 +14,20 @@ func (_m *BroadcastUseCase) AddConnection(subscribeAddress string, publishAddres
 	_m.Called(subscribeAddress, publishAddress)
 }
 
+// IsSessionConnected provides a mock function with given fields: sessionId
+func (_m *BroadcastUseCase) IsSessionConnected(sessionId string) bool {
+	ret := _m.Called(sessionId)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(sessionId)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // PublishToUsers provides a mock function with given fields: message, eventType, users
 func (_m *BroadcastUseCase) PublishToUsers(message string, eventType string, users []string) error {
 	ret := _m.Called(message, eventType, users)
@@ -28,9 +42,14 @@ func (_m *BroadcastUseCase) PublishToUsers(message string, eventType string, use
 	return r0
 }
 
-// RegisterUser provides a mock function with given fields: addr, userId
-func (_m *BroadcastUseCase) RegisterUser(addr string, userId string) {
-	_m.Called(addr, userId)
+// RegisterUser provides a mock function with given fields: addr, userId, sessionId
+func (_m *BroadcastUseCase) RegisterUser(addr string, userId string, sessionId string) {
+	_m.Called(addr, userId, sessionId)
+}
+
+// RemoveSession provides a mock function with given fields: sessionId
+func (_m *BroadcastUseCase) RemoveSession(sessionId string) {
+	_m.Called(sessionId)
 }
 
 // RemoveUser provides a mock function with given fields: addr
@@ -38,6 +57,11 @@ func (_m *BroadcastUseCase) RemoveUser(addr string) {
 	_m.Called(addr)
 }
 
+// RemoveUserSessions provides a mock function with given fields: userId
+func (_m *BroadcastUseCase) RemoveUserSessions(userId string) {
+	_m.Called(userId)
+}
+
 // NewBroadcastUseCase creates a new instance of BroadcastUseCase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewBroadcastUseCase(t interface {
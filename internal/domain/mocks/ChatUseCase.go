@@ -12,25 +12,25 @@ type ChatUseCase struct {
 	mock.Mock
 }
 
-// CreateChat provides a mock function with given fields: participants
-func (_m *ChatUseCase) CreateChat(participants []string) (*models.Chat, error) {
-	ret := _m.Called(participants)
+// CreateChat provides a mock function with given fields: creatorId, participants
+func (_m *ChatUseCase) CreateChat(creatorId string, participants []string) (*models.Chat, error) {
+	ret := _m.Called(creatorId, participants)
 
 	var r0 *models.Chat
 	var r1 error
-	if rf, ok := ret.Get(0).(func([]string) (*models.Chat, error)); ok {
-		return rf(participants)
+	if rf, ok := ret.Get(0).(func(string, []string) (*models.Chat, error)); ok {
+		return rf(creatorId, participants)
 	}
-	if rf, ok := ret.Get(0).(func([]string) *models.Chat); ok {
-		r0 = rf(participants)
+	if rf, ok := ret.Get(0).(func(string, []string) *models.Chat); ok {
+		r0 = rf(creatorId, participants)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Chat)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func([]string) error); ok {
-		r1 = rf(participants)
+	if rf, ok := ret.Get(1).(func(string, []string) error); ok {
+		r1 = rf(creatorId, participants)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -38,25 +38,25 @@ func (_m *ChatUseCase) CreateChat(participants []string) (*models.Chat, error) {
 	return r0, r1
 }
 
-// GetChat provides a mock function with given fields: chatId
-func (_m *ChatUseCase) GetChat(chatId string) (*models.Chat, error) {
-	ret := _m.Called(chatId)
+// GetChat provides a mock function with given fields: userId, chatId
+func (_m *ChatUseCase) GetChat(userId string, chatId string) (*models.Chat, error) {
+	ret := _m.Called(userId, chatId)
 
 	var r0 *models.Chat
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*models.Chat, error)); ok {
-		return rf(chatId)
+	if rf, ok := ret.Get(0).(func(string, string) (*models.Chat, error)); ok {
+		return rf(userId, chatId)
 	}
-	if rf, ok := ret.Get(0).(func(string) *models.Chat); ok {
-		r0 = rf(chatId)
+	if rf, ok := ret.Get(0).(func(string, string) *models.Chat); ok {
+		r0 = rf(userId, chatId)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Chat)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(chatId)
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userId, chatId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -116,6 +116,39 @@ func (_m *ChatUseCase) GetChatThumbnails(userId string) ([]models.ChatThumbnail,
 	return r0, r1
 }
 
+// GetMessages provides a mock function with given fields: userId, request
+func (_m *ChatUseCase) GetMessages(userId string, request models.ChatMessagesRequest) ([]models.Message, models.PageInfo, error) {
+	ret := _m.Called(userId, request)
+
+	var r0 []models.Message
+	var r1 models.PageInfo
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, models.ChatMessagesRequest) ([]models.Message, models.PageInfo, error)); ok {
+		return rf(userId, request)
+	}
+	if rf, ok := ret.Get(0).(func(string, models.ChatMessagesRequest) []models.Message); ok {
+		r0 = rf(userId, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, models.ChatMessagesRequest) models.PageInfo); ok {
+		r1 = rf(userId, request)
+	} else {
+		r1 = ret.Get(1).(models.PageInfo)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, models.ChatMessagesRequest) error); ok {
+		r2 = rf(userId, request)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // SendMessage provides a mock function with given fields: chatId, message, userId
 func (_m *ChatUseCase) SendMessage(chatId string, message string, userId string) error {
 	ret := _m.Called(chatId, message, userId)
@@ -0,0 +1,91 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// LoginGuard is an autogenerated mock type for the LoginGuard type
+type LoginGuard struct {
+	mock.Mock
+}
+
+// Check provides a mock function with given fields: username, remoteAddr
+func (_m *LoginGuard) Check(username string, remoteAddr string) error {
+	ret := _m.Called(username, remoteAddr)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(username, remoteAddr)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClearLockout provides a mock function with given fields: key
+func (_m *LoginGuard) ClearLockout(key string) error {
+	ret := _m.Called(key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetLockouts provides a mock function with given fields:
+func (_m *LoginGuard) GetLockouts() []models.Lockout {
+	ret := _m.Called()
+
+	var r0 []models.Lockout
+	if rf, ok := ret.Get(0).(func() []models.Lockout); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Lockout)
+		}
+	}
+
+	return r0
+}
+
+// Prune provides a mock function with given fields:
+func (_m *LoginGuard) Prune() {
+	_m.Called()
+}
+
+// RecordFailure provides a mock function with given fields: username, remoteAddr
+func (_m *LoginGuard) RecordFailure(username string, remoteAddr string) {
+	_m.Called(username, remoteAddr)
+}
+
+// RecordSuccess provides a mock function with given fields: userId, username, remoteAddr
+func (_m *LoginGuard) RecordSuccess(userId string, username string, remoteAddr string) {
+	_m.Called(userId, username, remoteAddr)
+}
+
+// Release provides a mock function with given fields: username, remoteAddr
+func (_m *LoginGuard) Release(username string, remoteAddr string) {
+	_m.Called(username, remoteAddr)
+}
+
+// NewLoginGuard creates a new instance of LoginGuard. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLoginGuard(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LoginGuard {
+	mock := &LoginGuard{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,8 +3,10 @@
 package mocks
 
 import (
-	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	domain "github.com/kkcaz/shu-dades-server/internal/domain"
 	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
 )
 
 // NotificationUseCase is an autogenerated mock type for the NotificationUseCase type
@@ -26,13 +28,13 @@ func (_m *NotificationUseCase) Add(userId string, message string) error {
 	return r0
 }
 
-// AddAll provides a mock function with given fields: message
-func (_m *NotificationUseCase) AddAll(message string) error {
-	ret := _m.Called(message)
+// AddAll provides a mock function with given fields: senderId, message
+func (_m *NotificationUseCase) AddAll(senderId string, message string) error {
+	ret := _m.Called(senderId, message)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(message)
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(senderId, message)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -68,30 +70,65 @@ func (_m *NotificationUseCase) Delete(userId string, notificationId string) erro
 	return r0
 }
 
-// Get provides a mock function with given fields: userId
-func (_m *NotificationUseCase) Get(userId string) ([]models.Notification, error) {
-	ret := _m.Called(userId)
+// Get provides a mock function with given fields: userId, request
+func (_m *NotificationUseCase) Get(userId string, request models.PageRequest) ([]models.Notification, models.PageInfo, error) {
+	ret := _m.Called(userId, request)
 
 	var r0 []models.Notification
-	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]models.Notification, error)); ok {
-		return rf(userId)
+	var r1 models.PageInfo
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, models.PageRequest) ([]models.Notification, models.PageInfo, error)); ok {
+		return rf(userId, request)
 	}
-	if rf, ok := ret.Get(0).(func(string) []models.Notification); ok {
-		r0 = rf(userId)
+	if rf, ok := ret.Get(0).(func(string, models.PageRequest) []models.Notification); ok {
+		r0 = rf(userId, request)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.Notification)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(userId)
+	if rf, ok := ret.Get(1).(func(string, models.PageRequest) models.PageInfo); ok {
+		r1 = rf(userId, request)
+	} else {
+		r1 = ret.Get(1).(models.PageInfo)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, models.PageRequest) error); ok {
+		r2 = rf(userId, request)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Publish provides a mock function with given fields: message, users
+func (_m *NotificationUseCase) Publish(message string, users []string) error {
+	ret := _m.Called(message, users)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string) error); ok {
+		r0 = rf(message, users)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Record provides a mock function with given fields: tx, message, users
+func (_m *NotificationUseCase) Record(tx domain.Tx, message string, users []string) error {
+	ret := _m.Called(tx, message, users)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(domain.Tx, string, []string) error); ok {
+		r0 = rf(tx, message, users)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
 // NewNotificationUseCase creates a new instance of NotificationUseCase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
@@ -0,0 +1,121 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OrganisationRepository is an autogenerated mock type for the OrganisationRepository type
+type OrganisationRepository struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: organisation
+func (_m *OrganisationRepository) Create(organisation models.Organisation) error {
+	ret := _m.Called(organisation)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Organisation) error); ok {
+		r0 = rf(organisation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: id
+func (_m *OrganisationRepository) Delete(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields:
+func (_m *OrganisationRepository) GetAll() ([]models.Organisation, error) {
+	ret := _m.Called()
+
+	var r0 []models.Organisation
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]models.Organisation, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []models.Organisation); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Organisation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetById provides a mock function with given fields: id
+func (_m *OrganisationRepository) GetById(id string) (*models.Organisation, error) {
+	ret := _m.Called(id)
+
+	var r0 *models.Organisation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*models.Organisation, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *models.Organisation); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Organisation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: organisation
+func (_m *OrganisationRepository) Update(organisation models.Organisation) error {
+	ret := _m.Called(organisation)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Organisation) error); ok {
+		r0 = rf(organisation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewOrganisationRepository creates a new instance of OrganisationRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOrganisationRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OrganisationRepository {
+	mock := &OrganisationRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
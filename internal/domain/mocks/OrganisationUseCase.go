@@ -0,0 +1,171 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OrganisationUseCase is an autogenerated mock type for the OrganisationUseCase type
+type OrganisationUseCase struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: request
+func (_m *OrganisationUseCase) Create(request models.CreateOrganisationRequest) (*models.Organisation, error) {
+	ret := _m.Called(request)
+
+	var r0 *models.Organisation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.CreateOrganisationRequest) (*models.Organisation, error)); ok {
+		return rf(request)
+	}
+	if rf, ok := ret.Get(0).(func(models.CreateOrganisationRequest) *models.Organisation); ok {
+		r0 = rf(request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Organisation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.CreateOrganisationRequest) error); ok {
+		r1 = rf(request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: id
+func (_m *OrganisationUseCase) Delete(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Get provides a mock function with given fields: id
+func (_m *OrganisationUseCase) Get(id string) (*models.Organisation, error) {
+	ret := _m.Called(id)
+
+	var r0 *models.Organisation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*models.Organisation, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *models.Organisation); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Organisation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAll provides a mock function with given fields:
+func (_m *OrganisationUseCase) GetAll() ([]models.Organisation, error) {
+	ret := _m.Called()
+
+	var r0 []models.Organisation
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]models.Organisation, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []models.Organisation); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Organisation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetScope provides a mock function with given fields: userId
+func (_m *OrganisationUseCase) GetScope(userId string) (*models.TenantScope, error) {
+	ret := _m.Called(userId)
+
+	var r0 *models.TenantScope
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*models.TenantScope, error)); ok {
+		return rf(userId)
+	}
+	if rf, ok := ret.Get(0).(func(string) *models.TenantScope); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TenantScope)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: request
+func (_m *OrganisationUseCase) Update(request models.UpdateOrganisationRequest) (*models.Organisation, error) {
+	ret := _m.Called(request)
+
+	var r0 *models.Organisation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.UpdateOrganisationRequest) (*models.Organisation, error)); ok {
+		return rf(request)
+	}
+	if rf, ok := ret.Get(0).(func(models.UpdateOrganisationRequest) *models.Organisation); ok {
+		r0 = rf(request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Organisation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.UpdateOrganisationRequest) error); ok {
+		r1 = rf(request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewOrganisationUseCase creates a new instance of OrganisationUseCase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOrganisationUseCase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OrganisationUseCase {
+	mock := &OrganisationUseCase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
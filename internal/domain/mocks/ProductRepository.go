@@ -92,6 +92,84 @@ func (_m *ProductRepository) GetAll() ([]models.Product, error) {
 	return r0, r1
 }
 
+// GetAllByName provides a mock function with given fields:
+func (_m *ProductRepository) GetAllByName() ([]models.Product, error) {
+	ret := _m.Called()
+
+	var r0 []models.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]models.Product, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []models.Product); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Product)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBySku provides a mock function with given fields: supplierId, sku
+func (_m *ProductRepository) GetBySku(supplierId string, sku string) ([]models.Product, error) {
+	ret := _m.Called(supplierId, sku)
+
+	var r0 []models.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]models.Product, error)); ok {
+		return rf(supplierId, sku)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []models.Product); ok {
+		r0 = rf(supplierId, sku)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Product)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(supplierId, sku)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProductSubscribers provides a mock function with given fields: productId
+func (_m *ProductRepository) GetProductSubscribers(productId string) ([]string, error) {
+	ret := _m.Called(productId)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
+		return rf(productId)
+	}
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(productId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(productId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetSubscriptions provides a mock function with given fields: subType
 func (_m *ProductRepository) GetSubscriptions(subType string) ([]models.ProductSubscription, error) {
 	ret := _m.Called(subType)
@@ -172,6 +250,20 @@ func (_m *ProductRepository) Unsubscribe(productId string, subType string, userI
 	return r0
 }
 
+// Update provides a mock function with given fields: product, version
+func (_m *ProductRepository) Update(product models.Product, version int) error {
+	ret := _m.Called(product, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Product, int) error); ok {
+		r0 = rf(product, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewProductRepository creates a new instance of ProductRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewProductRepository(t interface {
@@ -12,12 +12,38 @@ type ProductUseCase struct {
 	mock.Mock
 }
 
+// ConvertReservation provides a mock function with given fields: userId, id
+func (_m *ProductUseCase) ConvertReservation(userId string, id string) (*models.Reservation, error) {
+	ret := _m.Called(userId, id)
+
+	var r0 *models.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*models.Reservation, error)); ok {
+		return rf(userId, id)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *models.Reservation); ok {
+		r0 = rf(userId, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Reservation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userId, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: product
-func (_m *ProductUseCase) Create(product models.Product) error {
+func (_m *ProductUseCase) Create(product *models.Product) error {
 	ret := _m.Called(product)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(models.Product) error); ok {
+	if rf, ok := ret.Get(0).(func(*models.Product) error); ok {
 		r0 = rf(product)
 	} else {
 		r0 = ret.Error(0)
@@ -40,25 +66,89 @@ func (_m *ProductUseCase) Delete(id string) error {
 	return r0
 }
 
-// Get provides a mock function with given fields: id
-func (_m *ProductUseCase) Get(id string) (*models.Product, error) {
-	ret := _m.Called(id)
+// ExpireReservations provides a mock function with given fields:
+func (_m *ProductUseCase) ExpireReservations() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportProducts provides a mock function with given fields: request
+func (_m *ProductUseCase) ExportProducts(request models.ExportProductsRequest) (string, error) {
+	ret := _m.Called(request)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.ExportProductsRequest) (string, error)); ok {
+		return rf(request)
+	}
+	if rf, ok := ret.Get(0).(func(models.ExportProductsRequest) string); ok {
+		r0 = rf(request)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(models.ExportProductsRequest) error); ok {
+		r1 = rf(request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExtendReservation provides a mock function with given fields: userId, request
+func (_m *ProductUseCase) ExtendReservation(userId string, request models.ExtendReservationRequest) (*models.Reservation, error) {
+	ret := _m.Called(userId, request)
+
+	var r0 *models.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, models.ExtendReservationRequest) (*models.Reservation, error)); ok {
+		return rf(userId, request)
+	}
+	if rf, ok := ret.Get(0).(func(string, models.ExtendReservationRequest) *models.Reservation); ok {
+		r0 = rf(userId, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Reservation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, models.ExtendReservationRequest) error); ok {
+		r1 = rf(userId, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: userId, id
+func (_m *ProductUseCase) Get(userId string, id string) (*models.Product, error) {
+	ret := _m.Called(userId, id)
 
 	var r0 *models.Product
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*models.Product, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(string, string) (*models.Product, error)); ok {
+		return rf(userId, id)
 	}
-	if rf, ok := ret.Get(0).(func(string) *models.Product); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(string, string) *models.Product); ok {
+		r0 = rf(userId, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Product)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userId, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -66,25 +156,25 @@ func (_m *ProductUseCase) Get(id string) (*models.Product, error) {
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields:
-func (_m *ProductUseCase) GetAll() ([]models.Product, error) {
-	ret := _m.Called()
+// GetAll provides a mock function with given fields: userId
+func (_m *ProductUseCase) GetAll(userId string) ([]models.Product, error) {
+	ret := _m.Called(userId)
 
 	var r0 []models.Product
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]models.Product, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(string) ([]models.Product, error)); ok {
+		return rf(userId)
 	}
-	if rf, ok := ret.Get(0).(func() []models.Product); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(string) []models.Product); ok {
+		r0 = rf(userId)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.Product)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -118,25 +208,155 @@ func (_m *ProductUseCase) GetProductSubscriptions(userId string) ([]models.Produ
 	return r0, r1
 }
 
-// Search provides a mock function with given fields: pageNumber, pageSize, sortBy, order
-func (_m *ProductUseCase) Search(pageNumber int, pageSize int, sortBy models.SortBy, order models.Order) ([]models.Product, error) {
-	ret := _m.Called(pageNumber, pageSize, sortBy, order)
+// GetReservations provides a mock function with given fields: userId, request
+func (_m *ProductUseCase) GetReservations(userId string, request models.ReservationListRequest) ([]models.Reservation, error) {
+	ret := _m.Called(userId, request)
 
-	var r0 []models.Product
+	var r0 []models.Reservation
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, int, models.SortBy, models.Order) ([]models.Product, error)); ok {
-		return rf(pageNumber, pageSize, sortBy, order)
+	if rf, ok := ret.Get(0).(func(string, models.ReservationListRequest) ([]models.Reservation, error)); ok {
+		return rf(userId, request)
 	}
-	if rf, ok := ret.Get(0).(func(int, int, models.SortBy, models.Order) []models.Product); ok {
-		r0 = rf(pageNumber, pageSize, sortBy, order)
+	if rf, ok := ret.Get(0).(func(string, models.ReservationListRequest) []models.Reservation); ok {
+		r0 = rf(userId, request)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.Product)
+			r0 = ret.Get(0).([]models.Reservation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, models.ReservationListRequest) error); ok {
+		r1 = rf(userId, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStockLevel provides a mock function with given fields: userId, productId
+func (_m *ProductUseCase) GetStockLevel(userId string, productId string) (*models.StockLevel, error) {
+	ret := _m.Called(userId, productId)
+
+	var r0 *models.StockLevel
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*models.StockLevel, error)); ok {
+		return rf(userId, productId)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *models.StockLevel); ok {
+		r0 = rf(userId, productId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.StockLevel)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userId, productId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStockMovements provides a mock function with given fields: request
+func (_m *ProductUseCase) GetStockMovements(request models.StockHistoryRequest) ([]models.StockMovement, error) {
+	ret := _m.Called(request)
+
+	var r0 []models.StockMovement
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.StockHistoryRequest) ([]models.StockMovement, error)); ok {
+		return rf(request)
+	}
+	if rf, ok := ret.Get(0).(func(models.StockHistoryRequest) []models.StockMovement); ok {
+		r0 = rf(request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.StockMovement)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.StockHistoryRequest) error); ok {
+		r1 = rf(request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserThresholds provides a mock function with given fields: userId
+func (_m *ProductUseCase) GetUserThresholds(userId string) ([]models.UserThreshold, error) {
+	ret := _m.Called(userId)
+
+	var r0 []models.UserThreshold
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.UserThreshold, error)); ok {
+		return rf(userId)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.UserThreshold); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UserThreshold)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportProducts provides a mock function with given fields: userId, request
+func (_m *ProductUseCase) ImportProducts(userId string, request models.ImportProductsRequest) (*models.ImportReport, error) {
+	ret := _m.Called(userId, request)
+
+	var r0 *models.ImportReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, models.ImportProductsRequest) (*models.ImportReport, error)); ok {
+		return rf(userId, request)
+	}
+	if rf, ok := ret.Get(0).(func(string, models.ImportProductsRequest) *models.ImportReport); ok {
+		r0 = rf(userId, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ImportReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, models.ImportProductsRequest) error); ok {
+		r1 = rf(userId, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MoveStock provides a mock function with given fields: userId, request
+func (_m *ProductUseCase) MoveStock(userId string, request models.StockMovementRequest) (*models.StockMovement, error) {
+	ret := _m.Called(userId, request)
+
+	var r0 *models.StockMovement
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, models.StockMovementRequest) (*models.StockMovement, error)); ok {
+		return rf(userId, request)
+	}
+	if rf, ok := ret.Get(0).(func(string, models.StockMovementRequest) *models.StockMovement); ok {
+		r0 = rf(userId, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.StockMovement)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, int, models.SortBy, models.Order) error); ok {
-		r1 = rf(pageNumber, pageSize, sortBy, order)
+	if rf, ok := ret.Get(1).(func(string, models.StockMovementRequest) error); ok {
+		r1 = rf(userId, request)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -144,6 +364,91 @@ func (_m *ProductUseCase) Search(pageNumber int, pageSize int, sortBy models.Sor
 	return r0, r1
 }
 
+// ReleaseReservation provides a mock function with given fields: userId, id
+func (_m *ProductUseCase) ReleaseReservation(userId string, id string) (*models.Reservation, error) {
+	ret := _m.Called(userId, id)
+
+	var r0 *models.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*models.Reservation, error)); ok {
+		return rf(userId, id)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *models.Reservation); ok {
+		r0 = rf(userId, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Reservation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userId, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reserve provides a mock function with given fields: userId, request
+func (_m *ProductUseCase) Reserve(userId string, request models.ReservationRequest) (*models.Reservation, error) {
+	ret := _m.Called(userId, request)
+
+	var r0 *models.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, models.ReservationRequest) (*models.Reservation, error)); ok {
+		return rf(userId, request)
+	}
+	if rf, ok := ret.Get(0).(func(string, models.ReservationRequest) *models.Reservation); ok {
+		r0 = rf(userId, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Reservation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, models.ReservationRequest) error); ok {
+		r1 = rf(userId, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: userId, request
+func (_m *ProductUseCase) Search(userId string, request models.SearchRequest) ([]models.Product, models.PageInfo, error) {
+	ret := _m.Called(userId, request)
+
+	var r0 []models.Product
+	var r1 models.PageInfo
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, models.SearchRequest) ([]models.Product, models.PageInfo, error)); ok {
+		return rf(userId, request)
+	}
+	if rf, ok := ret.Get(0).(func(string, models.SearchRequest) []models.Product); ok {
+		r0 = rf(userId, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Product)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, models.SearchRequest) models.PageInfo); ok {
+		r1 = rf(userId, request)
+	} else {
+		r1 = ret.Get(1).(models.PageInfo)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, models.SearchRequest) error); ok {
+		r2 = rf(userId, request)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // SendProductNotifications provides a mock function with given fields: subType
 func (_m *ProductUseCase) SendProductNotifications(subType string) error {
 	ret := _m.Called(subType)
@@ -158,6 +463,20 @@ func (_m *ProductUseCase) SendProductNotifications(subType string) error {
 	return r0
 }
 
+// SetUserThreshold provides a mock function with given fields: userId, request
+func (_m *ProductUseCase) SetUserThreshold(userId string, request models.UserThresholdRequest) error {
+	ret := _m.Called(userId, request)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, models.UserThresholdRequest) error); ok {
+		r0 = rf(userId, request)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Subscribe provides a mock function with given fields: productId, subType, userId
 func (_m *ProductUseCase) Subscribe(productId string, subType string, userId string) error {
 	ret := _m.Called(productId, subType, userId)
@@ -186,13 +505,13 @@ func (_m *ProductUseCase) Unsubscribe(productId string, subType string, userId s
 	return r0
 }
 
-// Update provides a mock function with given fields: product
-func (_m *ProductUseCase) Update(product *models.Product) error {
-	ret := _m.Called(product)
+// Update provides a mock function with given fields: userId, product
+func (_m *ProductUseCase) Update(userId string, product *models.Product) error {
+	ret := _m.Called(userId, product)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Product) error); ok {
-		r0 = rf(product)
+	if rf, ok := ret.Get(0).(func(string, *models.Product) error); ok {
+		r0 = rf(userId, product)
 	} else {
 		r0 = ret.Error(0)
 	}
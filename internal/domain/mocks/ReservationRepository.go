@@ -0,0 +1,147 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ReservationRepository is an autogenerated mock type for the ReservationRepository type
+type ReservationRepository struct {
+	mock.Mock
+}
+
+// Get provides a mock function with given fields: id
+func (_m *ReservationRepository) Get(id string) (*models.Reservation, error) {
+	ret := _m.Called(id)
+
+	var r0 *models.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*models.Reservation, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *models.Reservation); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Reservation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByProduct provides a mock function with given fields: productId
+func (_m *ReservationRepository) GetByProduct(productId string) ([]models.Reservation, error) {
+	ret := _m.Called(productId)
+
+	var r0 []models.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.Reservation, error)); ok {
+		return rf(productId)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.Reservation); ok {
+		r0 = rf(productId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Reservation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(productId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByUser provides a mock function with given fields: userId
+func (_m *ReservationRepository) GetByUser(userId string) ([]models.Reservation, error) {
+	ret := _m.Called(userId)
+
+	var r0 []models.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.Reservation, error)); ok {
+		return rf(userId)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.Reservation); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Reservation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExpired provides a mock function with given fields: t
+func (_m *ReservationRepository) GetExpired(t time.Time) ([]models.Reservation, error) {
+	ret := _m.Called(t)
+
+	var r0 []models.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) ([]models.Reservation, error)); ok {
+		return rf(t)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) []models.Reservation); ok {
+		r0 = rf(t)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Reservation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(t)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: reservation
+func (_m *ReservationRepository) Save(reservation models.Reservation) error {
+	ret := _m.Called(reservation)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Reservation) error); ok {
+		r0 = rf(reservation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewReservationRepository creates a new instance of ReservationRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReservationRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReservationRepository {
+	mock := &ReservationRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,43 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ResetCodeSender is an autogenerated mock type for the ResetCodeSender type
+type ResetCodeSender struct {
+	mock.Mock
+}
+
+// SendResetCode provides a mock function with given fields: user, code, expiresAt
+func (_m *ResetCodeSender) SendResetCode(user models.User, code string, expiresAt time.Time) error {
+	ret := _m.Called(user, code, expiresAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.User, string, time.Time) error); ok {
+		r0 = rf(user, code, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewResetCodeSender creates a new instance of ResetCodeSender. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewResetCodeSender(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ResetCodeSender {
+	mock := &ResetCodeSender{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
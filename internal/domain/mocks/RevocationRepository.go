@@ -0,0 +1,86 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RevocationRepository is an autogenerated mock type for the RevocationRepository type
+type RevocationRepository struct {
+	mock.Mock
+}
+
+// GetUserRevokedAt provides a mock function with given fields: userId
+func (_m *RevocationRepository) GetUserRevokedAt(userId string) *time.Time {
+	ret := _m.Called(userId)
+
+	var r0 *time.Time
+	if rf, ok := ret.Get(0).(func(string) *time.Time); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Time)
+		}
+	}
+
+	return r0
+}
+
+// IsSessionRevoked provides a mock function with given fields: sessionId
+func (_m *RevocationRepository) IsSessionRevoked(sessionId string) bool {
+	ret := _m.Called(sessionId)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(sessionId)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// RevokeSession provides a mock function with given fields: sessionId, until
+func (_m *RevocationRepository) RevokeSession(sessionId string, until time.Time) error {
+	ret := _m.Called(sessionId, until)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) error); ok {
+		r0 = rf(sessionId, until)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUser provides a mock function with given fields: userId, at
+func (_m *RevocationRepository) RevokeUser(userId string, at time.Time) error {
+	ret := _m.Called(userId, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) error); ok {
+		r0 = rf(userId, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewRevocationRepository creates a new instance of RevocationRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRevocationRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RevocationRepository {
+	mock := &RevocationRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,117 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// SessionRepository is an autogenerated mock type for the SessionRepository type
+type SessionRepository struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: sessionId
+func (_m *SessionRepository) Delete(sessionId string) error {
+	ret := _m.Called(sessionId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(sessionId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteByUser provides a mock function with given fields: userId
+func (_m *SessionRepository) DeleteByUser(userId string) error {
+	ret := _m.Called(userId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Get provides a mock function with given fields: sessionId
+func (_m *SessionRepository) Get(sessionId string) *models.Session {
+	ret := _m.Called(sessionId)
+
+	var r0 *models.Session
+	if rf, ok := ret.Get(0).(func(string) *models.Session); ok {
+		r0 = rf(sessionId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Session)
+		}
+	}
+
+	return r0
+}
+
+// GetByUser provides a mock function with given fields: userId
+func (_m *SessionRepository) GetByUser(userId string) []models.Session {
+	ret := _m.Called(userId)
+
+	var r0 []models.Session
+	if rf, ok := ret.Get(0).(func(string) []models.Session); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Session)
+		}
+	}
+
+	return r0
+}
+
+// Save provides a mock function with given fields: session
+func (_m *SessionRepository) Save(session models.Session) error {
+	ret := _m.Called(session)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Session) error); ok {
+		r0 = rf(session)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Touch provides a mock function with given fields: sessionId, at, remoteAddress
+func (_m *SessionRepository) Touch(sessionId string, at time.Time, remoteAddress string) error {
+	ret := _m.Called(sessionId, at, remoteAddress)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time, string) error); ok {
+		r0 = rf(sessionId, at, remoteAddress)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewSessionRepository creates a new instance of SessionRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSessionRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SessionRepository {
+	mock := &SessionRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
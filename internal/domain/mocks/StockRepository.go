@@ -0,0 +1,95 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// StockRepository is an autogenerated mock type for the StockRepository type
+type StockRepository struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: movement
+func (_m *StockRepository) Add(movement models.StockMovement) error {
+	ret := _m.Called(movement)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.StockMovement) error); ok {
+		r0 = rf(movement)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByProduct provides a mock function with given fields: productId, from, to
+func (_m *StockRepository) GetByProduct(productId string, from *time.Time, to *time.Time) ([]models.StockMovement, error) {
+	ret := _m.Called(productId, from, to)
+
+	var r0 []models.StockMovement
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, *time.Time, *time.Time) ([]models.StockMovement, error)); ok {
+		return rf(productId, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(string, *time.Time, *time.Time) []models.StockMovement); ok {
+		r0 = rf(productId, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.StockMovement)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, *time.Time, *time.Time) error); ok {
+		r1 = rf(productId, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Latest provides a mock function with given fields: productId
+func (_m *StockRepository) Latest(productId string) (*models.StockMovement, error) {
+	ret := _m.Called(productId)
+
+	var r0 *models.StockMovement
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*models.StockMovement, error)); ok {
+		return rf(productId)
+	}
+	if rf, ok := ret.Get(0).(func(string) *models.StockMovement); ok {
+		r0 = rf(productId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.StockMovement)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(productId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewStockRepository creates a new instance of StockRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewStockRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *StockRepository {
+	mock := &StockRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,107 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ThresholdRepository is an autogenerated mock type for the ThresholdRepository type
+type ThresholdRepository struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: userId, productId
+func (_m *ThresholdRepository) Delete(userId string, productId string) error {
+	ret := _m.Called(userId, productId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(userId, productId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByProduct provides a mock function with given fields: productId
+func (_m *ThresholdRepository) GetByProduct(productId string) ([]models.UserThreshold, error) {
+	ret := _m.Called(productId)
+
+	var r0 []models.UserThreshold
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.UserThreshold, error)); ok {
+		return rf(productId)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.UserThreshold); ok {
+		r0 = rf(productId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UserThreshold)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(productId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByUser provides a mock function with given fields: userId
+func (_m *ThresholdRepository) GetByUser(userId string) ([]models.UserThreshold, error) {
+	ret := _m.Called(userId)
+
+	var r0 []models.UserThreshold
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.UserThreshold, error)); ok {
+		return rf(userId)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.UserThreshold); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UserThreshold)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: threshold
+func (_m *ThresholdRepository) Save(threshold models.UserThreshold) error {
+	ret := _m.Called(threshold)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.UserThreshold) error); ok {
+		r0 = rf(threshold)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewThresholdRepository creates a new instance of ThresholdRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewThresholdRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ThresholdRepository {
+	mock := &ThresholdRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
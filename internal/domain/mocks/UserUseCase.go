@@ -0,0 +1,121 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/kkcaz/shu-dades-server/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// UserUseCase is an autogenerated mock type for the UserUseCase type
+type UserUseCase struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: request
+func (_m *UserUseCase) Create(request models.CreateUserRequest) (*models.UserInfo, error) {
+	ret := _m.Called(request)
+
+	var r0 *models.UserInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.CreateUserRequest) (*models.UserInfo, error)); ok {
+		return rf(request)
+	}
+	if rf, ok := ret.Get(0).(func(models.CreateUserRequest) *models.UserInfo); ok {
+		r0 = rf(request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UserInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.CreateUserRequest) error); ok {
+		r1 = rf(request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: userId
+func (_m *UserUseCase) Delete(userId string) error {
+	ret := _m.Called(userId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetDisabled provides a mock function with given fields: userId, disabled
+func (_m *UserUseCase) SetDisabled(userId string, disabled bool) error {
+	ret := _m.Called(userId, disabled)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, bool) error); ok {
+		r0 = rf(userId, disabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetRole provides a mock function with given fields: userId, role
+func (_m *UserUseCase) SetRole(userId string, role models.Role) error {
+	ret := _m.Called(userId, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, models.Role) error); ok {
+		r0 = rf(userId, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: request
+func (_m *UserUseCase) Update(request models.UpdateUserRequest) (*models.UserInfo, error) {
+	ret := _m.Called(request)
+
+	var r0 *models.UserInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.UpdateUserRequest) (*models.UserInfo, error)); ok {
+		return rf(request)
+	}
+	if rf, ok := ret.Get(0).(func(models.UpdateUserRequest) *models.UserInfo); ok {
+		r0 = rf(request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UserInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.UpdateUserRequest) error); ok {
+		r1 = rf(request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewUserUseCase creates a new instance of UserUseCase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUserUseCase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UserUseCase {
+	mock := &UserUseCase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
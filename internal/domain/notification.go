@@ -9,9 +9,14 @@ type NotificationRepository interface {
 }
 
 type NotificationUseCase interface {
-	Get(userId string) ([]models.Notification, error)
+	Get(userId string, request models.PageRequest) ([]models.Notification, models.PageInfo, error)
 	Add(userId string, message string) error
-	AddAll(message string) error
+	AddAll(senderId string, message string) error
 	AddForUsers(message string, users []string) error
+	// Record adds a notification for each user through tx, as part of its
+	// transaction. Publish must be called once the transaction has committed.
+	Record(tx Tx, message string, users []string) error
+	// Publish pushes a recorded notification to its users' broadcast subscriptions.
+	Publish(message string, users []string) error
 	Delete(userId string, notificationId string) error
 }
@@ -0,0 +1,20 @@
+package domain
+
+import "github.com/kkcaz/shu-dades-server/pkg/models"
+
+type OrganisationRepository interface {
+	GetAll() ([]models.Organisation, error)
+	GetById(id string) (*models.Organisation, error)
+	Create(organisation models.Organisation) error
+	Update(organisation models.Organisation) error
+	Delete(id string) error
+}
+
+type OrganisationUseCase interface {
+	GetAll() ([]models.Organisation, error)
+	Get(id string) (*models.Organisation, error)
+	Create(request models.CreateOrganisationRequest) (*models.Organisation, error)
+	Update(request models.UpdateOrganisationRequest) (*models.Organisation, error)
+	Delete(id string) error
+	GetScope(userId string) (*models.TenantScope, error)
+}
@@ -1,27 +1,92 @@
 package domain
 
-import "github.com/kkcaz/shu-dades-server/pkg/models"
+import (
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// ErrVersionConflict is returned when a product is saved over a version other
+// than the one it was read at.
+var ErrVersionConflict = errors.New("product has been changed since it was read")
 
 type ProductRepository interface {
 	Get(id string) (*models.Product, error)
 	GetAll() ([]models.Product, error)
+	// GetAllByName returns every product ordered case-insensitively by name.
+	GetAllByName() ([]models.Product, error)
+	// GetBySku returns the supplier's products with the SKU, matched
+	// case-insensitively.
+	GetBySku(supplierId string, sku string) ([]models.Product, error)
 	Create(product models.Product) error
+	// Update replaces the product in place if the stored product is still at
+	// version, failing with ErrVersionConflict if it has changed or gone.
+	Update(product models.Product, version int) error
 	Delete(id string) error
 	Subscribe(productId string, subType string, userId string) error
 	Unsubscribe(productId string, subType string, userId string) error
 	GetSubscriptions(subType string) ([]models.ProductSubscription, error)
 	GetSubscriptionsByUser(userId string) ([]models.ProductSubscription, error)
+	// GetProductSubscribers returns the users subscribed to the product with any
+	// subscription type.
+	GetProductSubscribers(productId string) ([]string, error)
+}
+
+// StockRepository is the stock ledger. Movements are kept in the order they were
+// added, which is also their time order.
+type StockRepository interface {
+	Add(movement models.StockMovement) error
+	// Latest returns the product's most recent movement, or nil if it has none.
+	Latest(productId string) (*models.StockMovement, error)
+	// GetByProduct returns the product's movements at or after from and before
+	// to. Either may be nil.
+	GetByProduct(productId string, from *time.Time, to *time.Time) ([]models.StockMovement, error)
+}
+
+// ThresholdRepository holds users' own low stock thresholds, at most one per
+// user and product.
+type ThresholdRepository interface {
+	Save(threshold models.UserThreshold) error
+	Delete(userId string, productId string) error
+	GetByProduct(productId string) ([]models.UserThreshold, error)
+	GetByUser(userId string) ([]models.UserThreshold, error)
+}
+
+// ReservationRepository holds stock reserved for users, including reservations
+// that are no longer held. Lists are ordered by when the reservation was made.
+type ReservationRepository interface {
+	// Get returns the reservation, or nil if it doesn't exist.
+	Get(id string) (*models.Reservation, error)
+	// Save adds the reservation or replaces the one with its ID.
+	Save(reservation models.Reservation) error
+	GetByProduct(productId string) ([]models.Reservation, error)
+	GetByUser(userId string) ([]models.Reservation, error)
+	// GetExpired returns the held reservations that expire at or before t.
+	GetExpired(t time.Time) ([]models.Reservation, error)
 }
 
 type ProductUseCase interface {
-	Get(id string) (*models.Product, error)
-	GetAll() ([]models.Product, error)
-	Search(pageNumber int, pageSize int, sortBy models.SortBy, order models.Order) ([]models.Product, error)
-	Create(product models.Product) error
-	Update(product *models.Product) error
+	Get(userId string, id string) (*models.Product, error)
+	GetAll(userId string) ([]models.Product, error)
+	Search(userId string, request models.SearchRequest) ([]models.Product, models.PageInfo, error)
+	Create(product *models.Product) error
+	Update(userId string, product *models.Product) error
 	Delete(id string) error
 	Subscribe(productId string, subType string, userId string) error
 	Unsubscribe(productId string, subType string, userId string) error
 	SendProductNotifications(subType string) error
 	GetProductSubscriptions(userId string) ([]models.ProductSubscription, error)
+	MoveStock(userId string, request models.StockMovementRequest) (*models.StockMovement, error)
+	GetStockMovements(request models.StockHistoryRequest) ([]models.StockMovement, error)
+	SetUserThreshold(userId string, request models.UserThresholdRequest) error
+	GetUserThresholds(userId string) ([]models.UserThreshold, error)
+	ImportProducts(userId string, request models.ImportProductsRequest) (*models.ImportReport, error)
+	ExportProducts(request models.ExportProductsRequest) (string, error)
+	GetStockLevel(userId string, productId string) (*models.StockLevel, error)
+	Reserve(userId string, request models.ReservationRequest) (*models.Reservation, error)
+	ExtendReservation(userId string, request models.ExtendReservationRequest) (*models.Reservation, error)
+	ConvertReservation(userId string, id string) (*models.Reservation, error)
+	ReleaseReservation(userId string, id string) (*models.Reservation, error)
+	GetReservations(userId string, request models.ReservationListRequest) ([]models.Reservation, error)
+	ExpireReservations() error
 }
@@ -0,0 +1,25 @@
+package domain
+
+// Tx gives access to repositories whose changes are part of one transaction.
+type Tx interface {
+	Products() ProductRepository
+	Stock() StockRepository
+	Thresholds() ThresholdRepository
+	Reservations() ReservationRepository
+	Chats() ChatRepository
+	Notifications() NotificationRepository
+}
+
+type Transactor interface {
+	// Transaction runs fn and commits every change made through tx if it returns
+	// nil, discarding them otherwise.
+	Transaction(fn func(tx Tx) error) error
+}
+
+// Store is a storage backend. Its repositories can be used directly, with each
+// call committed on its own, or through a transaction.
+type Store interface {
+	Tx
+	Transactor
+	Close() error
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "github.com/kkcaz/shu-dades-server/pkg/models"
+
+type UserRepository interface {
+	GetAll() ([]models.User, error)
+	GetById(id string) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	Create(user models.User) error
+	Update(user models.User) error
+	Delete(id string) error
+}
+
+type UserUseCase interface {
+	Create(request models.CreateUserRequest) (*models.UserInfo, error)
+	Update(request models.UpdateUserRequest) (*models.UserInfo, error)
+	SetDisabled(userId string, disabled bool) error
+	SetRole(userId string, role models.Role) error
+	Delete(userId string) error
+}
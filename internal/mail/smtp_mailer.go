@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/pkg/errors"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+type smtpMailer struct {
+	Logger  slog.Logger
+	address string
+	from    string
+	auth    smtp.Auth
+}
+
+func NewSMTPMailer(cfg config.Mail, logger slog.Logger) domain.Mailer {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	return &smtpMailer{
+		Logger:  logger,
+		address: net.JoinHostPort(cfg.Host, cfg.Port),
+		from:    cfg.From,
+		auth:    auth,
+	}
+}
+
+func (s *smtpMailer) Send(to string, subject string, body string) error {
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return errors.New("mail headers must not contain line breaks")
+	}
+
+	s.Logger.Info("sending mail", "to", to, "subject", subject)
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n", s.from, to, subject, body)
+
+	err := smtp.SendMail(s.address, s.auth, s.from, []string{to}, []byte(message))
+	if err != nil {
+		return errors.Wrapf(err, "failed to send mail to %s", to)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"bufio"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+)
+
+// smtpStub is a minimal SMTP server that accepts a single message.
+type smtpStub struct {
+	listener net.Listener
+	from     string
+	to       []string
+	data     string
+	done     chan struct{}
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	stub := &smtpStub{
+		listener: listener,
+		done:     make(chan struct{}),
+	}
+	go stub.serve()
+	t.Cleanup(func() { listener.Close() })
+	return stub
+}
+
+func (s *smtpStub) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 stub ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 stub")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			s.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 ok")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			s.to = append(s.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 ok")
+		case command == "DATA":
+			reply("354 send data")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.data = data.String()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	stub := newSMTPStub(t)
+	host, port, err := net.SplitHostPort(stub.listener.Addr().String())
+	assert.NoError(t, err)
+
+	mailer := NewSMTPMailer(config.Mail{
+		Host: host,
+		Port: port,
+		From: "no-reply@dades.local",
+	}, *slog.Default())
+
+	err = mailer.Send("customer@example.com", "Your password reset code", "Your code is 12345678")
+	assert.NoError(t, err)
+	<-stub.done
+
+	assert.Equal(t, "no-reply@dades.local", stub.from)
+	assert.Equal(t, []string{"customer@example.com"}, stub.to)
+	assert.Contains(t, stub.data, "Subject: Your password reset code\r\n")
+	assert.Contains(t, stub.data, "Your code is 12345678")
+}
+
+func TestSMTPMailer_Send_RejectsHeaderInjection(t *testing.T) {
+	mailer := NewSMTPMailer(config.Mail{Host: "127.0.0.1", Port: "1"}, *slog.Default())
+
+	err := mailer.Send("customer@example.com\r\nBcc: someone@example.com", "Subject", "Body")
+	assert.Error(t, err)
+}
@@ -3,8 +3,10 @@ package notification
 import (
 	"encoding/json"
 	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
 	"github.com/kkcaz/shu-dades-server/internal/router"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
 )
 
 type notificationHandler struct {
@@ -30,9 +32,21 @@ func (n notificationHandler) Get(ctx *router.RouterContext) {
 		return
 	}
 
+	var request models.PageRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
 	userClaim, err := n.Auth.GetUser(*token)
 
-	notifications, err := n.UseCase.Get(userClaim.UserId)
+	notifications, pageInfo, err := n.UseCase.Get(userClaim.UserId, request)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
@@ -41,6 +55,7 @@ func (n notificationHandler) Get(ctx *router.RouterContext) {
 	ctx.JSON(200, models.NotificationListResponse{
 		StatusCode:    200,
 		Notifications: notifications,
+		PageInfo:      pageInfo,
 	})
 }
 
@@ -77,7 +92,7 @@ func (n notificationHandler) AddAll(ctx *router.RouterContext) {
 		return
 	}
 
-	err = n.UseCase.AddAll(request.Message)
+	err = n.UseCase.AddAll(ctx.User.UserId, request.Message)
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
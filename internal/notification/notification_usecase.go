@@ -3,8 +3,13 @@ package notification
 import (
 	"github.com/google/uuid"
 	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 )
 
 type notificationUseCase struct {
@@ -23,32 +28,62 @@ func NewNotificationUseCase(repository domain.NotificationRepository, auth domai
 	}
 }
 
-func (n *notificationUseCase) Get(userId string) ([]models.Notification, error) {
+// notificationKey orders notifications oldest first. Notifications from before
+// they were timestamped come first, in ID order.
+type notificationKey struct {
+	CreatedAt time.Time `json:"t"`
+	Id        string    `json:"i"`
+}
+
+func keyOfNotification(notification models.Notification) notificationKey {
+	return notificationKey{CreatedAt: notification.CreatedAt, Id: notification.Id}
+}
+
+func (k notificationKey) compare(other notificationKey) int {
+	if c := k.CreatedAt.Compare(other.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(k.Id, other.Id)
+}
+
+// Get returns a page of userId's notifications, oldest first, or all of them when
+// the request isn't paged by cursor.
+func (n *notificationUseCase) Get(userId string, request models.PageRequest) ([]models.Notification, models.PageInfo, error) {
 	notifications, err := n.Repository.Get(userId)
 	if err != nil {
-		return nil, err
+		return nil, models.PageInfo{}, err
 	}
 
-	return notifications, nil
+	slices.SortFunc(notifications, func(a, b models.Notification) int {
+		return keyOfNotification(a).compare(keyOfNotification(b))
+	})
+
+	if !pagination.UsesCursor(request) {
+		page, info := pagination.Offset(notifications, 0, 0)
+		return page, info, nil
+	}
+
+	return pagination.Paginate(notifications, request, pagination.List("notifications"), keyOfNotification, func(notification models.Notification, key notificationKey) bool {
+		return keyOfNotification(notification).compare(key) > 0
+	})
 }
 
 func (n *notificationUseCase) Add(userId string, message string) error {
-	notification := models.Notification{
-		Id:      uuid.New().String(),
-		UserId:  userId,
-		Message: message,
-	}
+	return n.Repository.Add(newNotification(userId, message))
+}
 
-	err := n.Repository.Add(notification)
-	if err != nil {
-		return err
+func newNotification(userId string, message string) models.Notification {
+	return models.Notification{
+		Id:        uuid.New().String(),
+		UserId:    userId,
+		Message:   message,
+		CreatedAt: time.Now().UTC(),
 	}
-
-	return nil
 }
 
-func (n *notificationUseCase) AddAll(message string) error {
-	users := n.Auth.GetAllUserIds()
+// AddAll sends a notification to every user visible to the sender.
+func (n *notificationUseCase) AddAll(senderId string, message string) error {
+	users := n.Auth.GetAllUserIds(senderId)
 	err := n.AddForUsers(message, users)
 	if err != nil {
 		return err
@@ -65,6 +100,20 @@ func (n *notificationUseCase) AddForUsers(message string, users []string) error
 		}
 	}
 
+	return n.Publish(message, users)
+}
+
+func (n *notificationUseCase) Record(tx domain.Tx, message string, users []string) error {
+	for _, user := range users {
+		err := tx.Notifications().Add(newNotification(user, message))
+		if err != nil {
+			return errors.Wrapf(err, "failed to add notification to user %s", user)
+		}
+	}
+	return nil
+}
+
+func (n *notificationUseCase) Publish(message string, users []string) error {
 	err := n.Broadcast.PublishToUsers(message, "notification", users)
 	if err != nil {
 		n.Logger.Error("failed to broadcast notification", "error", err)
@@ -1,12 +1,15 @@
 package notification
 
 import (
+	"fmt"
 	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"log/slog"
 	"testing"
+	"time"
 )
 
 func TestNotificationUseCase_Get(t *testing.T) {
@@ -44,7 +47,7 @@ func TestNotificationUseCase_Get(t *testing.T) {
 
 			repo.On("Get", tc.userId).Return(tc.expected, tc.err)
 
-			notification, err := testUc.Get(tc.userId)
+			notification, _, err := testUc.Get(tc.userId, models.PageRequest{})
 			assert.Equal(t, tc.expected, notification)
 			if err != nil {
 				assert.Error(t, err)
@@ -53,6 +56,48 @@ func TestNotificationUseCase_Get(t *testing.T) {
 	}
 }
 
+func TestNotificationUseCase_GetPages(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notifications := []models.Notification{
+		{Id: "c", CreatedAt: start.Add(time.Minute)},
+		{Id: "legacy"},
+		{Id: "b", CreatedAt: start},
+		{Id: "a", CreatedAt: start},
+	}
+
+	repo := mocks.NewNotificationRepository(t)
+	testUc := NewNotificationUseCase(repo, nil, nil, *slog.Default())
+	repo.On("Get", "test").Return(func(string) []models.Notification {
+		return append([]models.Notification(nil), notifications...)
+	}, nil)
+
+	page, pageInfo, err := testUc.Get("test", models.PageRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"legacy", "a"}, []string{page[0].Id, page[1].Id})
+	assert.Equal(t, models.PageInfo{NextCursor: pageInfo.NextCursor, HasMore: true, Total: 4}, pageInfo)
+
+	// Deleting a notification that has been seen doesn't skip any.
+	notifications = notifications[:3]
+	page, pageInfo, err = testUc.Get("test", models.PageRequest{Cursor: pageInfo.NextCursor, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, []string{page[0].Id, page[1].Id})
+	assert.False(t, pageInfo.HasMore)
+
+	_, _, err = testUc.Get("test", models.PageRequest{Cursor: "invalid"})
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	// Without a cursor or limit every notification is returned, as before
+	// notifications were paged.
+	notifications = nil
+	for i := 0; i < pagination.DefaultLimit+1; i++ {
+		notifications = append(notifications, models.Notification{Id: fmt.Sprint(i), CreatedAt: start.Add(time.Duration(i) * time.Second)})
+	}
+	page, pageInfo, err = testUc.Get("test", models.PageRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, page, pagination.DefaultLimit+1)
+	assert.Equal(t, models.PageInfo{Total: pagination.DefaultLimit + 1}, pageInfo)
+}
+
 func TestNotificationUseCase_Add(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -119,11 +164,11 @@ func TestNotificationUseCase_AddAll(t *testing.T) {
 			broadcast := mocks.NewBroadcastUseCase(t)
 			testUc := NewNotificationUseCase(repo, auth, broadcast, *logger)
 
-			auth.On("GetAllUserIds").Return([]string{"test"}, nil)
+			auth.On("GetAllUserIds", "sender").Return([]string{"test"})
 			repo.On("Add", mock.AnythingOfType("models.Notification")).Return(tc.expected)
 			broadcast.On("PublishToUsers", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("[]string")).Return(tc.expected)
 
-			err := testUc.AddAll(tc.message)
+			err := testUc.AddAll("sender", tc.message)
 			assert.Equal(t, tc.expected, err)
 			if err != nil {
 				assert.Error(t, err)
@@ -0,0 +1,128 @@
+package organisation
+
+import (
+	"encoding/json"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type OrganisationHandler struct {
+	OrganisationUseCase domain.OrganisationUseCase
+}
+
+func NewOrganisationHandler(router *router.RouterUseCase, uc domain.OrganisationUseCase) {
+	handler := OrganisationHandler{
+		OrganisationUseCase: uc,
+	}
+
+	router.AddRoute("/organisation", models.GET, handler.Get)
+	router.AddRoute("/organisation/all", models.GET, handler.GetAll)
+	router.AddRoute("/organisation", models.POST, handler.Create)
+	router.AddRoute("/organisation", models.PUT, handler.Update)
+	router.AddRoute("/organisation", models.DELETE, handler.Delete)
+}
+
+func (o OrganisationHandler) Get(ctx *router.RouterContext) {
+	var request models.RequestById
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	organisation, err := o.OrganisationUseCase.Get(request.Id)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.OrganisationResponse{
+		StatusCode:   200,
+		Organisation: organisation,
+	})
+}
+
+func (o OrganisationHandler) GetAll(ctx *router.RouterContext) {
+	organisations, err := o.OrganisationUseCase.GetAll()
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.OrganisationListResponse{
+		StatusCode:    200,
+		Organisations: organisations,
+	})
+}
+
+func (o OrganisationHandler) Create(ctx *router.RouterContext) {
+	var request models.CreateOrganisationRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	organisation, err := o.OrganisationUseCase.Create(request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.OrganisationResponse{
+		StatusCode:   200,
+		Organisation: organisation,
+	})
+}
+
+func (o OrganisationHandler) Update(ctx *router.RouterContext) {
+	var request models.UpdateOrganisationRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	organisation, err := o.OrganisationUseCase.Update(request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.OrganisationResponse{
+		StatusCode:   200,
+		Organisation: organisation,
+	})
+}
+
+func (o OrganisationHandler) Delete(ctx *router.RouterContext) {
+	var request models.RequestById
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	err = o.OrganisationUseCase.Delete(request.Id)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "Organisation deleted"))
+}
+
+func writeError(ctx *router.RouterContext, err error) {
+	switch {
+	case errors.Is(err, ErrOrganisationNotFound):
+		ctx.JSON(404, models.NewErrorResponse(404, "Organisation not found"))
+	case errors.Is(err, ErrInvalidOrganisation):
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+	case errors.Is(err, ErrOrganisationInUse):
+		ctx.JSON(409, models.NewErrorResponse(409, err.Error()))
+	default:
+		ctx.JSON(500, models.NewInternalServerError())
+	}
+}
@@ -0,0 +1,130 @@
+package organisation
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+var ErrOrganisationNotFound = errors.New("organisation not found")
+
+type organisationData struct {
+	Organisations []models.Organisation `json:"organisations"`
+}
+
+type organisationRepository struct {
+	Logger        slog.Logger
+	path          string
+	mu            sync.RWMutex
+	organisations []models.Organisation
+}
+
+func NewOrganisationRepository(logger slog.Logger) domain.OrganisationRepository {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	repo, err := newOrganisationRepository(fmt.Sprintf("%s/internal/data/organisation/organisations.json", currentDir), logger)
+	if err != nil {
+		panic(err)
+	}
+
+	return repo
+}
+
+func newOrganisationRepository(path string, logger slog.Logger) (*organisationRepository, error) {
+	var data organisationData
+	err := storage.ReadJSON(path, &data)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return &organisationRepository{
+		Logger:        logger,
+		path:          path,
+		organisations: data.Organisations,
+	}, nil
+}
+
+func (o *organisationRepository) GetAll() ([]models.Organisation, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return append([]models.Organisation{}, o.organisations...), nil
+}
+
+func (o *organisationRepository) GetById(id string) (*models.Organisation, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	for _, organisation := range o.organisations {
+		if organisation.Id == id {
+			return &organisation, nil
+		}
+	}
+
+	return nil, ErrOrganisationNotFound
+}
+
+func (o *organisationRepository) Create(organisation models.Organisation) error {
+	o.Logger.Info("creating organisation", "organisationId", organisation.Id, "name", organisation.Name)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	organisations := append([]models.Organisation{}, o.organisations...)
+	organisations = append(organisations, organisation)
+	return o.save(organisations)
+}
+
+func (o *organisationRepository) Update(organisation models.Organisation) error {
+	o.Logger.Info("updating organisation", "organisationId", organisation.Id)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	organisations := append([]models.Organisation{}, o.organisations...)
+	for i := range organisations {
+		if organisations[i].Id == organisation.Id {
+			organisations[i] = organisation
+			return o.save(organisations)
+		}
+	}
+
+	return ErrOrganisationNotFound
+}
+
+func (o *organisationRepository) Delete(id string) error {
+	o.Logger.Info("deleting organisation", "organisationId", id)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	organisations := make([]models.Organisation, 0, len(o.organisations))
+	for _, organisation := range o.organisations {
+		if organisation.Id != id {
+			organisations = append(organisations, organisation)
+		}
+	}
+
+	if len(organisations) == len(o.organisations) {
+		return ErrOrganisationNotFound
+	}
+
+	return o.save(organisations)
+}
+
+// save persists organisations and, only once that succeeds, makes them the
+// current set. The caller must hold the write lock.
+func (o *organisationRepository) save(organisations []models.Organisation) error {
+	err := storage.WriteJSON(o.path, organisationData{Organisations: organisations})
+	if err != nil {
+		return errors.Wrap(err, "failed to persist organisations")
+	}
+
+	o.organisations = organisations
+	return nil
+}
@@ -0,0 +1,178 @@
+package organisation
+
+import (
+	"github.com/google/uuid"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidOrganisation = errors.New("invalid organisation")
+	ErrOrganisationInUse   = errors.New("organisation still has members")
+)
+
+type organisationUseCase struct {
+	Repository domain.OrganisationRepository
+	Users      domain.UserRepository
+	Logger     slog.Logger
+}
+
+func NewOrganisationUseCase(repository domain.OrganisationRepository, users domain.UserRepository, logger slog.Logger) domain.OrganisationUseCase {
+	return &organisationUseCase{
+		Repository: repository,
+		Users:      users,
+		Logger:     logger,
+	}
+}
+
+func (o *organisationUseCase) GetAll() ([]models.Organisation, error) {
+	return o.Repository.GetAll()
+}
+
+func (o *organisationUseCase) Get(id string) (*models.Organisation, error) {
+	return o.Repository.GetById(id)
+}
+
+func (o *organisationUseCase) Create(request models.CreateOrganisationRequest) (*models.Organisation, error) {
+	organisation := models.Organisation{
+		Id:                uuid.New().String(),
+		Name:              strings.TrimSpace(request.Name),
+		LinkedSupplierIds: request.LinkedSupplierIds,
+	}
+
+	err := o.validate(organisation)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.Repository.Create(organisation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &organisation, nil
+}
+
+func (o *organisationUseCase) Update(request models.UpdateOrganisationRequest) (*models.Organisation, error) {
+	organisation, err := o.Repository.GetById(request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := strings.TrimSpace(request.Name); name != "" {
+		organisation.Name = name
+	}
+	organisation.LinkedSupplierIds = request.LinkedSupplierIds
+
+	err = o.validate(*organisation)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.Repository.Update(*organisation)
+	if err != nil {
+		return nil, err
+	}
+
+	return organisation, nil
+}
+
+func (o *organisationUseCase) validate(organisation models.Organisation) error {
+	if organisation.Name == "" {
+		return errors.Wrap(ErrInvalidOrganisation, "name is required")
+	}
+
+	for _, supplierId := range organisation.LinkedSupplierIds {
+		if supplierId == organisation.Id {
+			return errors.Wrap(ErrInvalidOrganisation, "an organisation can't be linked to itself")
+		}
+
+		_, err := o.Repository.GetById(supplierId)
+		if err != nil {
+			return errors.Wrapf(ErrInvalidOrganisation, "linked organisation %s does not exist", supplierId)
+		}
+	}
+
+	return nil
+}
+
+func (o *organisationUseCase) Delete(id string) error {
+	users, err := o.Users.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if user.OrganisationId == id {
+			return ErrOrganisationInUse
+		}
+	}
+
+	err = o.Repository.Delete(id)
+	if err != nil {
+		return err
+	}
+
+	// Don't leave other organisations linked to one that no longer exists.
+	organisations, err := o.Repository.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, organisation := range organisations {
+		if !slices.Contains(organisation.LinkedSupplierIds, id) {
+			continue
+		}
+
+		organisation.LinkedSupplierIds = slices.DeleteFunc(slices.Clone(organisation.LinkedSupplierIds), func(supplierId string) bool {
+			return supplierId == id
+		})
+		err = o.Repository.Update(organisation)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *organisationUseCase) GetScope(userId string) (*models.TenantScope, error) {
+	user, err := o.Users.GetById(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := &models.TenantScope{
+		UserId:         user.Id,
+		All:            user.Role == models.Admin,
+		OrganisationId: user.OrganisationId,
+	}
+
+	if scope.All || user.OrganisationId == "" {
+		return scope, nil
+	}
+
+	organisations, err := o.Repository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	scope.Organisations = []string{user.OrganisationId}
+	scope.Catalogues = []string{user.OrganisationId}
+	for _, organisation := range organisations {
+		switch {
+		case organisation.Id == user.OrganisationId:
+			scope.Organisations = append(scope.Organisations, organisation.LinkedSupplierIds...)
+			scope.Catalogues = append(scope.Catalogues, organisation.LinkedSupplierIds...)
+		case slices.Contains(organisation.LinkedSupplierIds, user.OrganisationId):
+			// Customers of the user's organisation.
+			scope.Organisations = append(scope.Organisations, organisation.Id)
+		}
+	}
+
+	return scope, nil
+}
@@ -0,0 +1,109 @@
+package organisation
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"testing"
+)
+
+func TestOrganisationUseCase_GetScope(t *testing.T) {
+	organisations := []models.Organisation{
+		{Id: "techuk", Name: "TechUK"},
+		{Id: "leeds", Name: "Leeds Gadgets", LinkedSupplierIds: []string{"techuk"}},
+		{Id: "sheffield", Name: "Sheffield Gizmos", LinkedSupplierIds: []string{"techuk"}},
+	}
+
+	testCases := []struct {
+		name                  string
+		user                  models.User
+		expectedOrganisations []string
+		expectedCatalogues    []string
+		expectedAll           bool
+	}{
+		{
+			name:                  "Customer sees own organisation and linked supplier",
+			user:                  models.User{Id: "1", Role: models.Customer, OrganisationId: "leeds"},
+			expectedOrganisations: []string{"leeds", "techuk"},
+			expectedCatalogues:    []string{"leeds", "techuk"},
+		},
+		{
+			name:                  "Supplier sees its customers but only its own catalogue",
+			user:                  models.User{Id: "1", Role: models.Supplier, OrganisationId: "techuk"},
+			expectedOrganisations: []string{"techuk", "leeds", "sheffield"},
+			expectedCatalogues:    []string{"techuk"},
+		},
+		{
+			name:        "Admin sees everything",
+			user:        models.User{Id: "1", Role: models.Admin},
+			expectedAll: true,
+		},
+		{
+			name: "User without organisation sees nothing",
+			user: models.User{Id: "1", Role: models.Customer},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewOrganisationRepository(t)
+			users := mocks.NewUserRepository(t)
+			testUc := NewOrganisationUseCase(repo, users, *slog.Default())
+
+			users.On("GetById", "1").Return(&tc.user, nil)
+			repo.On("GetAll").Return(organisations, nil).Maybe()
+
+			scope, err := testUc.GetScope("1")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedAll, scope.All)
+			assert.ElementsMatch(t, tc.expectedOrganisations, scope.Organisations)
+			assert.ElementsMatch(t, tc.expectedCatalogues, scope.Catalogues)
+		})
+	}
+}
+
+func TestOrganisationUseCase_Delete(t *testing.T) {
+	testCases := []struct {
+		name        string
+		users       []models.User
+		expectedErr error
+	}{
+		{
+			name:  "Happy path - links removed",
+			users: []models.User{{Id: "1", OrganisationId: "leeds"}},
+		},
+		{
+			name:        "Sad path - organisation has members",
+			users:       []models.User{{Id: "1", OrganisationId: "techuk"}},
+			expectedErr: ErrOrganisationInUse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewOrganisationRepository(t)
+			users := mocks.NewUserRepository(t)
+			testUc := NewOrganisationUseCase(repo, users, *slog.Default())
+
+			users.On("GetAll").Return(tc.users, nil)
+			if tc.expectedErr == nil {
+				repo.On("Delete", "techuk").Return(nil)
+				repo.On("GetAll").Return([]models.Organisation{
+					{Id: "leeds", LinkedSupplierIds: []string{"techuk"}},
+				}, nil)
+				repo.On("Update", mock.MatchedBy(func(organisation models.Organisation) bool {
+					return organisation.Id == "leeds" && len(organisation.LinkedSupplierIds) == 0
+				})).Return(nil)
+			}
+
+			err := testUc.Delete("techuk")
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"hash/fnv"
+	"sort"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// cursor is the decoded form of a page's nextCursor. It holds the sort key of
+// the last item on the page, so the next page starts after it wherever it
+// now is, and which list it came from.
+type cursor[K any] struct {
+	List string `json:"l"`
+	Key  K      `json:"k"`
+}
+
+// List identifies a list by everything that decides its contents and order,
+// such as its filters and sorting, so a cursor can't be used with another.
+func List(v any) string {
+	dat, err := json.Marshal(v)
+	if err != nil {
+		dat = []byte(fmt.Sprint(v))
+	}
+
+	hash := fnv.New64a()
+	hash.Write(dat)
+	return fmt.Sprintf("%x", hash.Sum64())
+}
+
+// Paginate returns the page of items after request's cursor. items must be
+// sorted, keyOf gives the key an item is sorted by and after reports whether an
+// item comes after a key in that order. list should come from List.
+func Paginate[T any, K any](items []T, request models.PageRequest, list string, keyOf func(T) K, after func(T, K) bool) ([]T, models.PageInfo, error) {
+	start := 0
+	if request.Cursor != "" {
+		var c cursor[K]
+		dat, err := base64.RawURLEncoding.DecodeString(request.Cursor)
+		if err == nil {
+			err = json.Unmarshal(dat, &c)
+		}
+		if err != nil || c.List != list {
+			return nil, models.PageInfo{}, ErrInvalidCursor
+		}
+
+		start = sort.Search(len(items), func(i int) bool {
+			return after(items[i], c.Key)
+		})
+	}
+
+	end := min(start+limit(request.Limit), len(items))
+	info := models.PageInfo{
+		HasMore: end < len(items),
+		Total:   len(items),
+	}
+
+	if info.HasMore {
+		dat, err := json.Marshal(cursor[K]{List: list, Key: keyOf(items[end-1])})
+		if err != nil {
+			return nil, models.PageInfo{}, errors.Wrap(err, "failed to encode cursor")
+		}
+		info.NextCursor = base64.RawURLEncoding.EncodeToString(dat)
+	}
+
+	return items[start:end], info, nil
+}
+
+// UsesCursor reports whether request is paged by cursor, which it is when it has
+// a cursor or a limit. Lists that could be paged by number before cursors were
+// added page other requests with Offset, so a request with no paging fields
+// still gets every item.
+func UsesCursor(request models.PageRequest) bool {
+	return request.Cursor != "" || request.Limit > 0
+}
+
+// Offset returns the pageNumber'th page of pageSize items, or every item when
+// pageSize isn't positive. It is kept for clients that still page by number.
+func Offset[T any](items []T, pageNumber int, pageSize int) ([]T, models.PageInfo) {
+	if pageSize <= 0 {
+		return items, models.PageInfo{Total: len(items)}
+	}
+
+	start := min((max(pageNumber, 1)-1)*pageSize, len(items))
+	end := min(start+pageSize, len(items))
+	return items[start:end], models.PageInfo{
+		HasMore: end < len(items),
+		Total:   len(items),
+	}
+}
+
+func limit(requested int) int {
+	if requested <= 0 {
+		return DefaultLimit
+	}
+	return min(requested, MaxLimit)
+}
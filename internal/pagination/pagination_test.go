@@ -0,0 +1,66 @@
+package pagination
+
+import (
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func identity(i int) int { return i }
+
+func greater(i int, key int) bool { return i > key }
+
+func TestPaginate(t *testing.T) {
+	items := make([]int, 0, 600)
+	for i := 0; i < 600; i++ {
+		items = append(items, i*2)
+	}
+	list := List("numbers")
+
+	page, info, err := Paginate(items, models.PageRequest{}, list, identity, greater)
+	assert.NoError(t, err)
+	assert.Len(t, page, DefaultLimit)
+	assert.True(t, info.HasMore)
+	assert.Equal(t, 600, info.Total)
+
+	page, _, err = Paginate(items, models.PageRequest{Limit: 1000}, list, identity, greater)
+	assert.NoError(t, err)
+	assert.Len(t, page, MaxLimit)
+
+	// Continues after the last key even if it is no longer in the list.
+	_, info, err = Paginate(items, models.PageRequest{Limit: 3}, list, identity, greater)
+	assert.NoError(t, err)
+	page, info, err = Paginate(append([]int{-1, 1, 3}, items[3:]...), models.PageRequest{Cursor: info.NextCursor, Limit: 3}, list, identity, greater)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{6, 8, 10}, page)
+
+	_, _, err = Paginate(items, models.PageRequest{Cursor: info.NextCursor}, List("letters"), identity, greater)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	page, info, err = Paginate([]int{}, models.PageRequest{}, list, identity, greater)
+	assert.NoError(t, err)
+	assert.Empty(t, page)
+	assert.Equal(t, models.PageInfo{}, info)
+}
+
+func TestOffset(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	page, info := Offset(items, 2, 2)
+	assert.Equal(t, []int{3, 4}, page)
+	assert.Equal(t, models.PageInfo{HasMore: true, Total: 5}, info)
+
+	page, info = Offset(items, 4, 2)
+	assert.Empty(t, page)
+	assert.NotNil(t, page)
+	assert.False(t, info.HasMore)
+
+	page, _ = Offset(items, 0, 0)
+	assert.Equal(t, items, page)
+}
+
+func TestUsesCursor(t *testing.T) {
+	assert.False(t, UsesCursor(models.PageRequest{}))
+	assert.True(t, UsesCursor(models.PageRequest{Limit: 10}))
+	assert.True(t, UsesCursor(models.PageRequest{Cursor: "cursor"}))
+}
@@ -0,0 +1,502 @@
+package product
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidCatalogue = errors.New("invalid catalogue file")
+
+// Every row is saved on its own, so big files are slow to import.
+const maxImportRows = 5000
+
+type columnKind int
+
+const (
+	textColumn columnKind = iota
+	numberColumn
+	// Semicolon separated in CSV files.
+	listColumn
+)
+
+type catalogueColumn struct {
+	// The product's JSON field, which is also the CSV header.
+	name string
+	kind columnKind
+	// Exported for reference and ignored on import, so an export can be
+	// edited and imported again.
+	readOnly bool
+}
+
+// catalogueColumns are the columns of an exported CSV file, in order.
+var catalogueColumns = []catalogueColumn{
+	{name: "id", readOnly: true},
+	{name: "sku"},
+	{name: "name"},
+	{name: "barcode"},
+	{name: "description"},
+	{name: "category"},
+	{name: "tags", kind: listColumn},
+	{name: "unitPrice", kind: numberColumn},
+	{name: "currency"},
+	{name: "unitOfMeasure"},
+	{name: "quantity", kind: numberColumn},
+	{name: "reserved", readOnly: true},
+	{name: "reorderThreshold", kind: numberColumn},
+	{name: "lowStock", readOnly: true},
+	{name: "version", readOnly: true},
+	{name: "createdAt", readOnly: true},
+	{name: "updatedAt", readOnly: true},
+}
+
+// JSON exports are whole products, so these are ignored on import too.
+var readOnlyFields = []string{"supplierId", "organisationId"}
+
+// catalogueRow is one product from an import file, as the JSON fields it sets.
+type catalogueRow struct {
+	row    int
+	sku    string
+	fields map[string]json.RawMessage
+	err    error
+}
+
+// ImportProducts creates or updates a product in the supplier's catalogue for
+// each row of the file, matching them to existing products by SKU. Rows are
+// saved one at a time through Create and Update, so a bad row is reported
+// without stopping the rest. A dry run checks every row without saving any.
+func (p productUseCase) ImportProducts(userId string, request models.ImportProductsRequest) (*models.ImportReport, error) {
+	rows, err := parseCatalogue(request.Format, request.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) > maxImportRows {
+		return nil, errors.Wrapf(ErrInvalidCatalogue, "files can have at most %d products", maxImportRows)
+	}
+
+	products, err := p.ProductRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	bySku := make(map[string]models.Product)
+	for _, product := range products {
+		if product.SupplierId == request.SupplierId && product.Sku != "" {
+			bySku[strings.ToLower(product.Sku)] = product
+		}
+	}
+
+	report := &models.ImportReport{
+		DryRun: request.DryRun,
+		Rows:   make([]models.ImportRowResult, 0, len(rows)),
+	}
+	seen := make(map[string]int)
+	for _, row := range rows {
+		result := models.ImportRowResult{Row: row.row, Sku: row.sku}
+		result.Action, err = p.importRow(userId, request, row, bySku, seen)
+		if err != nil {
+			message, ok := rowError(err)
+			if !ok {
+				return nil, errors.Wrapf(err, "failed to import row %d", row.row)
+			}
+			result.Action = models.ImportFailed
+			result.Error = message
+		}
+
+		switch result.Action {
+		case models.ImportCreated:
+			report.Created++
+		case models.ImportUpdated:
+			report.Updated++
+		case models.ImportUnchanged:
+			report.Unchanged++
+		case models.ImportFailed:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	p.Logger.Info("imported products", "supplierId", request.SupplierId, "dryRun", request.DryRun, "created", report.Created, "updated", report.Updated, "failed", report.Failed)
+	return report, nil
+}
+
+func (p productUseCase) importRow(userId string, request models.ImportProductsRequest, row catalogueRow, bySku map[string]models.Product, seen map[string]int) (models.ImportAction, error) {
+	if row.err != nil {
+		return "", row.err
+	}
+
+	if row.sku == "" {
+		return "", errors.Wrap(ErrInvalidProduct, "sku is required")
+	}
+
+	key := strings.ToLower(row.sku)
+	if previous, ok := seen[key]; ok {
+		return "", errors.Wrapf(ErrInvalidProduct, "sku is also on row %d", previous)
+	}
+	seen[key] = row.row
+
+	existing, exists := bySku[key]
+	product := models.Product{SupplierId: request.SupplierId}
+	if exists {
+		product = existing
+	}
+
+	product, err := applyFields(product, row.fields)
+	if err != nil {
+		return "", err
+	}
+
+	err = normaliseProduct(&product)
+	if err != nil {
+		return "", err
+	}
+
+	if product.Quantity < 0 {
+		return "", errors.Wrap(ErrInvalidProduct, "quantity can't be negative")
+	}
+
+	// Update checks this too, but a dry run doesn't get that far.
+	if exists && product.Quantity < existing.Reserved {
+		return "", errors.Wrapf(ErrInsufficientStock, "%d in stock of which %d is reserved", existing.Quantity, existing.Reserved)
+	}
+
+	if exists && sameProduct(existing, product) {
+		return models.ImportUnchanged, nil
+	}
+
+	if !exists {
+		if !request.DryRun {
+			err = p.Create(&product)
+		}
+		return models.ImportCreated, err
+	}
+
+	if !request.DryRun {
+		err = p.Update(userId, &product)
+	}
+	return models.ImportUpdated, err
+}
+
+// rowError returns the message to report for a row that failed with err, or
+// false if the failure wasn't the row's fault.
+func rowError(err error) (string, bool) {
+	switch {
+	case errors.Is(err, ErrInsufficientStock),
+		errors.Is(err, ErrInvalidThreshold),
+		errors.Is(err, ErrInvalidProduct),
+		errors.Is(err, ErrDuplicateSku),
+		errors.Is(err, ErrInvalidCatalogue):
+		return err.Error(), true
+	case errors.Is(err, domain.ErrVersionConflict):
+		return "product was changed during the import, import it again", true
+	default:
+		return "", false
+	}
+}
+
+// applyFields returns product with the fields set by an import row written
+// over it. Fields the row doesn't have keep their value.
+func applyFields(product models.Product, fields map[string]json.RawMessage) (models.Product, error) {
+	data, err := json.Marshal(product)
+	if err != nil {
+		return product, err
+	}
+
+	var merged map[string]json.RawMessage
+	err = json.Unmarshal(data, &merged)
+	if err != nil {
+		return product, err
+	}
+
+	for name, value := range fields {
+		merged[name] = value
+	}
+
+	data, err = json.Marshal(merged)
+	if err != nil {
+		return product, err
+	}
+
+	var result models.Product
+	err = json.Unmarshal(data, &result)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return product, errors.Wrapf(ErrInvalidCatalogue, "%s can't be a %s", typeErr.Field, typeErr.Value)
+	}
+	if err != nil {
+		return product, err
+	}
+	return result, nil
+}
+
+// sameProduct reports whether importing a row would leave the product as it is.
+func sameProduct(a models.Product, b models.Product) bool {
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aData, bData)
+}
+
+// parseCatalogue reads the rows of an import file. Problems with the whole
+// file are returned as errors, problems with a row are kept with the row.
+func parseCatalogue(format models.CatalogueFormat, data string) ([]catalogueRow, error) {
+	switch format {
+	case models.CSV:
+		return parseCSV(data)
+	case models.JSON:
+		return parseJSON(data)
+	default:
+		return nil, errors.Wrapf(ErrInvalidCatalogue, "unknown format %q, use csv or json", format)
+	}
+}
+
+func parseCSV(data string) ([]catalogueRow, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.Wrap(ErrInvalidCatalogue, "file is empty")
+	}
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidCatalogue, err.Error())
+	}
+
+	// Spreadsheets often save CSV files with a byte order mark.
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	columns := make([]*catalogueColumn, len(header))
+	for i, name := range header {
+		column := findColumn(name)
+		if column == nil {
+			return nil, errors.Wrapf(ErrInvalidCatalogue, "unknown column %q", name)
+		}
+		for _, other := range columns[:i] {
+			if other == column {
+				return nil, errors.Wrapf(ErrInvalidCatalogue, "column %q appears more than once", column.name)
+			}
+		}
+		columns[i] = column
+	}
+	if !slices.Contains(columns, findColumn("sku")) {
+		return nil, errors.Wrap(ErrInvalidCatalogue, "file must have a sku column")
+	}
+
+	var rows []catalogueRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, errors.Wrap(ErrInvalidCatalogue, err.Error())
+		}
+
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			rows = append(rows, catalogueRow{
+				row: line,
+				err: errors.Wrapf(ErrInvalidCatalogue, "row has %d columns but the header has %d", len(record), len(header)),
+			})
+			continue
+		}
+
+		row := catalogueRow{row: line, fields: make(map[string]json.RawMessage)}
+		for i, cell := range record {
+			column := columns[i]
+			if column.readOnly {
+				continue
+			}
+
+			value, err := csvValue(*column, cell)
+			if err != nil {
+				row.err = err
+				break
+			}
+			row.fields[column.name] = value
+			if column.name == "sku" {
+				row.sku = strings.TrimSpace(cell)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// findColumn returns the column with name, ignoring case, spaces, dashes and
+// underscores so headers like "Unit Price" are understood.
+func findColumn(name string) *catalogueColumn {
+	name = strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+	for i, column := range catalogueColumns {
+		if strings.ToLower(column.name) == name {
+			return &catalogueColumns[i]
+		}
+	}
+	return nil
+}
+
+// csvValue converts a cell to the JSON value of its column. Empty number cells
+// are null, which clears a reorder threshold.
+func csvValue(column catalogueColumn, cell string) (json.RawMessage, error) {
+	cell = strings.TrimSpace(cell)
+	var value any = cell
+	switch column.kind {
+	case numberColumn:
+		if cell == "" {
+			value = nil
+			break
+		}
+		number, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidCatalogue, "%s must be a whole number", column.name)
+		}
+		value = number
+	case listColumn:
+		value = strings.Split(cell, ";")
+	}
+	return json.Marshal(value)
+}
+
+func parseJSON(data string) ([]catalogueRow, error) {
+	var items []map[string]json.RawMessage
+	err := json.Unmarshal([]byte(data), &items)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidCatalogue, "file must be a JSON array of products")
+	}
+
+	rows := make([]catalogueRow, 0, len(items))
+	for i, item := range items {
+		row := catalogueRow{row: i + 1, fields: make(map[string]json.RawMessage)}
+		if sku, ok := item["sku"]; ok {
+			err := json.Unmarshal(sku, &row.sku)
+			if err != nil {
+				row.err = errors.Wrap(ErrInvalidCatalogue, "sku must be a string")
+			}
+			row.sku = strings.TrimSpace(row.sku)
+		}
+
+		for name, value := range item {
+			column := findJSONField(name)
+			if column == nil {
+				row.err = errors.Wrapf(ErrInvalidCatalogue, "unknown field %q", name)
+				break
+			}
+			if column.readOnly {
+				continue
+			}
+			row.fields[column.name] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// findJSONField returns the column for a product field in a JSON import.
+func findJSONField(name string) *catalogueColumn {
+	for _, field := range readOnlyFields {
+		if name == field {
+			return &catalogueColumn{name: name, readOnly: true}
+		}
+	}
+	for i, column := range catalogueColumns {
+		if column.name == name {
+			return &catalogueColumns[i]
+		}
+	}
+	return nil
+}
+
+// ExportProducts writes the supplier's catalogue, or every product if
+// supplierId is empty, in the request's format, ordered by name.
+func (p productUseCase) ExportProducts(request models.ExportProductsRequest) (string, error) {
+	products, err := p.ProductRepository.GetAllByName()
+	if err != nil {
+		return "", err
+	}
+
+	exported := make([]models.Product, 0, len(products))
+	for _, product := range products {
+		if request.SupplierId == "" || product.SupplierId == request.SupplierId {
+			exported = append(exported, product)
+		}
+	}
+
+	switch request.Format {
+	case models.CSV:
+		return exportCSV(exported)
+	case models.JSON:
+		data, err := json.MarshalIndent(exported, "", "  ")
+		return string(data), err
+	default:
+		return "", errors.Wrapf(ErrInvalidCatalogue, "unknown format %q, use csv or json", request.Format)
+	}
+}
+
+func exportCSV(products []models.Product) (string, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+
+	header := make([]string, len(catalogueColumns))
+	for i, column := range catalogueColumns {
+		header[i] = column.name
+	}
+	err := writer.Write(header)
+	if err != nil {
+		return "", err
+	}
+
+	for _, product := range products {
+		data, err := json.Marshal(product)
+		if err != nil {
+			return "", err
+		}
+
+		var fields map[string]json.RawMessage
+		err = json.Unmarshal(data, &fields)
+		if err != nil {
+			return "", err
+		}
+
+		record := make([]string, len(catalogueColumns))
+		for i, column := range catalogueColumns {
+			record[i], err = csvCell(fields[column.name])
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to export %s of product %s", column.name, product.Id)
+			}
+		}
+
+		err = writer.Write(record)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	return buffer.String(), writer.Error()
+}
+
+// csvCell converts a JSON value to a cell, the reverse of csvValue.
+func csvCell(value json.RawMessage) (string, error) {
+	if len(value) == 0 || string(value) == "null" {
+		return "", nil
+	}
+
+	switch value[0] {
+	case '"':
+		var text string
+		err := json.Unmarshal(value, &text)
+		return text, err
+	case '[':
+		var list []string
+		err := json.Unmarshal(value, &list)
+		return strings.Join(list, ";"), err
+	default:
+		return string(value), nil
+	}
+}
@@ -0,0 +1,181 @@
+package product
+
+import (
+	"encoding/json"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func catalogue() []models.Product {
+	return []models.Product{
+		{Id: "1", Name: "iPhone 15", Sku: "IP15", UnitOfMeasure: models.Each, Quantity: 10, SupplierId: "supplier", Version: 1},
+		{Id: "2", Name: "Xbox", Sku: "XBOX", UnitOfMeasure: models.Each, Quantity: 4, SupplierId: "other", Version: 1},
+	}
+}
+
+func TestProductUseCase_ImportProducts(t *testing.T) {
+	uc, _ := newLedgerUseCase(t, catalogue()...)
+	file := "sku,name,quantity,Unit Price,currency,tags\n" +
+		"ip15,iPhone 15,12,79900,gbp,phone;apple\n" +
+		"XBOX,Xbox Series X,1,,,\n" +
+		"PS5,,1,,,\n" +
+		"xbox,Xbox One,1,,,\n" +
+		"TV,Television,lots,,,\n" +
+		"RADIO,Radio\n"
+	request := models.ImportProductsRequest{Format: models.CSV, Data: file, DryRun: true, SupplierId: "supplier"}
+
+	expected := []models.ImportRowResult{
+		{Row: 2, Sku: "ip15", Action: models.ImportUpdated},
+		{Row: 3, Sku: "XBOX", Action: models.ImportCreated},
+		{Row: 4, Sku: "PS5", Action: models.ImportFailed, Error: "name is required: invalid product"},
+		{Row: 5, Sku: "xbox", Action: models.ImportFailed, Error: "sku is also on row 3: invalid product"},
+		{Row: 6, Sku: "TV", Action: models.ImportFailed, Error: "quantity must be a whole number: invalid catalogue file"},
+		{Row: 7, Sku: "", Action: models.ImportFailed, Error: "row has 2 columns but the header has 6: invalid catalogue file"},
+	}
+
+	// A dry run reports what would change without changing it.
+	report, err := uc.ImportProducts("supplier", request)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportReport{DryRun: true, Created: 1, Updated: 1, Failed: 4, Rows: expected}, report)
+
+	products, err := uc.GetAll("supplier")
+	assert.NoError(t, err)
+	assert.Equal(t, catalogue(), products)
+
+	request.DryRun = false
+	report, err = uc.ImportProducts("supplier", request)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportReport{Created: 1, Updated: 1, Failed: 4, Rows: expected}, report)
+
+	products, err = uc.GetAll("supplier")
+	assert.NoError(t, err)
+	assert.Len(t, products, 3)
+
+	// SKUs only match the supplier's own products.
+	iPhone, other, created := products[0], products[1], products[2]
+	assert.Equal(t, 12, iPhone.Quantity)
+	assert.Equal(t, int64(79900), iPhone.UnitPrice)
+	assert.Equal(t, "GBP", iPhone.Currency)
+	assert.Equal(t, []string{"phone", "apple"}, iPhone.Tags)
+	assert.Equal(t, 2, iPhone.Version)
+	assert.Equal(t, catalogue()[1], other)
+	assert.Equal(t, "Xbox Series X", created.Name)
+	assert.Equal(t, "supplier", created.SupplierId)
+	assert.Equal(t, 1, created.Quantity)
+
+	// Importing the same file again changes nothing.
+	report, err = uc.ImportProducts("supplier", request)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Unchanged)
+	assert.Equal(t, 0, report.Created+report.Updated)
+}
+
+func TestProductUseCase_ImportProductsReserved(t *testing.T) {
+	products := catalogue()
+	products[0].Reserved = 6
+	uc, _ := newLedgerUseCase(t, products...)
+	request := models.ImportProductsRequest{Format: models.CSV, Data: "sku,quantity\nIP15,5\n", DryRun: true, SupplierId: "supplier"}
+
+	// A dry run fails a row that would take reserved stock, as the import would.
+	expected := []models.ImportRowResult{{Row: 2, Sku: "IP15", Action: models.ImportFailed, Error: "10 in stock of which 6 is reserved: insufficient stock"}}
+	report, err := uc.ImportProducts("supplier", request)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportReport{DryRun: true, Failed: 1, Rows: expected}, report)
+
+	request.DryRun = false
+	report, err = uc.ImportProducts("supplier", request)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportReport{Failed: 1, Rows: expected}, report)
+}
+
+func TestProductUseCase_ImportProductsJSON(t *testing.T) {
+	uc, _ := newLedgerUseCase(t, catalogue()...)
+	file := `[
+		{"sku": "IP15", "quantity": 20, "reorderThreshold": 5},
+		{"sku": "NEW", "name": "New", "colour": "red"},
+		{"name": "No SKU"},
+		{"sku": "TV", "name": "Television", "quantity": "1"},
+		{"id": "2", "sku": "TV2", "name": "Television", "supplierId": "other", "version": 7}
+	]`
+
+	report, err := uc.ImportProducts("supplier", models.ImportProductsRequest{Format: models.JSON, Data: file, SupplierId: "supplier"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ImportRowResult{
+		{Row: 1, Sku: "IP15", Action: models.ImportUpdated},
+		{Row: 2, Sku: "NEW", Action: models.ImportFailed, Error: `unknown field "colour": invalid catalogue file`},
+		{Row: 3, Sku: "", Action: models.ImportFailed, Error: "sku is required: invalid product"},
+		{Row: 4, Sku: "TV", Action: models.ImportFailed, Error: "quantity can't be a string: invalid catalogue file"},
+		{Row: 5, Sku: "TV2", Action: models.ImportCreated},
+	}, report.Rows)
+
+	products, err := uc.GetAll("supplier")
+	assert.NoError(t, err)
+	assert.Len(t, products, 3)
+
+	// Fields the row doesn't have are left alone, and read-only ones ignored.
+	assert.Equal(t, "iPhone 15", products[0].Name)
+	assert.Equal(t, 20, products[0].Quantity)
+	assert.Equal(t, 5, *products[0].ReorderThreshold)
+	assert.NotEqual(t, "2", products[2].Id)
+	assert.Equal(t, "supplier", products[2].SupplierId)
+	assert.Equal(t, 1, products[2].Version)
+}
+
+func TestProductUseCase_ImportProductsInvalidFile(t *testing.T) {
+	testCases := []struct {
+		name   string
+		format models.CatalogueFormat
+		data   string
+	}{
+		{name: "Sad path - unknown format", format: "xlsx", data: "sku\nIP15\n"},
+		{name: "Sad path - empty", format: models.CSV, data: ""},
+		{name: "Sad path - unknown column", format: models.CSV, data: "sku,colour\nIP15,red\n"},
+		{name: "Sad path - repeated column", format: models.CSV, data: "sku,name,Name\nIP15,a,b\n"},
+		{name: "Sad path - no sku column", format: models.CSV, data: "name\niPhone\n"},
+		{name: "Sad path - not a JSON array", format: models.JSON, data: `{"sku":"IP15"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, _ := newLedgerUseCase(t, catalogue()...)
+			_, err := uc.ImportProducts("supplier", models.ImportProductsRequest{Format: tc.format, Data: tc.data, SupplierId: "supplier"})
+			assert.ErrorIs(t, err, ErrInvalidCatalogue)
+		})
+	}
+}
+
+func TestProductUseCase_ExportProducts(t *testing.T) {
+	threshold := 3
+	products := catalogue()
+	products[0].Description = "Has a comma, and \"quotes\""
+	products[0].Tags = []string{"phone", "apple"}
+	products[0].ReorderThreshold = &threshold
+	uc, _ := newLedgerUseCase(t, products...)
+
+	data, err := uc.ExportProducts(models.ExportProductsRequest{Format: models.CSV, SupplierId: "supplier"})
+	assert.NoError(t, err)
+	assert.Equal(t, "id,sku,name,barcode,description,category,tags,unitPrice,currency,unitOfMeasure,quantity,reserved,reorderThreshold,lowStock,version,createdAt,updatedAt\n"+
+		"1,IP15,iPhone 15,,\"Has a comma, and \"\"quotes\"\"\",,phone;apple,0,,each,10,0,3,,1,0001-01-01T00:00:00Z,0001-01-01T00:00:00Z\n", data)
+
+	// An export can be imported again as it is.
+	report, err := uc.ImportProducts("supplier", models.ImportProductsRequest{Format: models.CSV, Data: data, SupplierId: "supplier"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ImportRowResult{{Row: 2, Sku: "IP15", Action: models.ImportUnchanged}}, report.Rows)
+
+	data, err = uc.ExportProducts(models.ExportProductsRequest{Format: models.JSON})
+	assert.NoError(t, err)
+	var exported []models.Product
+	assert.NoError(t, json.Unmarshal([]byte(data), &exported))
+	assert.Equal(t, products, exported)
+
+	report, err = uc.ImportProducts("supplier", models.ImportProductsRequest{Format: models.JSON, Data: data, SupplierId: "other"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ImportRowResult{
+		{Row: 1, Sku: "IP15", Action: models.ImportCreated},
+		{Row: 2, Sku: "XBOX", Action: models.ImportUnchanged},
+	}, report.Rows)
+
+	_, err = uc.ExportProducts(models.ExportProductsRequest{Format: "xlsx"})
+	assert.ErrorIs(t, err, ErrInvalidCatalogue)
+}
@@ -0,0 +1,160 @@
+package product
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+)
+
+var benchmarkSizes = []int{1000, 10000, 100000}
+
+// Every user in the benchmark catalogue subscribes to this many products.
+const subscriptionsPerUser = 10
+
+// seedRepository writes a catalogue of size products, each with a daily
+// subscriber, straight to disk and loads a repository from it.
+func seedRepository(b *testing.B, size int) *productRepository {
+	b.Helper()
+	dir := b.TempDir()
+	productsPath := filepath.Join(dir, "products.json")
+	subscriptionsPath := filepath.Join(dir, "subscriptions.json")
+
+	products := make([]models.Product, size)
+	subscriptions := make([]models.ProductSubscription, size)
+	for i := range products {
+		products[i] = models.Product{
+			Id:             fmt.Sprintf("product-%d", i),
+			Name:           fmt.Sprintf("Product %d", (i*7919)%size),
+			Quantity:       (i * 31) % 500,
+			SupplierId:     "supplier",
+			OrganisationId: "organisation",
+		}
+		subscriptions[i] = models.ProductSubscription{
+			ProductId: products[i].Id,
+			SubType:   "daily",
+			Users:     []string{fmt.Sprintf("user-%d", i/subscriptionsPerUser)},
+		}
+	}
+
+	err := storage.WriteJSON(productsPath, productData{Products: products})
+	if err != nil {
+		b.Fatal(err)
+	}
+	err = storage.WriteJSON(subscriptionsPath, subscriptionData{Subscriptions: subscriptions})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	repo, err := newProductRepository(productsPath, subscriptionsPath, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return repo
+}
+
+func newBenchmarkUseCase(repo domain.ProductRepository) domain.ProductUseCase {
+	return NewProductUseCase(config.Product{}, testStore{repo}, discardNotifications{}, allScope{}, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func BenchmarkProductRepository_Get(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			repo := seedRepository(b, size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := repo.Get(fmt.Sprintf("product-%d", i%size))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkProductRepository_GetSubscriptionsByUser(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			repo := seedRepository(b, size)
+			users := size / subscriptionsPerUser
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := repo.GetSubscriptionsByUser(fmt.Sprintf("user-%d", i%users))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkProductUseCase_Search(b *testing.B) {
+	requests := []models.SearchRequest{
+		{SortBy: models.Name},
+		{SortBy: models.Quantity},
+		{SortBy: models.Relevance, Query: "product 1"},
+	}
+	for _, request := range requests {
+		request.PageNumber = 2
+		request.PageSize = 50
+		request.Order = models.Asc
+		for _, size := range benchmarkSizes {
+			b.Run(fmt.Sprintf("%s/%d", request.SortBy, size), func(b *testing.B) {
+				uc := newBenchmarkUseCase(seedRepository(b, size))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_, _, err := uc.Search("user", request)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkProductUseCase_SendProductNotifications(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			uc := newBenchmarkUseCase(seedRepository(b, size))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := uc.SendProductNotifications("daily")
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// allScope lets every user see every product without the overhead of a mock.
+type allScope struct {
+	domain.OrganisationUseCase
+}
+
+func (allScope) GetScope(userId string) (*models.TenantScope, error) {
+	return &models.TenantScope{UserId: userId, All: true}, nil
+}
+
+// discardNotifications drops every notification without the overhead of a mock.
+type discardNotifications struct {
+	domain.NotificationUseCase
+}
+
+func (discardNotifications) AddForUsers(message string, userIds []string) error {
+	return nil
+}
+
+func (discardNotifications) Record(tx domain.Tx, message string, userIds []string) error {
+	return nil
+}
+
+func (discardNotifications) Publish(message string, userIds []string) error {
+	return nil
+}
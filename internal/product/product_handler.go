@@ -3,8 +3,10 @@ package product
 import (
 	"encoding/json"
 	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
 	"github.com/kkcaz/shu-dades-server/internal/router"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
 )
 
 type ProductHandler struct {
@@ -27,6 +29,18 @@ func NewProductHandler(router *router.RouterUseCase, uc domain.ProductUseCase, a
 	router.AddRoute("/product/subscribe", models.POST, handler.Subscribe)
 	router.AddRoute("/product/unsubscribe", models.POST, handler.Unsubscribe)
 	router.AddRoute("/product/subscriptions", models.GET, handler.GetProductSubscriptions)
+	router.AddRoute("/product/stock", models.POST, handler.MoveStock)
+	router.AddRoute("/product/stock", models.GET, handler.GetStockMovements)
+	router.AddRoute("/product/threshold", models.PUT, handler.SetUserThreshold)
+	router.AddRoute("/product/thresholds", models.GET, handler.GetUserThresholds)
+	router.AddRoute("/product/import", models.POST, handler.ImportProducts)
+	router.AddRoute("/product/stock/level", models.GET, handler.GetStockLevel)
+	router.AddRoute("/product/reservation", models.POST, handler.Reserve)
+	router.AddRoute("/product/reservation", models.PUT, handler.ExtendReservation)
+	router.AddRoute("/product/reservation/convert", models.POST, handler.ConvertReservation)
+	router.AddRoute("/product/reservation/release", models.POST, handler.ReleaseReservation)
+	router.AddRoute("/product/reservations", models.GET, handler.GetReservations)
+	router.AddRoute("/product/export", models.GET, handler.ExportProducts)
 }
 
 func (p ProductHandler) Get(ctx *router.RouterContext) {
@@ -37,7 +51,7 @@ func (p ProductHandler) Get(ctx *router.RouterContext) {
 		return
 	}
 
-	product, err := p.ProductUseCase.Get(request.Id)
+	product, err := p.ProductUseCase.Get(ctx.User.UserId, request.Id)
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
@@ -55,7 +69,7 @@ func (p ProductHandler) Get(ctx *router.RouterContext) {
 }
 
 func (p ProductHandler) GetAll(ctx *router.RouterContext) {
-	products, err := p.ProductUseCase.GetAll()
+	products, err := p.ProductUseCase.GetAll(ctx.User.UserId)
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
@@ -75,20 +89,21 @@ func (p ProductHandler) Search(ctx *router.RouterContext) {
 		return
 	}
 
-	products, err := p.ProductUseCase.Search(searchRequest.PageNumber, searchRequest.PageSize, searchRequest.SortBy, searchRequest.Order)
-	if err != nil {
-		ctx.JSON(500, models.NewInternalServerError())
+	products, pageInfo, err := p.ProductUseCase.Search(ctx.User.UserId, searchRequest)
+	if errors.Is(err, ErrInvalidSearch) || errors.Is(err, pagination.ErrInvalidCursor) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
 		return
 	}
 
-	if products == nil {
-		ctx.JSON(404, models.NewErrorResponse(404, "Products not found"))
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
 		return
 	}
 
-	ctx.JSON(200, models.ProductListResponse{
+	ctx.JSON(200, models.ProductSearchResponse{
 		StatusCode: 200,
 		Products:   products,
+		PageInfo:   pageInfo,
 	})
 }
 
@@ -101,11 +116,45 @@ func (p ProductHandler) Create(ctx *router.RouterContext) {
 	}
 
 	product := models.Product{
-		Name:     createProductRequest.Name,
-		Quantity: createProductRequest.Quantity,
+		Name:             createProductRequest.Name,
+		Sku:              createProductRequest.Sku,
+		Barcode:          createProductRequest.Barcode,
+		Description:      createProductRequest.Description,
+		Category:         createProductRequest.Category,
+		Tags:             createProductRequest.Tags,
+		UnitPrice:        createProductRequest.UnitPrice,
+		Currency:         createProductRequest.Currency,
+		UnitOfMeasure:    createProductRequest.UnitOfMeasure,
+		Quantity:         createProductRequest.Quantity,
+		ReorderThreshold: createProductRequest.ReorderThreshold,
+		SupplierId:       ctx.User.UserId,
+	}
+
+	if ctx.User.Role == models.Admin && createProductRequest.SupplierId != "" {
+		product.SupplierId = createProductRequest.SupplierId
+	}
+
+	err = p.ProductUseCase.Create(&product)
+	if errors.Is(err, ErrInsufficientStock) {
+		ctx.JSON(400, models.NewErrorResponse(400, "Quantity can't be negative"))
+		return
+	}
+
+	if errors.Is(err, ErrInvalidThreshold) {
+		ctx.JSON(400, models.NewErrorResponse(400, "Reorder threshold can't be negative"))
+		return
+	}
+
+	if errors.Is(err, ErrInvalidProduct) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if errors.Is(err, ErrDuplicateSku) {
+		ctx.JSON(409, models.NewErrorResponse(409, err.Error()))
+		return
 	}
 
-	err = p.ProductUseCase.Create(product)
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
@@ -125,13 +174,62 @@ func (p ProductHandler) Update(ctx *router.RouterContext) {
 		return
 	}
 
-	err = p.ProductUseCase.Update(&updateProductRequest)
+	// Keys that may only adjust stock can't change anything but the quantity.
+	if ctx.User != nil && ctx.User.IsApiKey() && !ctx.User.HasScope(models.ProductsWrite) {
+		existing, err := p.ProductUseCase.Get(ctx.User.UserId, updateProductRequest.Id)
+		if err != nil {
+			ctx.JSON(500, models.NewInternalServerError())
+			return
+		}
+		if existing == nil {
+			ctx.JSON(404, models.NewErrorResponse(404, "Product not found"))
+			return
+		}
+		existing.Quantity = updateProductRequest.Quantity
+		existing.Version = updateProductRequest.Version
+		updateProductRequest = *existing
+	}
+
+	err = p.ProductUseCase.Update(ctx.User.UserId, &updateProductRequest)
+	if errors.Is(err, ErrProductNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Product not found"))
+		return
+	}
+
+	if errors.Is(err, ErrInsufficientStock) {
+		ctx.JSON(400, models.NewErrorResponse(400, "Quantity can't be negative or less than the stock reserved"))
+		return
+	}
+
+	if errors.Is(err, ErrInvalidThreshold) {
+		ctx.JSON(400, models.NewErrorResponse(400, "Reorder threshold can't be negative"))
+		return
+	}
+
+	if errors.Is(err, ErrInvalidProduct) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if errors.Is(err, ErrDuplicateSku) {
+		ctx.JSON(409, models.NewErrorResponse(409, err.Error()))
+		return
+	}
+
+	if errors.Is(err, domain.ErrVersionConflict) {
+		ctx.JSON(409, models.NewErrorResponse(409, "Product has been changed since it was read, fetch it and try again"))
+		return
+	}
+
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
 	}
 
-	ctx.JSON(200, models.NewSuccessResponse(200, "Product updated successfully"))
+	ctx.JSON(200, models.ProductResponse{
+		StatusCode: 200,
+		Product:    &updateProductRequest,
+	})
 }
 
 func (p ProductHandler) Delete(ctx *router.RouterContext) {
@@ -167,6 +265,11 @@ func (p ProductHandler) Subscribe(ctx *router.RouterContext) {
 	}
 
 	err = p.ProductUseCase.Subscribe(request.ProductId, request.SubType, userClaim.UserId)
+	if errors.Is(err, ErrProductNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Product not found"))
+		return
+	}
+
 	if err != nil {
 		ctx.JSON(500, models.NewInternalServerError())
 		return
@@ -218,3 +321,352 @@ func (p ProductHandler) GetProductSubscriptions(ctx *router.RouterContext) {
 		Subscriptions: subscriptions,
 	})
 }
+
+func (p ProductHandler) MoveStock(ctx *router.RouterContext) {
+	var request models.StockMovementRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	movement, err := p.ProductUseCase.MoveStock(ctx.User.UserId, request)
+	if errors.Is(err, ErrInvalidStockMovement) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if errors.Is(err, ErrProductNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Product not found"))
+		return
+	}
+
+	if errors.Is(err, ErrInsufficientStock) {
+		ctx.JSON(409, models.NewErrorResponse(409, err.Error()))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.StockMovementResponse{
+		StatusCode: 200,
+		Movement:   movement,
+	})
+}
+
+func (p ProductHandler) GetStockMovements(ctx *router.RouterContext) {
+	var request models.StockHistoryRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	movements, err := p.ProductUseCase.GetStockMovements(request)
+	if errors.Is(err, ErrInvalidStockQuery) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if errors.Is(err, ErrProductNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Product not found"))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.StockHistoryResponse{
+		StatusCode: 200,
+		Movements:  movements,
+	})
+}
+
+func (p ProductHandler) SetUserThreshold(ctx *router.RouterContext) {
+	var request models.UserThresholdRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	err = p.ProductUseCase.SetUserThreshold(ctx.User.UserId, request)
+	if errors.Is(err, ErrInvalidThreshold) {
+		ctx.JSON(400, models.NewErrorResponse(400, "Threshold can't be negative"))
+		return
+	}
+
+	if errors.Is(err, ErrProductNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Product not found"))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "Threshold updated"))
+}
+
+func (p ProductHandler) GetUserThresholds(ctx *router.RouterContext) {
+	thresholds, err := p.ProductUseCase.GetUserThresholds(ctx.User.UserId)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.UserThresholdListResponse{
+		StatusCode: 200,
+		Thresholds: thresholds,
+	})
+}
+
+func (p ProductHandler) ImportProducts(ctx *router.RouterContext) {
+	var request models.ImportProductsRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	if ctx.User.Role != models.Admin || request.SupplierId == "" {
+		request.SupplierId = ctx.User.UserId
+	}
+
+	report, err := p.ProductUseCase.ImportProducts(ctx.User.UserId, request)
+	if errors.Is(err, ErrInvalidCatalogue) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.ImportProductsResponse{
+		StatusCode:   200,
+		ImportReport: *report,
+	})
+}
+
+func (p ProductHandler) ExportProducts(ctx *router.RouterContext) {
+	var request models.ExportProductsRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	if ctx.User.Role != models.Admin {
+		request.SupplierId = ctx.User.UserId
+	}
+
+	data, err := p.ProductUseCase.ExportProducts(request)
+	if errors.Is(err, ErrInvalidCatalogue) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.ExportProductsResponse{
+		StatusCode: 200,
+		Format:     request.Format,
+		Data:       data,
+	})
+}
+
+func (p ProductHandler) GetStockLevel(ctx *router.RouterContext) {
+	var request models.RequestById
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	level, err := p.ProductUseCase.GetStockLevel(ctx.User.UserId, request.Id)
+	if errors.Is(err, ErrProductNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Product not found"))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.StockLevelResponse{
+		StatusCode: 200,
+		StockLevel: *level,
+	})
+}
+
+func (p ProductHandler) Reserve(ctx *router.RouterContext) {
+	var request models.ReservationRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	reservation, err := p.ProductUseCase.Reserve(ctx.User.UserId, request)
+	if errors.Is(err, ErrInvalidReservation) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if errors.Is(err, ErrProductNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Product not found"))
+		return
+	}
+
+	if errors.Is(err, ErrInsufficientStock) {
+		ctx.JSON(409, models.NewErrorResponse(409, err.Error()))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.ReservationResponse{
+		StatusCode:  200,
+		Reservation: reservation,
+	})
+}
+
+func (p ProductHandler) ExtendReservation(ctx *router.RouterContext) {
+	var request models.ExtendReservationRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	reservation, err := p.ProductUseCase.ExtendReservation(ctx.User.UserId, request)
+	if errors.Is(err, ErrInvalidReservation) {
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if errors.Is(err, ErrReservationNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Reservation not found"))
+		return
+	}
+
+	if errors.Is(err, ErrReservationClosed) {
+		ctx.JSON(409, models.NewErrorResponse(409, err.Error()))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.ReservationResponse{
+		StatusCode:  200,
+		Reservation: reservation,
+	})
+}
+
+func (p ProductHandler) ConvertReservation(ctx *router.RouterContext) {
+	var request models.RequestById
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	reservation, err := p.ProductUseCase.ConvertReservation(ctx.User.UserId, request.Id)
+	if errors.Is(err, ErrReservationNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Reservation not found"))
+		return
+	}
+
+	if errors.Is(err, ErrReservationClosed) {
+		ctx.JSON(409, models.NewErrorResponse(409, err.Error()))
+		return
+	}
+
+	if errors.Is(err, ErrProductNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Product not found"))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.ReservationResponse{
+		StatusCode:  200,
+		Reservation: reservation,
+	})
+}
+
+func (p ProductHandler) ReleaseReservation(ctx *router.RouterContext) {
+	var request models.RequestById
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	reservation, err := p.ProductUseCase.ReleaseReservation(ctx.User.UserId, request.Id)
+	if errors.Is(err, ErrReservationNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Reservation not found"))
+		return
+	}
+
+	if errors.Is(err, ErrReservationClosed) {
+		ctx.JSON(409, models.NewErrorResponse(409, err.Error()))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.ReservationResponse{
+		StatusCode:  200,
+		Reservation: reservation,
+	})
+}
+
+func (p ProductHandler) GetReservations(ctx *router.RouterContext) {
+	var request models.ReservationListRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	reservations, err := p.ProductUseCase.GetReservations(ctx.User.UserId, request)
+	if errors.Is(err, ErrProductNotFound) {
+		ctx.JSON(404, models.NewErrorResponse(404, "Product not found"))
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	ctx.JSON(200, models.ReservationListResponse{
+		StatusCode:   200,
+		Reservations: reservations,
+	})
+}
@@ -1,156 +1,431 @@
 package product
 
 import (
-	"encoding/json"
-	"fmt"
 	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/storage"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 )
 
+// Every product can be subscribed to with each of these subscription types.
+var subscriptionTypes = []string{"hourly", "daily"}
+
+var errSubscriptionNotFound = errors.New("product subscription not found")
+
 type productData struct {
-	Products []models.Product `json:"Products"`
+	Products []models.Product `json:"products"`
 }
 
-type productRepository struct {
-	Logger               slog.Logger
-	Products             []models.Product
-	ProductSubscriptions []models.ProductSubscription
+type subscriptionData struct {
+	Subscriptions []models.ProductSubscription `json:"subscriptions"`
 }
 
-func NewProductRepository(logger slog.Logger) domain.ProductRepository {
-	products, err := readProducts()
-	if err != nil {
-		panic(err)
-	}
+type subscriptionKey struct {
+	productId string
+	subType   string
+}
+
+// nameKey orders the name index case-insensitively by name, then by ID.
+type nameKey struct {
+	name string
+	id   string
+}
 
-	subscriptions := createSubscriptions(products)
+func newNameKey(product models.Product) nameKey {
+	return nameKey{name: strings.ToLower(product.Name), id: product.Id}
+}
 
-	return &productRepository{
-		Logger:               logger,
-		Products:             products,
-		ProductSubscriptions: subscriptions,
+func compareNameKeys(a nameKey, b nameKey) int {
+	if c := strings.Compare(a.name, b.name); c != 0 {
+		return c
 	}
+	return strings.Compare(a.id, b.id)
 }
 
-func readProducts() ([]models.Product, error) {
-	currentDir, err := os.Getwd()
-	if err != nil {
+// skuKey indexes products by supplier and lowercase SKU.
+type skuKey struct {
+	supplierId string
+	sku        string
+}
+
+func newSkuKey(supplierId string, sku string) skuKey {
+	return skuKey{supplierId: supplierId, sku: strings.ToLower(sku)}
+}
+
+// productRepository keeps products and subscriptions in maps keyed by ID, with
+// indexes on product name, SKU and subscriber, and writes both through to JSON
+// files.
+// Changes are made in place and undone if they can't be persisted.
+type productRepository struct {
+	Logger            slog.Logger
+	productsPath      string
+	subscriptionsPath string
+	mu                sync.RWMutex
+
+	products map[string]models.Product
+	// Product IDs in the order they were created, for listing and persisting.
+	productOrder []string
+	byName       []nameKey
+	// The IDs of the products with each SKU, in no particular order.
+	bySku map[skuKey][]string
+
+	subscriptions     map[subscriptionKey]models.ProductSubscription
+	subscriptionOrder []subscriptionKey
+	// The subscriptions each user is subscribed to, in the order they subscribed.
+	bySubscriber map[string][]subscriptionKey
+}
+
+func newProductRepository(productsPath string, subscriptionsPath string, logger slog.Logger) (*productRepository, error) {
+	var products productData
+	err := storage.ReadJSON(productsPath, &products)
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
 
-	dat, err := os.ReadFile(fmt.Sprintf("%s/internal/data/product/products.json", currentDir))
-	if err != nil {
+	var subscriptions subscriptionData
+	err = storage.ReadJSON(subscriptionsPath, &subscriptions)
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
 
-	var productData productData
-	err = json.Unmarshal(dat, &productData)
-	if err != nil {
-		return nil, err
+	repo := &productRepository{
+		Logger:            logger,
+		productsPath:      productsPath,
+		subscriptionsPath: subscriptionsPath,
+		products:          make(map[string]models.Product, len(products.Products)),
+		productOrder:      make([]string, 0, len(products.Products)),
+		byName:            make([]nameKey, 0, len(products.Products)),
+		bySku:             make(map[skuKey][]string, len(products.Products)),
+		subscriptions:     make(map[subscriptionKey]models.ProductSubscription, len(subscriptions.Subscriptions)),
+		subscriptionOrder: make([]subscriptionKey, 0, len(subscriptions.Subscriptions)),
+		bySubscriber:      make(map[string][]subscriptionKey),
 	}
 
-	return productData.Products, nil
+	for _, product := range products.Products {
+		if _, exists := repo.products[product.Id]; !exists {
+			repo.productOrder = append(repo.productOrder, product.Id)
+		}
+		repo.products[product.Id] = product
+	}
+	for _, id := range repo.productOrder {
+		repo.byName = append(repo.byName, newNameKey(repo.products[id]))
+		repo.indexSku(repo.products[id])
+	}
+	slices.SortFunc(repo.byName, compareNameKeys)
+
+	for _, subscription := range subscriptions.Subscriptions {
+		key := subscriptionKey{productId: subscription.ProductId, subType: subscription.SubType}
+		if _, exists := repo.subscriptions[key]; exists {
+			continue
+		}
+		repo.subscriptions[key] = subscription
+		repo.subscriptionOrder = append(repo.subscriptionOrder, key)
+		for _, userId := range subscription.Users {
+			repo.bySubscriber[userId] = append(repo.bySubscriber[userId], key)
+		}
+	}
+	repo.addSubscriptions(products.Products...)
+
+	return repo, nil
 }
 
-func createSubscriptions(products []models.Product) []models.ProductSubscription {
-	var productSubscriptions []models.ProductSubscription
+// addSubscriptions adds an empty subscription of every type for each of products
+// that doesn't already have one, returning the keys of those added.
+func (p *productRepository) addSubscriptions(products ...models.Product) []subscriptionKey {
+	var added []subscriptionKey
 	for _, product := range products {
-		productSubscriptions = append(productSubscriptions, models.ProductSubscription{
-			ProductId: product.Id,
-			SubType:   "hourly",
-			Users:     make([]string, 0),
-		})
-		productSubscriptions = append(productSubscriptions, models.ProductSubscription{
-			ProductId: product.Id,
-			SubType:   "daily",
-			Users:     make([]string, 0),
-		})
+		for _, subType := range subscriptionTypes {
+			key := subscriptionKey{productId: product.Id, subType: subType}
+			if _, exists := p.subscriptions[key]; exists {
+				continue
+			}
+
+			p.subscriptions[key] = models.ProductSubscription{
+				ProductId: product.Id,
+				SubType:   subType,
+				Users:     make([]string, 0),
+			}
+			p.subscriptionOrder = append(p.subscriptionOrder, key)
+			added = append(added, key)
+		}
 	}
-	return productSubscriptions
+	return added
 }
 
-func (p *productRepository) Get(id string) (*models.Product, error) {
-	for _, product := range p.Products {
-		if product.Id == id {
-			return &product, nil
-		}
+// removeSubscriptions undoes addSubscriptions. Added subscriptions are always the
+// last in order and have no subscribers.
+func (p *productRepository) removeSubscriptions(keys []subscriptionKey) {
+	for _, key := range keys {
+		delete(p.subscriptions, key)
 	}
+	p.subscriptionOrder = p.subscriptionOrder[:len(p.subscriptionOrder)-len(keys)]
+}
+
+func (p *productRepository) Get(id string) (*models.Product, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 
-	return nil, nil
+	product, ok := p.products[id]
+	if !ok {
+		return nil, nil
+	}
+	return &product, nil
 }
 
 func (p *productRepository) GetAll() ([]models.Product, error) {
-	return p.Products, nil
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	products := make([]models.Product, 0, len(p.productOrder))
+	for _, id := range p.productOrder {
+		products = append(products, p.products[id])
+	}
+	return products, nil
+}
+
+func (p *productRepository) GetAllByName() ([]models.Product, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	products := make([]models.Product, 0, len(p.byName))
+	for _, key := range p.byName {
+		products = append(products, p.products[key.id])
+	}
+	return products, nil
 }
 
+func (p *productRepository) GetBySku(supplierId string, sku string) ([]models.Product, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ids := p.bySku[newSkuKey(supplierId, sku)]
+	products := make([]models.Product, 0, len(ids))
+	for _, id := range ids {
+		products = append(products, p.products[id])
+	}
+	return products, nil
+}
+
+// Create adds the product, replacing any existing product with the same ID.
 func (p *productRepository) Create(product models.Product) error {
 	p.Logger.Debug("Creating product: {product}", "product", product)
-	p.Products = append(p.Products, product)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous, existed := p.products[product.Id]
+	p.putProduct(product, len(p.productOrder))
+	err := p.saveProducts()
+	if err != nil {
+		if existed {
+			p.putProduct(previous, 0)
+		} else {
+			p.removeProduct(product.Id)
+		}
+		return err
+	}
+
+	added := p.addSubscriptions(product)
+	if len(added) == 0 {
+		return nil
+	}
+
+	err = p.saveSubscriptions()
+	if err != nil {
+		p.removeSubscriptions(added)
+		return err
+	}
 	return nil
 }
 
+// Update replaces the product where it is, if the stored product is still at
+// version.
+func (p *productRepository) Update(product models.Product, version int) error {
+	p.Logger.Debug("Updating product: {product}", "product", product)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous, ok := p.products[product.Id]
+	if !ok || previous.Version != version {
+		return domain.ErrVersionConflict
+	}
+
+	p.putProduct(product, 0)
+	err := p.saveProducts()
+	if err != nil {
+		p.putProduct(previous, 0)
+		return err
+	}
+	return nil
+}
+
+// Delete removes the product. Its subscriptions are kept, so a product
+// recreated with the same ID keeps its subscribers.
 func (p *productRepository) Delete(id string) error {
-	for i, product := range p.Products {
-		if product.Id == id {
-			p.Products = append(p.Products[:i], p.Products[i+1:]...)
-			return nil
-		}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	product, position, ok := p.removeProduct(id)
+	if !ok {
+		return nil
 	}
 
+	err := p.saveProducts()
+	if err != nil {
+		p.putProduct(product, position)
+		return err
+	}
 	return nil
 }
 
+// putProduct stores the product and indexes its name and SKU. New products are
+// inserted at position in the creation order, existing products keep their place.
+func (p *productRepository) putProduct(product models.Product, position int) {
+	previous, existed := p.products[product.Id]
+	if existed {
+		p.unindexName(previous)
+		p.unindexSku(previous)
+	} else {
+		p.productOrder = slices.Insert(p.productOrder, position, product.Id)
+	}
+
+	p.products[product.Id] = product
+	key := newNameKey(product)
+	i, _ := slices.BinarySearchFunc(p.byName, key, compareNameKeys)
+	p.byName = slices.Insert(p.byName, i, key)
+	p.indexSku(product)
+}
+
+// removeProduct removes the product and its name and SKU from the indexes, returning it
+// along with its position in the creation order.
+func (p *productRepository) removeProduct(id string) (models.Product, int, bool) {
+	product, ok := p.products[id]
+	if !ok {
+		return models.Product{}, -1, false
+	}
+
+	delete(p.products, id)
+	p.unindexName(product)
+	p.unindexSku(product)
+	position := slices.Index(p.productOrder, id)
+	p.productOrder = slices.Delete(p.productOrder, position, position+1)
+	return product, position, true
+}
+
+func (p *productRepository) unindexName(product models.Product) {
+	i, found := slices.BinarySearchFunc(p.byName, newNameKey(product), compareNameKeys)
+	if found {
+		p.byName = slices.Delete(p.byName, i, i+1)
+	}
+}
+
+func (p *productRepository) indexSku(product models.Product) {
+	if product.Sku == "" {
+		return
+	}
+	key := newSkuKey(product.SupplierId, product.Sku)
+	p.bySku[key] = append(p.bySku[key], product.Id)
+}
+
+func (p *productRepository) unindexSku(product models.Product) {
+	if product.Sku == "" {
+		return
+	}
+	key := newSkuKey(product.SupplierId, product.Sku)
+	ids := slices.DeleteFunc(p.bySku[key], func(id string) bool {
+		return id == product.Id
+	})
+	if len(ids) == 0 {
+		delete(p.bySku, key)
+	} else {
+		p.bySku[key] = ids
+	}
+}
+
 func (p *productRepository) Subscribe(productId string, subType string, userId string) error {
 	p.Logger.Info("subscribing user to product", "productId", productId, "userId", userId)
-	var foundSubscription *models.ProductSubscription
-	for i, productSubscription := range p.ProductSubscriptions {
-		if productSubscription.ProductId == productId && productSubscription.SubType == subType {
-			foundSubscription = &p.ProductSubscriptions[i]
-		}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := subscriptionKey{productId: productId, subType: subType}
+	subscription, ok := p.subscriptions[key]
+	if !ok {
+		return errSubscriptionNotFound
 	}
 
-	if foundSubscription == nil {
-		return fmt.Errorf("product subscription not found")
+	if slices.Contains(subscription.Users, userId) {
+		return nil
 	}
 
-	users := append([]string{}, foundSubscription.Users...)
-	users = append(users, userId)
-	foundSubscription.Users = users
+	previous := subscription
+	subscription.Users = append(slices.Clip(subscription.Users), userId)
+	p.subscriptions[key] = subscription
+	p.bySubscriber[userId] = append(p.bySubscriber[userId], key)
 
+	err := p.saveSubscriptions()
+	if err != nil {
+		p.subscriptions[key] = previous
+		p.unindexSubscriber(userId, key)
+		return err
+	}
 	return nil
 }
 
 func (p *productRepository) Unsubscribe(productId string, subType string, userId string) error {
 	p.Logger.Info("unsubscribing user to product", "productId", productId, "userId", userId)
-	var foundSubscription *models.ProductSubscription
-	for i, productSubscription := range p.ProductSubscriptions {
-		if productSubscription.ProductId == productId && productSubscription.SubType == subType {
-			foundSubscription = &p.ProductSubscriptions[i]
-		}
-	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	if foundSubscription == nil {
-		return fmt.Errorf("product subscription not found")
+	key := subscriptionKey{productId: productId, subType: subType}
+	subscription, ok := p.subscriptions[key]
+	if !ok {
+		return errSubscriptionNotFound
 	}
 
+	previous := subscription
+	previousKeys := p.bySubscriber[userId]
 	var users []string
-	for _, user := range foundSubscription.Users {
+	for _, user := range subscription.Users {
 		if user != userId {
 			users = append(users, user)
 		}
 	}
-	foundSubscription.Users = users
+	subscription.Users = users
+	p.subscriptions[key] = subscription
+	p.unindexSubscriber(userId, key)
 
+	err := p.saveSubscriptions()
+	if err != nil {
+		p.subscriptions[key] = previous
+		p.bySubscriber[userId] = previousKeys
+		return err
+	}
 	return nil
 }
 
+func (p *productRepository) unindexSubscriber(userId string, key subscriptionKey) {
+	keys := slices.DeleteFunc(slices.Clone(p.bySubscriber[userId]), func(subscribed subscriptionKey) bool {
+		return subscribed == key
+	})
+	if len(keys) == 0 {
+		delete(p.bySubscriber, userId)
+		return
+	}
+	p.bySubscriber[userId] = keys
+}
+
 func (p *productRepository) GetSubscriptions(subType string) ([]models.ProductSubscription, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	var subscriptions []models.ProductSubscription
-	for _, productSubscription := range p.ProductSubscriptions {
-		if productSubscription.SubType == subType {
-			subscriptions = append(subscriptions, productSubscription)
+	for _, key := range p.subscriptionOrder {
+		if key.subType == subType {
+			subscriptions = append(subscriptions, p.subscriptions[key])
 		}
 	}
 
@@ -158,14 +433,58 @@ func (p *productRepository) GetSubscriptions(subType string) ([]models.ProductSu
 }
 
 func (p *productRepository) GetSubscriptionsByUser(userId string) ([]models.ProductSubscription, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	var subscriptions []models.ProductSubscription
-	for _, productSubscription := range p.ProductSubscriptions {
-		for _, user := range productSubscription.Users {
-			if user == userId {
-				subscriptions = append(subscriptions, productSubscription)
+	for _, key := range p.bySubscriber[userId] {
+		subscriptions = append(subscriptions, p.subscriptions[key])
+	}
+
+	return subscriptions, nil
+}
+
+func (p *productRepository) GetProductSubscribers(productId string) ([]string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var users []string
+	for _, subType := range subscriptionTypes {
+		for _, userId := range p.subscriptions[subscriptionKey{productId: productId, subType: subType}].Users {
+			if !slices.Contains(users, userId) {
+				users = append(users, userId)
 			}
 		}
 	}
+	return users, nil
+}
 
-	return subscriptions, nil
+// saveProducts persists the current products. The caller must hold the write
+// lock and undo its change if this fails.
+func (p *productRepository) saveProducts() error {
+	products := make([]models.Product, 0, len(p.productOrder))
+	for _, id := range p.productOrder {
+		products = append(products, p.products[id])
+	}
+
+	err := storage.WriteJSON(p.productsPath, productData{Products: products})
+	if err != nil {
+		return errors.Wrap(err, "failed to persist products")
+	}
+	return nil
+}
+
+// saveSubscriptions persists the current subscriptions. The caller must hold the
+// write lock and undo its change if this fails.
+func (p *productRepository) saveSubscriptions() error {
+	subscriptions := make([]models.ProductSubscription, 0, len(p.subscriptionOrder))
+	for _, key := range p.subscriptionOrder {
+		subscriptions = append(subscriptions, p.subscriptions[key])
+	}
+
+	err := storage.WriteJSON(p.subscriptionsPath, subscriptionData{Subscriptions: subscriptions})
+	if err != nil {
+		return errors.Wrap(err, "failed to persist product subscriptions")
+	}
+	return nil
 }
@@ -0,0 +1,175 @@
+package product
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProductRepository_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	productsPath := filepath.Join(dir, "products.json")
+	subscriptionsPath := filepath.Join(dir, "subscriptions.json")
+	logger := slog.Default()
+
+	repo, err := newProductRepository(productsPath, subscriptionsPath, *logger)
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.Create(models.Product{Id: "1", Name: "iPhone 15", Quantity: 10}))
+	assert.NoError(t, repo.Create(models.Product{Id: "2", Name: "Playstation 5", Quantity: 5}))
+	assert.NoError(t, repo.Subscribe("1", "daily", "john"))
+	assert.NoError(t, repo.Delete("2"))
+
+	// Recreating a product must keep its subscribers.
+	assert.NoError(t, repo.Delete("1"))
+	assert.NoError(t, repo.Create(models.Product{Id: "1", Name: "iPhone 15", Quantity: 8}))
+
+	reloaded, err := newProductRepository(productsPath, subscriptionsPath, *logger)
+	assert.NoError(t, err)
+
+	products, err := reloaded.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Product{{Id: "1", Name: "iPhone 15", Quantity: 8}}, products)
+
+	subscriptions, err := reloaded.GetSubscriptionsByUser("john")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ProductSubscription{{ProductId: "1", SubType: "daily", Users: []string{"john"}}}, subscriptions)
+
+	err = reloaded.Subscribe("missing", "daily", "john")
+	assert.Error(t, err)
+}
+
+func TestProductRepository_Indexes(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := newProductRepository(filepath.Join(dir, "products.json"), filepath.Join(dir, "subscriptions.json"), *slog.Default())
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.Create(models.Product{Id: "1", Name: "playstation 5"}))
+	assert.NoError(t, repo.Create(models.Product{Id: "2", Name: "iPhone 15"}))
+	assert.NoError(t, repo.Create(models.Product{Id: "3", Name: "Xbox"}))
+
+	// Replacing a product moves it in the name index without duplicating it.
+	assert.NoError(t, repo.Create(models.Product{Id: "3", Name: "Apple Watch"}))
+
+	products, err := repo.GetAllByName()
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Product{
+		{Id: "3", Name: "Apple Watch"},
+		{Id: "2", Name: "iPhone 15"},
+		{Id: "1", Name: "playstation 5"},
+	}, products)
+
+	products, err = repo.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, []string{products[0].Id, products[1].Id, products[2].Id})
+
+	assert.NoError(t, repo.Delete("2"))
+	products, err = repo.GetAllByName()
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+
+	assert.NoError(t, repo.Subscribe("1", "daily", "john"))
+	assert.NoError(t, repo.Subscribe("1", "daily", "john"))
+	assert.NoError(t, repo.Subscribe("3", "hourly", "john"))
+	subscriptions, err := repo.GetSubscriptionsByUser("john")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ProductSubscription{
+		{ProductId: "1", SubType: "daily", Users: []string{"john"}},
+		{ProductId: "3", SubType: "hourly", Users: []string{"john"}},
+	}, subscriptions)
+
+	assert.NoError(t, repo.Unsubscribe("1", "daily", "john"))
+	subscriptions, err = repo.GetSubscriptionsByUser("john")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ProductSubscription{{ProductId: "3", SubType: "hourly", Users: []string{"john"}}}, subscriptions)
+}
+
+func TestProductRepository_SkuIndex(t *testing.T) {
+	dir := t.TempDir()
+	productsPath := filepath.Join(dir, "products.json")
+	repo, err := newProductRepository(productsPath, filepath.Join(dir, "subscriptions.json"), *slog.Default())
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.Create(models.Product{Id: "1", Sku: "IP-15", SupplierId: "apple"}))
+	assert.NoError(t, repo.Create(models.Product{Id: "2", Sku: "ip-15", SupplierId: "samsung"}))
+	assert.NoError(t, repo.Create(models.Product{Id: "3", Name: "No SKU", SupplierId: "apple"}))
+
+	products, err := repo.GetBySku("apple", "Ip-15")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Product{{Id: "1", Sku: "IP-15", SupplierId: "apple"}}, products)
+
+	// Changing the SKU moves the product in the index.
+	assert.NoError(t, repo.Update(models.Product{Id: "1", Sku: "IP-15-PRO", SupplierId: "apple", Version: 1}, 0))
+	products, err = repo.GetBySku("apple", "ip-15")
+	assert.NoError(t, err)
+	assert.Empty(t, products)
+	products, err = repo.GetBySku("apple", "ip-15-pro")
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+
+	assert.NoError(t, repo.Delete("2"))
+	products, err = repo.GetBySku("samsung", "ip-15")
+	assert.NoError(t, err)
+	assert.Empty(t, products)
+
+	// The index is rebuilt when the repository is loaded.
+	reloaded, err := newProductRepository(productsPath, filepath.Join(dir, "subscriptions.json"), *slog.Default())
+	assert.NoError(t, err)
+	products, err = reloaded.GetBySku("APPLE", "ip-15-pro")
+	assert.NoError(t, err)
+	assert.Empty(t, products)
+	products, err = reloaded.GetBySku("apple", "ip-15-pro")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Product{{Id: "1", Sku: "IP-15-PRO", SupplierId: "apple", Version: 1}}, products)
+}
+
+func TestProductRepository_Update(t *testing.T) {
+	dir := t.TempDir()
+	productsPath := filepath.Join(dir, "products.json")
+	repo, err := newProductRepository(productsPath, filepath.Join(dir, "subscriptions.json"), *slog.Default())
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.Create(models.Product{Id: "1", Name: "iPhone 15", Version: 1}))
+	assert.NoError(t, repo.Create(models.Product{Id: "2", Name: "Xbox", Version: 1}))
+
+	assert.NoError(t, repo.Update(models.Product{Id: "1", Name: "Apple iPhone 15", Version: 2}, 1))
+	assert.ErrorIs(t, repo.Update(models.Product{Id: "1", Name: "iPhone", Version: 2}, 1), domain.ErrVersionConflict)
+	assert.ErrorIs(t, repo.Update(models.Product{Id: "3", Version: 1}, 0), domain.ErrVersionConflict)
+
+	// Updated products keep their place.
+	reloaded, err := newProductRepository(productsPath, filepath.Join(dir, "subscriptions.json"), *slog.Default())
+	assert.NoError(t, err)
+	products, err := reloaded.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Product{
+		{Id: "1", Name: "Apple iPhone 15", Version: 2},
+		{Id: "2", Name: "Xbox", Version: 1},
+	}, products)
+
+	products, err = reloaded.GetAllByName()
+	assert.NoError(t, err)
+	assert.Equal(t, "Apple iPhone 15", products[0].Name)
+}
+
+func TestProductRepository_LegacyProducts(t *testing.T) {
+	// Products saved before SKUs, prices and timestamps were added still load.
+	dir := t.TempDir()
+	productsPath := filepath.Join(dir, "products.json")
+	data, err := os.ReadFile("../data/product/products-basic.json")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(productsPath, data, 0644))
+
+	repo, err := newProductRepository(productsPath, filepath.Join(dir, "subscriptions.json"), *slog.Default())
+	assert.NoError(t, err)
+
+	product, err := repo.Get("166e910e-49bd-4334-8522-3939cb7e3a90")
+	assert.NoError(t, err)
+	assert.Equal(t, "Product 1", product.Name)
+	assert.Equal(t, 16, product.Quantity)
+	assert.Empty(t, product.Sku)
+	assert.True(t, product.CreatedAt.IsZero())
+}
@@ -3,124 +3,350 @@ package product
 import (
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/kkcaz/shu-dades-server/internal/config"
 	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
 	"github.com/pkg/errors"
 	"log/slog"
 	"slices"
-	"sort"
-	"strings"
+	"time"
 )
 
+var ErrProductNotFound = errors.New("product not found")
+
 type productUseCase struct {
+	Store             domain.Store
 	ProductRepository domain.ProductRepository
 	Notification      domain.NotificationUseCase
+	Organisations     domain.OrganisationUseCase
+	LowStockMargin    int
+	ReservationTTL    time.Duration
+	MaxReservationTTL time.Duration
 	Logger            slog.Logger
 }
 
-func NewProductUseCase(productRepository domain.ProductRepository, notification domain.NotificationUseCase, logger slog.Logger) domain.ProductUseCase {
+func NewProductUseCase(cfg config.Product, store domain.Store, notification domain.NotificationUseCase, organisations domain.OrganisationUseCase, logger slog.Logger) domain.ProductUseCase {
 	return &productUseCase{
-		ProductRepository: productRepository,
+		LowStockMargin:    cfg.LowStockMargin,
+		ReservationTTL:    cfg.ReservationTTL,
+		MaxReservationTTL: cfg.MaxReservationTTL,
+		Store:             store,
+		ProductRepository: store.Products(),
 		Notification:      notification,
+		Organisations:     organisations,
 		Logger:            logger,
 	}
 }
 
-func (p productUseCase) Get(id string) (*models.Product, error) {
+// Get returns the product if userId can see it, and nil if it doesn't exist or they can't.
+func (p productUseCase) Get(userId string, id string) (*models.Product, error) {
 	product, err := p.ProductRepository.Get(id)
 	if err != nil {
 		return nil, err
 	}
+
+	if product == nil {
+		return nil, nil
+	}
+
+	scope, err := p.Organisations.GetScope(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scope.CanSeeProduct(*product) {
+		return nil, nil
+	}
 	return product, nil
 }
 
-func (p productUseCase) GetAll() ([]models.Product, error) {
+// GetAll returns every product in the catalogues userId can see.
+func (p productUseCase) GetAll(userId string) ([]models.Product, error) {
 	products, err := p.ProductRepository.GetAll()
 	if err != nil {
 		return nil, err
 	}
-	return products, nil
+
+	return p.visibleTo(userId, products)
 }
 
-func (p productUseCase) Search(pageNumber int, pageSize int, sortBy models.SortBy, order models.Order) ([]models.Product, error) {
-	products, err := p.GetAll()
+// visibleTo filters products down to those in the catalogues userId can see.
+func (p productUseCase) visibleTo(userId string, products []models.Product) ([]models.Product, error) {
+	scope, err := p.Organisations.GetScope(userId)
 	if err != nil {
 		return nil, err
 	}
 
-	switch sortBy {
-	case models.Name:
-		sort.Slice(products, func(i, j int) bool {
-			return strings.ToLower(products[i].Name) < strings.ToLower(products[j].Name)
-		})
-	case models.Quantity:
-		sort.Slice(products, func(i, j int) bool {
-			return products[i].Quantity < products[j].Quantity
-		})
+	visible := make([]models.Product, 0, len(products))
+	for _, product := range products {
+		if scope.CanSeeProduct(product) {
+			visible = append(visible, product)
+		}
 	}
+	return visible, nil
+}
 
-	if order == models.Desc {
-		slices.Reverse(products)
+// Search returns a page of the products userId can see that match the request,
+// and where it sits among every match. Name ordering comes from the
+// repository's name index, other orderings need a sort.
+func (p productUseCase) Search(userId string, request models.SearchRequest) ([]models.Product, models.PageInfo, error) {
+	filter, err := newProductFilter(request)
+	if err != nil {
+		return nil, models.PageInfo{}, err
 	}
 
-	start := (pageNumber - 1) * pageSize
-	end := pageNumber * pageSize
+	var products []models.Product
+	if filter.sortBy == models.Name {
+		products, err = p.ProductRepository.GetAllByName()
+	} else {
+		products, err = p.ProductRepository.GetAll()
+	}
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
 
-	if start > len(products) {
-		return nil, nil
+	products, err = p.visibleTo(userId, products)
+	if err != nil {
+		return nil, models.PageInfo{}, err
 	}
 
-	if end > len(products) {
-		end = len(products)
+	scores := make(map[string]int)
+	matching := products[:0]
+	for _, product := range products {
+		ok, score := filter.matches(product)
+		if !ok {
+			continue
+		}
+		matching = append(matching, product)
+		if score > 0 {
+			scores[product.Id] = score
+		}
+	}
+	products = matching
+
+	keyOf := productKeyer(filter.sortBy, scores)
+	direction := 1
+	if request.Order == models.Desc && filter.sortBy != models.Relevance {
+		direction = -1
+	}
+
+	if filter.sortBy != models.Name {
+		slices.SortFunc(products, func(a, b models.Product) int {
+			return direction * keyOf(a).compare(keyOf(b))
+		})
+	} else if direction < 0 {
+		slices.Reverse(products)
 	}
 
-	return products[start:end], nil
+	if !pagination.UsesCursor(request.PageRequest) {
+		page, info := pagination.Offset(products, request.PageNumber, request.PageSize)
+		return page, info, nil
+	}
+
+	return pagination.Paginate(products, request.PageRequest, filter.list, keyOf, func(product models.Product, key productKey) bool {
+		return direction*keyOf(product).compare(key) > 0
+	})
 }
 
-func (p productUseCase) Create(product models.Product) error {
-	p.Logger.Info("creating product", "product", product)
+// Create adds the product, recording its initial quantity as received stock.
+// The product is updated with its ID, version and the other fields set on creation.
+func (p productUseCase) Create(product *models.Product) error {
+	p.Logger.Info("creating product", "product", *product)
+	err := normaliseProduct(product)
+	if err != nil {
+		return err
+	}
+
 	product.Id = uuid.New().String()
-	err := p.ProductRepository.Create(product)
+	product.LowStock = false
+	product.Reserved = 0
+	product.CreatedAt = time.Now().UTC()
+	product.UpdatedAt = product.CreatedAt
+	product.Version = 1
+
+	supplierScope, err := p.Organisations.GetScope(product.SupplierId)
+	if err != nil {
+		return errors.Wrap(err, "failed to get supplier organisation")
+	}
+	product.OrganisationId = supplierScope.OrganisationId
+
+	var notices []notice
+	err = p.Store.Transaction(func(tx domain.Tx) error {
+		err := checkSkuAvailable(tx, *product)
+		if err != nil {
+			return err
+		}
+
+		quantity := product.Quantity
+		product.Quantity = 0
+		if quantity == 0 {
+			notices, err = p.evaluateThresholds(tx, product)
+		} else {
+			_, notices, err = p.recordMovement(tx, product, models.StockMovement{
+				Type:   models.Receive,
+				Delta:  quantity,
+				UserId: product.SupplierId,
+				Reason: "Product created",
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Products().Create(*product)
+	})
 	if err != nil {
 		return err
 	}
+
+	p.publish(notices)
 	return nil
 }
 
-func (p productUseCase) Update(product *models.Product) error {
-	existingProduct, err := p.ProductRepository.Get(product.Id)
+// Update replaces the product on behalf of userId, provided it is still at the
+// version the update was based on. A change in quantity is recorded in the
+// ledger as an adjustment.
+func (p productUseCase) Update(userId string, product *models.Product) error {
+	err := normaliseProduct(product)
 	if err != nil {
 		return err
 	}
 
-	if existingProduct == nil {
-		return errors.New("product not found")
-	}
+	var notices []notice
+	err = p.Store.Transaction(func(tx domain.Tx) error {
+		products := tx.Products()
+		existingProduct, err := products.Get(product.Id)
+		if err != nil {
+			return err
+		}
+
+		if existingProduct == nil {
+			return ErrProductNotFound
+		}
+
+		if product.Version != existingProduct.Version {
+			return errors.Wrapf(domain.ErrVersionConflict, "product is at version %d", existingProduct.Version)
+		}
+
+		// Ownership and reservations can't be changed through an update.
+		product.SupplierId = existingProduct.SupplierId
+		product.OrganisationId = existingProduct.OrganisationId
+		product.LowStock = existingProduct.LowStock
+		product.Reserved = existingProduct.Reserved
+		product.CreatedAt = existingProduct.CreatedAt
+		product.UpdatedAt = time.Now().UTC()
 
-	err = p.ProductRepository.Delete(product.Id)
+		err = checkSkuAvailable(tx, *product)
+		if err != nil {
+			return err
+		}
+
+		quantity := product.Quantity
+		product.Quantity = existingProduct.Quantity
+		if quantity == existingProduct.Quantity {
+			// The reorder threshold may have changed.
+			notices, err = p.evaluateThresholds(tx, product)
+		} else {
+			_, notices, err = p.recordMovement(tx, product, models.StockMovement{
+				Type:   models.Adjust,
+				Delta:  quantity - existingProduct.Quantity,
+				UserId: userId,
+				Reason: "Product updated",
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		return saveProduct(tx, product)
+	})
 	if err != nil {
 		return err
 	}
 
-	err = p.ProductRepository.Create(*product)
+	p.publish(notices)
+	return nil
+}
+
+// saveProduct writes back a product read earlier in the transaction as its next
+// version.
+func saveProduct(tx domain.Tx, product *models.Product) error {
+	version := product.Version
+	product.Version++
+	err := tx.Products().Update(*product, version)
 	if err != nil {
+		product.Version = version
 		return err
 	}
-
 	return nil
 }
 
+// Delete removes the product, releasing any reservations still held on it and
+// telling their holders.
 func (p productUseCase) Delete(id string) error {
 	p.Logger.Info("deleting product", "id", id)
-	err := p.ProductRepository.Delete(id)
+	now := time.Now().UTC()
+	var notices []notice
+	err := p.Store.Transaction(func(tx domain.Tx) error {
+		product, err := tx.Products().Get(id)
+		if err != nil {
+			return err
+		}
+
+		reservations, err := tx.Reservations().GetByProduct(id)
+		if err != nil {
+			return err
+		}
+
+		for i := range reservations {
+			reservation := &reservations[i]
+			if reservation.Status != models.ReservationHeld {
+				continue
+			}
+
+			// The product is going, so there is no stock left to release.
+			err = p.releaseStock(tx, reservation, nil, models.ReservationReleased, now)
+			if err != nil {
+				return err
+			}
+
+			name := reservation.ProductName
+			if product != nil {
+				name = product.Name
+			}
+			message := fmt.Sprintf("Your reservation of %d %s has been released because the product was removed", reservation.Quantity, name)
+			users := []string{reservation.UserId}
+			err = p.Notification.Record(tx, message, users)
+			if err != nil {
+				return err
+			}
+			notices = append(notices, notice{message: message, users: users})
+		}
+
+		return tx.Products().Delete(id)
+	})
 	if err != nil {
 		return err
 	}
+
+	p.publish(notices)
 	return nil
 }
 
 func (p productUseCase) Subscribe(productId string, subType string, userId string) error {
-	err := p.ProductRepository.Subscribe(productId, subType, userId)
+	product, err := p.Get(userId, productId)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return ErrProductNotFound
+	}
+
+	err = p.Store.Transaction(func(tx domain.Tx) error {
+		return tx.Products().Subscribe(productId, subType, userId)
+	})
 	if err != nil {
 		return err
 	}
@@ -129,7 +355,9 @@ func (p productUseCase) Subscribe(productId string, subType string, userId strin
 }
 
 func (p productUseCase) Unsubscribe(productId string, subType string, userId string) error {
-	err := p.ProductRepository.Unsubscribe(productId, subType, userId)
+	err := p.Store.Transaction(func(tx domain.Tx) error {
+		return tx.Products().Unsubscribe(productId, subType, userId)
+	})
 	if err != nil {
 		return err
 	}
@@ -145,17 +373,40 @@ func (p productUseCase) SendProductNotifications(subType string) error {
 		return err
 	}
 
+	// Subscribers are usually subscribed to many products, so look each of their
+	// scopes up once.
+	scopes := make(map[string]*models.TenantScope)
 	for _, subscription := range subscriptions {
-		product, err := p.Get(subscription.ProductId)
-		if err != nil {
-			p.Logger.Error("failed to get product", "error", err)
+		product, err := p.ProductRepository.Get(subscription.ProductId)
+		if err != nil || product == nil {
+			p.Logger.Error("failed to get product", "productId", subscription.ProductId, "error", err)
+			continue
+		}
+
+		// Subscribers may have since lost access to the supplier's catalogue.
+		users := make([]string, 0, len(subscription.Users))
+		for _, userId := range subscription.Users {
+			scope, ok := scopes[userId]
+			if !ok {
+				scope, err = p.Organisations.GetScope(userId)
+				if err != nil {
+					scope = nil
+				}
+				scopes[userId] = scope
+			}
+			if scope != nil && scope.CanSeeProduct(*product) {
+				users = append(users, userId)
+			}
+		}
+
+		if len(users) == 0 {
 			continue
 		}
 
 		productUpdate := fmt.Sprintf("Product %s has %v quantity remaining", product.Name, product.Quantity)
 
-		p.Logger.Info("sending notification to users", "users", subscription.Users)
-		err = p.Notification.AddForUsers(productUpdate, subscription.Users)
+		p.Logger.Debug("sending notification to users", "productId", product.Id, "users", users)
+		err = p.Notification.AddForUsers(productUpdate, users)
 		if err != nil {
 			p.Logger.Error("failed to send notification", "error", err)
 			continue
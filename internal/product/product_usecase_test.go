@@ -2,6 +2,8 @@ package product
 
 import (
 	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
 	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
 	"github.com/kkcaz/shu-dades-server/pkg/models"
 	"github.com/pkg/errors"
@@ -11,6 +13,13 @@ import (
 	"testing"
 )
 
+// unscoped returns an organisation use case that lets every user see everything.
+func unscoped(t *testing.T) *mocks.OrganisationUseCase {
+	organisations := mocks.NewOrganisationUseCase(t)
+	organisations.On("GetScope", mock.AnythingOfType("string")).Return(&models.TenantScope{All: true}, nil).Maybe()
+	return organisations
+}
+
 func TestProductUseCase_Get(t *testing.T) {
 	testCases := []struct {
 		name      string
@@ -38,11 +47,11 @@ func TestProductUseCase_Get(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			logger := slog.Default()
 			repo := mocks.NewProductRepository(t)
-			testUc := NewProductUseCase(repo, nil, *logger)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, nil, unscoped(t), *logger)
 
 			repo.On("Get", testCase.productId).Return(testCase.product, testCase.err)
 
-			product, err := testUc.Get(testCase.productId)
+			product, err := testUc.Get("1", testCase.productId)
 			assert.Equal(t, testCase.product, product)
 			if testCase.err != nil {
 				assert.Error(t, err)
@@ -80,11 +89,11 @@ func TestProductHandler_GetAll(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			logger := slog.Default()
 			repo := mocks.NewProductRepository(t)
-			testUc := NewProductUseCase(repo, nil, *logger)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, nil, unscoped(t), *logger)
 
 			repo.On("GetAll").Return(testCase.products, testCase.err)
 
-			products, err := testUc.GetAll()
+			products, err := testUc.GetAll("1")
 			assert.Equal(t, testCase.products, products)
 			if testCase.err != nil {
 				assert.Error(t, err)
@@ -93,6 +102,61 @@ func TestProductHandler_GetAll(t *testing.T) {
 	}
 }
 
+func TestProductUseCase_GetAll_TenantScope(t *testing.T) {
+	products := []models.Product{
+		{Id: "1", SupplierId: "supplier", OrganisationId: "techuk"},
+		{Id: "2", SupplierId: "other-supplier", OrganisationId: "other"},
+		{Id: "3", SupplierId: "legacy"},
+	}
+
+	testCases := []struct {
+		name     string
+		scope    models.TenantScope
+		expected []string
+	}{
+		{
+			name:     "Customer linked to supplier",
+			scope:    models.TenantScope{UserId: "customer", OrganisationId: "leeds", Catalogues: []string{"leeds", "techuk"}},
+			expected: []string{"1"},
+		},
+		{
+			name:     "Supplier sees own organisation",
+			scope:    models.TenantScope{UserId: "supplier", OrganisationId: "techuk", Catalogues: []string{"techuk"}},
+			expected: []string{"1"},
+		},
+		{
+			name:     "Supplier without organisation sees own products",
+			scope:    models.TenantScope{UserId: "legacy"},
+			expected: []string{"3"},
+		},
+		{
+			name:     "Admin sees everything",
+			scope:    models.TenantScope{UserId: "admin", All: true},
+			expected: []string{"1", "2", "3"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := mocks.NewProductRepository(t)
+			organisations := mocks.NewOrganisationUseCase(t)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, nil, organisations, *slog.Default())
+
+			repo.On("GetAll").Return(products, nil)
+			organisations.On("GetScope", testCase.scope.UserId).Return(&testCase.scope, nil)
+
+			visible, err := testUc.GetAll(testCase.scope.UserId)
+			assert.NoError(t, err)
+
+			ids := make([]string, 0)
+			for _, product := range visible {
+				ids = append(ids, product.Id)
+			}
+			assert.Equal(t, testCase.expected, ids)
+		})
+	}
+}
+
 func TestProductUseCase_Search(t *testing.T) {
 	allProducts := []models.Product{
 		{
@@ -204,13 +268,23 @@ func TestProductUseCase_Search(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			logger := slog.Default()
 			repo := mocks.NewProductRepository(t)
-			testUc := NewProductUseCase(repo, nil, *logger)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, nil, unscoped(t), *logger)
 
-			repo.On("GetAll").Return(testCase.products, testCase.err)
+			if testCase.sortBy == models.Name {
+				repo.On("GetAllByName").Return(testCase.products, testCase.err)
+			} else {
+				repo.On("GetAll").Return(testCase.products, testCase.err)
+			}
 
-			products, err := testUc.Search(testCase.pageNumber, testCase.pageSize, testCase.sortBy, testCase.order)
+			products, pageInfo, err := testUc.Search("1", models.SearchRequest{
+				PageNumber: testCase.pageNumber,
+				PageSize:   testCase.pageSize,
+				SortBy:     testCase.sortBy,
+				Order:      testCase.order,
+			})
 			assert.Equal(t, testCase.expectedProductCount, len(products))
-			assert.Equal(t, testCase.products, products)
+			assert.Equal(t, len(testCase.products), pageInfo.Total)
+			assert.ElementsMatch(t, testCase.products, products)
 			if testCase.expectedErr != nil {
 				assert.Error(t, err)
 				return
@@ -238,14 +312,16 @@ func TestProductUseCase_Create(t *testing.T) {
 		{
 			name: "Happy path",
 			product: models.Product{
-				Id: "1",
+				Id:   "1",
+				Name: "iPhone 15",
 			},
 			err: nil,
 		},
 		{
 			name: "Sad path",
 			product: models.Product{
-				Id: "1",
+				Id:   "1",
+				Name: "iPhone 15",
 			},
 			err: errors.New("not found"),
 		},
@@ -255,11 +331,11 @@ func TestProductUseCase_Create(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			logger := slog.Default()
 			repo := mocks.NewProductRepository(t)
-			testUc := NewProductUseCase(repo, nil, *logger)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, nil, unscoped(t), *logger)
 
 			repo.On("Create", mock.AnythingOfType("models.Product")).Return(testCase.err)
 
-			err := testUc.Create(testCase.product)
+			err := testUc.Create(&testCase.product)
 			if testCase.err != nil {
 				assert.Error(t, err)
 			}
@@ -274,22 +350,23 @@ func TestProductUseCase_Update(t *testing.T) {
 		existingProduct *models.Product
 		getErr          error
 		product         *models.Product
-		deleteErr       error
-		createErr       error
+		updateErr       error
+		expectedErr     error
 	}{
 		{
 			name:      "Happy path",
 			productId: "1",
 			existingProduct: &models.Product{
-				Id: "1",
+				Id:      "1",
+				Version: 3,
 			},
 			getErr: nil,
 			product: &models.Product{
-				Id:   "1",
-				Name: "A",
+				Id:      "1",
+				Name:    "A",
+				Version: 3,
 			},
-			deleteErr: nil,
-			createErr: nil,
+			updateErr: nil,
 		},
 		{
 			name:            "Sad path - get returns error",
@@ -310,33 +387,38 @@ func TestProductUseCase_Update(t *testing.T) {
 				Id:   "1",
 				Name: "A",
 			},
+			expectedErr: ErrProductNotFound,
 		},
 		{
-			name:      "Sad path - delete returns error",
+			name:      "Sad path - stale version",
 			productId: "1",
 			existingProduct: &models.Product{
-				Id: "1",
+				Id:      "1",
+				Version: 4,
 			},
 			getErr: nil,
 			product: &models.Product{
-				Id:   "1",
-				Name: "A",
+				Id:      "1",
+				Name:    "A",
+				Version: 3,
 			},
-			deleteErr: errors.New("something went wrong"),
+			expectedErr: domain.ErrVersionConflict,
 		},
 		{
-			name:      "Sad path - create returns error",
+			name:      "Sad path - update returns error",
 			productId: "1",
 			existingProduct: &models.Product{
-				Id: "1",
+				Id:      "1",
+				Version: 3,
 			},
 			getErr: nil,
 			product: &models.Product{
-				Id:   "1",
-				Name: "A",
+				Id:      "1",
+				Name:    "A",
+				Version: 3,
 			},
-			deleteErr: nil,
-			createErr: errors.New("something went wrong"),
+			updateErr:   domain.ErrVersionConflict,
+			expectedErr: domain.ErrVersionConflict,
 		},
 	}
 
@@ -344,26 +426,28 @@ func TestProductUseCase_Update(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			logger := slog.Default()
 			repo := mocks.NewProductRepository(t)
-			testUc := NewProductUseCase(repo, nil, *logger)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, nil, unscoped(t), *logger)
 
 			repo.On("Get", testCase.productId).Return(testCase.existingProduct, testCase.getErr)
-			if testCase.getErr == nil && testCase.existingProduct != nil {
-				repo.On("Delete", testCase.productId).Return(testCase.deleteErr)
-				if testCase.deleteErr == nil {
-					repo.On("Create", mock.AnythingOfType("models.Product")).Return(testCase.createErr)
-				}
+			if testCase.getErr == nil && testCase.existingProduct != nil && testCase.existingProduct.Version == testCase.product.Version {
+				repo.On("Update", mock.AnythingOfType("models.Product"), testCase.existingProduct.Version).Return(testCase.updateErr)
 			}
 
-			err := testUc.Update(testCase.product)
-			if testCase.getErr != nil ||
-				testCase.deleteErr != nil ||
-				testCase.createErr != nil {
+			version := testCase.product.Version
+			err := testUc.Update("1", testCase.product)
+			if testCase.getErr != nil {
 				assert.Error(t, err)
+				return
 			}
 
-			if testCase.getErr == nil && testCase.existingProduct == nil {
-				assert.ErrorContains(t, err, "product not found")
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+				assert.Equal(t, version, testCase.product.Version)
+				return
 			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, version+1, testCase.product.Version)
 		})
 	}
 }
@@ -390,8 +474,9 @@ func TestProductUseCase_Delete(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			logger := slog.Default()
 			repo := mocks.NewProductRepository(t)
-			testUc := NewProductUseCase(repo, nil, *logger)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, nil, unscoped(t), *logger)
 
+			repo.On("Get", testCase.productId).Return(&models.Product{Id: testCase.productId}, nil)
 			repo.On("Delete", testCase.productId).Return(testCase.err)
 
 			err := testUc.Delete(testCase.productId)
@@ -430,10 +515,12 @@ func TestProductUseCase_Subscribe(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			logger := slog.Default()
 			repo := mocks.NewProductRepository(t)
-			testUc := NewProductUseCase(repo, nil, *logger)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, nil, unscoped(t), *logger)
 
 			repo.On("Subscribe", testCase.productId, testCase.subType, testCase.userId).Return(testCase.err)
 
+			repo.On("Get", testCase.productId).Return(&models.Product{Id: testCase.productId}, nil)
+
 			err := testUc.Subscribe(testCase.productId, testCase.subType, testCase.userId)
 			if testCase.err != nil {
 				assert.Error(t, err)
@@ -470,7 +557,7 @@ func TestProductUseCase_Unsubscribe(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			logger := slog.Default()
 			repo := mocks.NewProductRepository(t)
-			testUc := NewProductUseCase(repo, nil, *logger)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, nil, unscoped(t), *logger)
 
 			repo.On("Unsubscribe", testCase.productId, testCase.subType, testCase.userId).Return(testCase.err)
 
@@ -566,7 +653,7 @@ func TestProductUseCase_SendProductNotifications(t *testing.T) {
 			logger := slog.Default()
 			repo := mocks.NewProductRepository(t)
 			notification := mocks.NewNotificationUseCase(t)
-			testUc := NewProductUseCase(repo, notification, *logger)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, notification, unscoped(t), *logger)
 
 			repo.On("GetSubscriptions", testCase.subType).Return(testCase.subscriptions, testCase.getSubscriptionsErr)
 			if testCase.getSubscriptionsErr == nil {
@@ -584,3 +671,58 @@ func TestProductUseCase_SendProductNotifications(t *testing.T) {
 		})
 	}
 }
+
+// testStore runs transactions directly against the product repository.
+type testStore struct {
+	domain.ProductRepository
+}
+
+func (s testStore) Products() domain.ProductRepository {
+	return s.ProductRepository
+}
+
+func (s testStore) Stock() domain.StockRepository {
+	return nil
+}
+
+func (s testStore) Thresholds() domain.ThresholdRepository {
+	return noThresholds{}
+}
+
+func (s testStore) Reservations() domain.ReservationRepository {
+	return noReservations{}
+}
+
+func (s testStore) Chats() domain.ChatRepository {
+	return nil
+}
+
+func (s testStore) Notifications() domain.NotificationRepository {
+	return nil
+}
+
+func (s testStore) Transaction(fn func(tx domain.Tx) error) error {
+	return fn(s)
+}
+
+func (s testStore) Close() error {
+	return nil
+}
+
+// noThresholds is a threshold repository without any thresholds.
+type noThresholds struct {
+	domain.ThresholdRepository
+}
+
+func (noThresholds) GetByProduct(productId string) ([]models.UserThreshold, error) {
+	return nil, nil
+}
+
+// noReservations is a reservation repository without any reservations.
+type noReservations struct {
+	domain.ReservationRepository
+}
+
+func (noReservations) GetByProduct(productId string) ([]models.Reservation, error) {
+	return nil, nil
+}
@@ -0,0 +1,127 @@
+package product
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type reservationData struct {
+	Reservations []models.Reservation `json:"reservations"`
+}
+
+// reservationRepository keeps reservations in the order they were made, indexed
+// by ID, and writes them through to a JSON file.
+type reservationRepository struct {
+	Logger       slog.Logger
+	path         string
+	mu           sync.RWMutex
+	reservations []models.Reservation
+	// Positions in reservations by ID.
+	byId map[string]int
+}
+
+func newReservationRepository(path string, logger slog.Logger) (*reservationRepository, error) {
+	var data reservationData
+	err := storage.ReadJSON(path, &data)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	repo := &reservationRepository{
+		Logger:       logger,
+		path:         path,
+		reservations: data.Reservations,
+		byId:         make(map[string]int),
+	}
+	for i, reservation := range repo.reservations {
+		repo.byId[reservation.Id] = i
+	}
+	return repo, nil
+}
+
+func (r *reservationRepository) Get(id string) (*models.Reservation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i, ok := r.byId[id]
+	if !ok {
+		return nil, nil
+	}
+
+	reservation := r.reservations[i]
+	return &reservation, nil
+}
+
+func (r *reservationRepository) Save(reservation models.Reservation) error {
+	r.Logger.Info("saving reservation", "id", reservation.Id, "productId", reservation.ProductId, "status", reservation.Status)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, existed := r.byId[reservation.Id]
+	if existed {
+		previous := r.reservations[i]
+		r.reservations[i] = reservation
+		err := r.save()
+		if err != nil {
+			r.reservations[i] = previous
+			return err
+		}
+		return nil
+	}
+
+	r.reservations = append(r.reservations, reservation)
+	err := r.save()
+	if err != nil {
+		r.reservations = r.reservations[:len(r.reservations)-1]
+		return err
+	}
+
+	r.byId[reservation.Id] = len(r.reservations) - 1
+	return nil
+}
+
+func (r *reservationRepository) GetByProduct(productId string) ([]models.Reservation, error) {
+	return r.filter(func(reservation models.Reservation) bool {
+		return reservation.ProductId == productId
+	}), nil
+}
+
+func (r *reservationRepository) GetByUser(userId string) ([]models.Reservation, error) {
+	return r.filter(func(reservation models.Reservation) bool {
+		return reservation.UserId == userId
+	}), nil
+}
+
+func (r *reservationRepository) GetExpired(t time.Time) ([]models.Reservation, error) {
+	return r.filter(func(reservation models.Reservation) bool {
+		return reservation.Status == models.ReservationHeld && !reservation.ExpiresAt.After(t)
+	}), nil
+}
+
+func (r *reservationRepository) filter(keep func(reservation models.Reservation) bool) []models.Reservation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reservations := make([]models.Reservation, 0)
+	for _, reservation := range r.reservations {
+		if keep(reservation) {
+			reservations = append(reservations, reservation)
+		}
+	}
+	return reservations
+}
+
+// save persists the current reservations. The caller must hold the write lock
+// and undo its change if this fails.
+func (r *reservationRepository) save() error {
+	err := storage.WriteJSON(r.path, reservationData{Reservations: r.reservations})
+	if err != nil {
+		return errors.Wrap(err, "failed to persist reservations")
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package product
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrInvalidReservation  = errors.New("invalid reservation")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationClosed   = errors.New("reservation is no longer held")
+)
+
+// Recorded against the sale when a reservation is converted.
+const convertedReason = "Reservation converted"
+
+// GetStockLevel returns how much of a product userId can see is on hand,
+// reserved and available.
+func (p productUseCase) GetStockLevel(userId string, productId string) (*models.StockLevel, error) {
+	product, err := p.Get(userId, productId)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+
+	return &models.StockLevel{
+		ProductId: product.Id,
+		OnHand:    product.Quantity,
+		Reserved:  product.Reserved,
+		Available: product.Available(),
+	}, nil
+}
+
+// Reserve holds stock of a product userId can see for them until the
+// reservation expires, provided that much is available.
+func (p productUseCase) Reserve(userId string, request models.ReservationRequest) (*models.Reservation, error) {
+	if request.Quantity <= 0 {
+		return nil, errors.Wrap(ErrInvalidReservation, "quantity must be positive")
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(p.ReservationTTL)
+	if request.ExpiresAt != nil {
+		expiresAt = request.ExpiresAt.UTC()
+	}
+	err := p.checkExpiry(now, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	visible, err := p.Get(userId, request.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	if visible == nil {
+		return nil, ErrProductNotFound
+	}
+
+	reservation := models.Reservation{
+		Id:        uuid.New().String(),
+		ProductId: request.ProductId,
+		UserId:    userId,
+		Quantity:  request.Quantity,
+		Status:    models.ReservationHeld,
+		Reference: request.Reference,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	err = p.Store.Transaction(func(tx domain.Tx) error {
+		product, err := tx.Products().Get(request.ProductId)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			return ErrProductNotFound
+		}
+
+		if product.Available() < reservation.Quantity {
+			return errors.Wrapf(ErrInsufficientStock, "%d available", product.Available())
+		}
+		reservation.ProductName = product.Name
+
+		product.Reserved += reservation.Quantity
+		err = saveProduct(tx, product)
+		if err != nil {
+			return err
+		}
+		return tx.Reservations().Save(reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.Logger.Info("reserved stock", "id", reservation.Id, "productId", reservation.ProductId, "quantity", reservation.Quantity, "userId", userId)
+	return &reservation, nil
+}
+
+// checkExpiry fails unless expiresAt is after now and within the longest a
+// reservation can be held for.
+func (p productUseCase) checkExpiry(now time.Time, expiresAt time.Time) error {
+	if !expiresAt.After(now) {
+		return errors.Wrap(ErrInvalidReservation, "expiresAt must be in the future")
+	}
+	if expiresAt.After(now.Add(p.MaxReservationTTL)) {
+		return errors.Wrapf(ErrInvalidReservation, "reservations can be held for at most %s", p.MaxReservationTTL)
+	}
+	return nil
+}
+
+// ExtendReservation moves the expiry of a held reservation. The holder and the
+// product's supplier can extend it.
+func (p productUseCase) ExtendReservation(userId string, request models.ExtendReservationRequest) (*models.Reservation, error) {
+	now := time.Now().UTC()
+	err := p.checkExpiry(now, request.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var reservation *models.Reservation
+	err = p.Store.Transaction(func(tx domain.Tx) error {
+		var err error
+		reservation, _, err = heldReservation(tx, userId, request.Id, now)
+		if err != nil {
+			return err
+		}
+
+		reservation.ExpiresAt = request.ExpiresAt.UTC()
+		return tx.Reservations().Save(*reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// ConvertReservation sells the held stock to the reservation's holder,
+// recording the sale in the product's ledger on behalf of userId. The holder
+// and the product's supplier can convert it.
+func (p productUseCase) ConvertReservation(userId string, id string) (*models.Reservation, error) {
+	now := time.Now().UTC()
+	var reservation *models.Reservation
+	var notices []notice
+	err := p.Store.Transaction(func(tx domain.Tx) error {
+		var product *models.Product
+		var err error
+		reservation, product, err = heldReservation(tx, userId, id, now)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			return ErrProductNotFound
+		}
+
+		product.Reserved -= reservation.Quantity
+		_, notices, err = p.recordMovement(tx, product, models.StockMovement{
+			Type:      models.Sell,
+			Delta:     -reservation.Quantity,
+			UserId:    userId,
+			Reason:    convertedReason,
+			Reference: reservation.Id,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = saveProduct(tx, product)
+		if err != nil {
+			return err
+		}
+		return closeReservation(tx, reservation, models.ReservationConverted, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(notices)
+	p.Logger.Info("converted reservation", "id", id, "userId", userId)
+	return reservation, nil
+}
+
+// ReleaseReservation gives up a held reservation, making its stock available
+// again. The holder and the product's supplier can release it.
+func (p productUseCase) ReleaseReservation(userId string, id string) (*models.Reservation, error) {
+	now := time.Now().UTC()
+	var reservation *models.Reservation
+	err := p.Store.Transaction(func(tx domain.Tx) error {
+		var product *models.Product
+		var err error
+		reservation, product, err = heldReservation(tx, userId, id, now)
+		if err != nil {
+			return err
+		}
+		return p.releaseStock(tx, reservation, product, models.ReservationReleased, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.Logger.Info("released reservation", "id", id, "userId", userId)
+	return reservation, nil
+}
+
+// GetReservations returns the reservations userId holds or, when the request
+// gives a product they supply, every reservation on it.
+func (p productUseCase) GetReservations(userId string, request models.ReservationListRequest) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	var err error
+	if request.ProductId == "" {
+		reservations, err = p.Store.Reservations().GetByUser(userId)
+	} else {
+		var product *models.Product
+		product, err = p.Get(userId, request.ProductId)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			return nil, ErrProductNotFound
+		}
+
+		reservations, err = p.Store.Reservations().GetByProduct(request.ProductId)
+		if err == nil && product.SupplierId != userId {
+			reservations = filterReservations(reservations, func(reservation models.Reservation) bool {
+				return reservation.UserId == userId
+			})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Status == "" {
+		return reservations, nil
+	}
+	return filterReservations(reservations, func(reservation models.Reservation) bool {
+		return reservation.Status == request.Status
+	}), nil
+}
+
+func filterReservations(reservations []models.Reservation, keep func(reservation models.Reservation) bool) []models.Reservation {
+	kept := make([]models.Reservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		if keep(reservation) {
+			kept = append(kept, reservation)
+		}
+	}
+	return kept
+}
+
+// ExpireReservations releases the stock of every held reservation that has
+// expired and notifies their holders. Each reservation is expired on its own,
+// so one that fails is logged and left for the next run without holding up the
+// rest.
+func (p productUseCase) ExpireReservations() error {
+	now := time.Now().UTC()
+	expired, err := p.Store.Reservations().GetExpired(now)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, reservation := range expired {
+		err = p.expireReservation(reservation.Id, now)
+		if err != nil {
+			p.Logger.Error("failed to expire reservation", "id", reservation.Id, "error", err)
+			failed++
+		}
+	}
+
+	if len(expired) > 0 {
+		p.Logger.Info("expired reservations", "count", len(expired)-failed)
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to expire %d of %d reservations", failed, len(expired))
+	}
+	return nil
+}
+
+// expireReservation releases the reservation's stock and notifies its holder,
+// unless it has stopped being held since it was found to have expired.
+func (p productUseCase) expireReservation(id string, now time.Time) error {
+	var notices []notice
+	err := p.Store.Transaction(func(tx domain.Tx) error {
+		reservation, err := tx.Reservations().Get(id)
+		if err != nil {
+			return err
+		}
+		if reservation == nil || reservation.Status != models.ReservationHeld {
+			return nil
+		}
+
+		product, err := tx.Products().Get(reservation.ProductId)
+		if err != nil {
+			return err
+		}
+
+		err = p.releaseStock(tx, reservation, product, models.ReservationExpired, now)
+		if err != nil {
+			return err
+		}
+
+		name := reservation.ProductName
+		if product != nil {
+			name = product.Name
+		}
+		message := fmt.Sprintf("Your reservation of %d %s has expired", reservation.Quantity, name)
+		if name == "" {
+			message = fmt.Sprintf("Your reservation of %d of a product that has since been removed has expired", reservation.Quantity)
+		}
+
+		users := []string{reservation.UserId}
+		err = p.Notification.Record(tx, message, users)
+		if err != nil {
+			return err
+		}
+		notices = append(notices, notice{message: message, users: users})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.publish(notices)
+	return nil
+}
+
+// heldReservation returns the reservation and its product, which is nil if it
+// has been deleted, provided the reservation is still held and userId is its
+// holder or the product's supplier. Reservations past their expiry count as
+// expired even before they are expired.
+func heldReservation(tx domain.Tx, userId string, id string, now time.Time) (*models.Reservation, *models.Product, error) {
+	reservation, err := tx.Reservations().Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reservation == nil {
+		return nil, nil, ErrReservationNotFound
+	}
+
+	product, err := tx.Products().Get(reservation.ProductId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if reservation.UserId != userId && (product == nil || product.SupplierId != userId) {
+		return nil, nil, ErrReservationNotFound
+	}
+
+	if reservation.Status != models.ReservationHeld {
+		return nil, nil, errors.Wrapf(ErrReservationClosed, "reservation is %s", reservation.Status)
+	}
+	if !reservation.ExpiresAt.After(now) {
+		return nil, nil, errors.Wrap(ErrReservationClosed, "reservation has expired")
+	}
+	return reservation, product, nil
+}
+
+// releaseStock makes a held reservation's stock available again and closes it
+// with status. The product is nil if it has been deleted.
+func (p productUseCase) releaseStock(tx domain.Tx, reservation *models.Reservation, product *models.Product, status models.ReservationStatus, now time.Time) error {
+	if product != nil {
+		product.Reserved = max(product.Reserved-reservation.Quantity, 0)
+		err := saveProduct(tx, product)
+		if err != nil {
+			return err
+		}
+	}
+	return closeReservation(tx, reservation, status, now)
+}
+
+func closeReservation(tx domain.Tx, reservation *models.Reservation, status models.ReservationStatus, now time.Time) error {
+	reservation.Status = status
+	reservation.ClosedAt = &now
+	return tx.Reservations().Save(*reservation)
+}
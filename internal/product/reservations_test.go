@@ -0,0 +1,266 @@
+package product
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+var reservationConfig = config.Product{ReservationTTL: time.Hour, MaxReservationTTL: 24 * time.Hour}
+
+func newReservationUseCase(t *testing.T, notification domain.NotificationUseCase) (domain.ProductUseCase, ledgerStore) {
+	store := newLedgerStore(t, models.Product{Id: "1", Name: "iPhone 15", Quantity: 10, SupplierId: "supplier", Version: 1})
+	return NewProductUseCase(reservationConfig, store, notification, unscoped(t), *slog.Default()), store
+}
+
+func assertStockLevel(t *testing.T, uc domain.ProductUseCase, onHand int, reserved int) {
+	level, err := uc.GetStockLevel("john", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.StockLevel{ProductId: "1", OnHand: onHand, Reserved: reserved, Available: onHand - reserved}, level)
+}
+
+func TestProductUseCase_Reserve(t *testing.T) {
+	later := time.Now().Add(48 * time.Hour)
+	testCases := []struct {
+		name        string
+		request     models.ReservationRequest
+		expectedErr error
+	}{
+		{
+			name:    "Happy path",
+			request: models.ReservationRequest{ProductId: "1", Quantity: 10, Reference: "chat"},
+		},
+		{
+			name:        "Sad path - more than is available",
+			request:     models.ReservationRequest{ProductId: "1", Quantity: 11},
+			expectedErr: ErrInsufficientStock,
+		},
+		{
+			name:        "Sad path - no quantity",
+			request:     models.ReservationRequest{ProductId: "1"},
+			expectedErr: ErrInvalidReservation,
+		},
+		{
+			name:        "Sad path - held too long",
+			request:     models.ReservationRequest{ProductId: "1", Quantity: 1, ExpiresAt: &later},
+			expectedErr: ErrInvalidReservation,
+		},
+		{
+			name:        "Sad path - product not found",
+			request:     models.ReservationRequest{ProductId: "2", Quantity: 1},
+			expectedErr: ErrProductNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testUc, _ := newReservationUseCase(t, nil)
+
+			reservation, err := testUc.Reserve("john", tc.request)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr != nil {
+				assert.Nil(t, reservation)
+				assertStockLevel(t, testUc, 10, 0)
+				return
+			}
+
+			assert.Equal(t, "john", reservation.UserId)
+			assert.Equal(t, models.ReservationHeld, reservation.Status)
+			assert.Equal(t, "chat", reservation.Reference)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), reservation.ExpiresAt, time.Minute)
+			assertStockLevel(t, testUc, 10, 10)
+		})
+	}
+}
+
+func TestProductUseCase_Reservations(t *testing.T) {
+	testUc, store := newReservationUseCase(t, nil)
+
+	held, err := testUc.Reserve("john", models.ReservationRequest{ProductId: "1", Quantity: 4})
+	assert.NoError(t, err)
+	assertStockLevel(t, testUc, 10, 4)
+
+	// Reserved stock can't be sold to anyone else or adjusted away.
+	_, err = testUc.MoveStock("supplier", models.StockMovementRequest{Id: "1", Type: models.Sell, Quantity: 7})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	_, err = testUc.MoveStock("supplier", models.StockMovementRequest{Id: "1", Type: models.Sell, Quantity: 6})
+	assert.NoError(t, err)
+	_, err = testUc.Reserve("jane", models.ReservationRequest{ProductId: "1", Quantity: 1})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+
+	product, err := testUc.Get("supplier", "1")
+	assert.NoError(t, err)
+	product.Quantity = 3
+	product.Reserved = 0
+	assert.ErrorIs(t, testUc.Update("supplier", product), ErrInsufficientStock)
+
+	// Only the holder and the supplier can see to a reservation.
+	expiresAt := time.Now().Add(2 * time.Hour)
+	_, err = testUc.ExtendReservation("jane", models.ExtendReservationRequest{Id: held.Id, ExpiresAt: expiresAt})
+	assert.ErrorIs(t, err, ErrReservationNotFound)
+	extended, err := testUc.ExtendReservation("supplier", models.ExtendReservationRequest{Id: held.Id, ExpiresAt: expiresAt})
+	assert.NoError(t, err)
+	assert.Equal(t, expiresAt.UTC(), extended.ExpiresAt)
+	_, err = testUc.ExtendReservation("john", models.ExtendReservationRequest{Id: held.Id, ExpiresAt: time.Now().Add(-time.Hour)})
+	assert.ErrorIs(t, err, ErrInvalidReservation)
+
+	converted, err := testUc.ConvertReservation("john", held.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReservationConverted, converted.Status)
+	assert.NotNil(t, converted.ClosedAt)
+	assertStockLevel(t, testUc, 0, 0)
+
+	latest, err := store.stock.Latest("1")
+	assert.NoError(t, err)
+	assert.Equal(t, models.Sell, latest.Type)
+	assert.Equal(t, -4, latest.Delta)
+	assert.Equal(t, held.Id, latest.Reference)
+	assert.Equal(t, convertedReason, latest.Reason)
+
+	_, err = testUc.ConvertReservation("john", held.Id)
+	assert.ErrorIs(t, err, ErrReservationClosed)
+	_, err = testUc.ReleaseReservation("john", "missing")
+	assert.ErrorIs(t, err, ErrReservationNotFound)
+
+	_, err = testUc.MoveStock("supplier", models.StockMovementRequest{Id: "1", Type: models.Receive, Quantity: 5})
+	assert.NoError(t, err)
+	released, err := testUc.Reserve("jane", models.ReservationRequest{ProductId: "1", Quantity: 2})
+	assert.NoError(t, err)
+	assertStockLevel(t, testUc, 5, 2)
+	released, err = testUc.ReleaseReservation("jane", released.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReservationReleased, released.Status)
+	assertStockLevel(t, testUc, 5, 0)
+
+	reservations, err := testUc.GetReservations("john", models.ReservationListRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{held.Id}, reservationIds(reservations))
+
+	reservations, err = testUc.GetReservations("supplier", models.ReservationListRequest{ProductId: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{held.Id, released.Id}, reservationIds(reservations))
+
+	reservations, err = testUc.GetReservations("jane", models.ReservationListRequest{ProductId: "1", Status: models.ReservationConverted})
+	assert.NoError(t, err)
+	assert.Empty(t, reservations)
+}
+
+func TestProductUseCase_ExpireReservations(t *testing.T) {
+	notification := mocks.NewNotificationUseCase(t)
+	testUc, store := newReservationUseCase(t, notification)
+
+	reservation, err := testUc.Reserve("john", models.ReservationRequest{ProductId: "1", Quantity: 3})
+	assert.NoError(t, err)
+	_, err = testUc.Reserve("jane", models.ReservationRequest{ProductId: "1", Quantity: 2})
+	assert.NoError(t, err)
+
+	reservation.ExpiresAt = time.Now().Add(-time.Minute)
+	assert.NoError(t, store.reservations.Save(*reservation))
+
+	// Reservations past their expiry can't be used before they are expired.
+	_, err = testUc.ConvertReservation("john", reservation.Id)
+	assert.ErrorIs(t, err, ErrReservationClosed)
+
+	expectNotice(notification, "Your reservation of 3 iPhone 15 has expired", []string{"john"})
+	assert.NoError(t, testUc.ExpireReservations())
+	assertStockLevel(t, testUc, 10, 2)
+
+	expired, err := store.reservations.Get(reservation.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReservationExpired, expired.Status)
+	assert.NotNil(t, expired.ClosedAt)
+
+	// Reservations are only expired once.
+	assert.NoError(t, testUc.ExpireReservations())
+}
+
+// failingReservations fails to save one reservation.
+type failingReservations struct {
+	domain.ReservationRepository
+	id string
+}
+
+func (r failingReservations) Save(reservation models.Reservation) error {
+	if reservation.Id == r.id {
+		return assert.AnError
+	}
+	return r.ReservationRepository.Save(reservation)
+}
+
+func TestProductUseCase_ExpireReservationsSeparately(t *testing.T) {
+	notification := mocks.NewNotificationUseCase(t)
+	testUc, store := newReservationUseCase(t, notification)
+
+	john, err := testUc.Reserve("john", models.ReservationRequest{ProductId: "1", Quantity: 3})
+	assert.NoError(t, err)
+	jane, err := testUc.Reserve("jane", models.ReservationRequest{ProductId: "1", Quantity: 2})
+	assert.NoError(t, err)
+	legacy := models.Reservation{Id: "legacy", ProductId: "1", UserId: "bob", Quantity: 1, Status: models.ReservationHeld}
+	for _, reservation := range []models.Reservation{*john, *jane, legacy} {
+		reservation.ExpiresAt = time.Now().Add(-time.Minute)
+		assert.NoError(t, store.reservations.Save(reservation))
+	}
+	assert.NoError(t, store.Products().Delete("1"))
+
+	// Holders are still told when the product has been deleted, and a
+	// reservation that can't be expired doesn't stop the others.
+	expectNotice(notification, "Your reservation of 2 iPhone 15 has expired", []string{"jane"})
+	expectNotice(notification, "Your reservation of 1 of a product that has since been removed has expired", []string{"bob"})
+	failing := store
+	failing.reservations = failingReservations{store.reservations, john.Id}
+	testUc = NewProductUseCase(reservationConfig, failing, notification, unscoped(t), *slog.Default())
+	assert.Error(t, testUc.ExpireReservations())
+
+	statuses := make(map[string]models.ReservationStatus)
+	for _, id := range []string{john.Id, jane.Id, legacy.Id} {
+		reservation, err := store.reservations.Get(id)
+		assert.NoError(t, err)
+		statuses[id] = reservation.Status
+	}
+	assert.Equal(t, map[string]models.ReservationStatus{
+		john.Id:   models.ReservationHeld,
+		jane.Id:   models.ReservationExpired,
+		legacy.Id: models.ReservationExpired,
+	}, statuses)
+}
+
+func TestProductUseCase_DeleteReleasesReservations(t *testing.T) {
+	notification := mocks.NewNotificationUseCase(t)
+	testUc, store := newReservationUseCase(t, notification)
+
+	john, err := testUc.Reserve("john", models.ReservationRequest{ProductId: "1", Quantity: 3})
+	assert.NoError(t, err)
+	jane, err := testUc.Reserve("jane", models.ReservationRequest{ProductId: "1", Quantity: 2})
+	assert.NoError(t, err)
+	_, err = testUc.ReleaseReservation("jane", jane.Id)
+	assert.NoError(t, err)
+
+	// Only reservations still held are released and their holders told.
+	expectNotice(notification, "Your reservation of 3 iPhone 15 has been released because the product was removed", []string{"john"})
+	assert.NoError(t, testUc.Delete("1"))
+
+	product, err := store.Products().Get("1")
+	assert.NoError(t, err)
+	assert.Nil(t, product)
+
+	released, err := store.reservations.Get(john.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReservationReleased, released.Status)
+	assert.NotNil(t, released.ClosedAt)
+
+	// Nothing is left for the expiry job to pick up.
+	assert.NoError(t, testUc.ExpireReservations())
+}
+
+func reservationIds(reservations []models.Reservation) []string {
+	ids := make([]string, len(reservations))
+	for i, reservation := range reservations {
+		ids[i] = reservation.Id
+	}
+	return ids
+}
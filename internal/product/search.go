@@ -0,0 +1,208 @@
+package product
+
+import (
+	"cmp"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var ErrInvalidSearch = errors.New("invalid search")
+
+// Matches in a product's name count for more than matches in its description,
+// and whole words count for more than prefixes.
+const (
+	nameWeight        = 3
+	descriptionWeight = 1
+	wholeWordWeight   = 2
+)
+
+// productFilter is a search request with its text normalised for matching.
+type productFilter struct {
+	name        string
+	category    string
+	supplierId  string
+	minQuantity *int
+	maxQuantity *int
+	tags        []string
+	terms       []string
+
+	// What the results are sorted by, relevance when there's a query and
+	// name otherwise unless the request says.
+	sortBy models.SortBy
+	// Identifies the results for cursors, see pagination.List.
+	list string
+}
+
+func newProductFilter(request models.SearchRequest) (*productFilter, error) {
+	if request.MinQuantity != nil && request.MaxQuantity != nil && *request.MinQuantity > *request.MaxQuantity {
+		return nil, errors.Wrap(ErrInvalidSearch, "minQuantity can't be more than maxQuantity")
+	}
+
+	filter := &productFilter{
+		name:        strings.ToLower(strings.TrimSpace(request.Name)),
+		category:    strings.TrimSpace(request.Category),
+		supplierId:  request.SupplierId,
+		minQuantity: request.MinQuantity,
+		maxQuantity: request.MaxQuantity,
+		terms:       tokenise(request.Query),
+	}
+	for _, tag := range request.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" {
+			filter.tags = append(filter.tags, tag)
+		}
+	}
+
+	filter.sortBy = request.SortBy
+	switch filter.sortBy {
+	case "":
+		filter.sortBy = models.Name
+		if len(filter.terms) > 0 {
+			filter.sortBy = models.Relevance
+		}
+	case models.Relevance:
+		if len(filter.terms) == 0 {
+			return nil, errors.Wrap(ErrInvalidSearch, "sorting by relevance needs a query")
+		}
+	case models.Name, models.Quantity, models.Price, models.CreatedAt, models.UpdatedAt:
+	default:
+		return nil, errors.Wrapf(ErrInvalidSearch, "can't sort by %q", filter.sortBy)
+	}
+
+	listed := request
+	listed.PageRequest = models.PageRequest{}
+	listed.PageNumber = 0
+	listed.PageSize = 0
+	listed.SortBy = filter.sortBy
+	filter.list = pagination.List(listed)
+	return filter, nil
+}
+
+// matches reports whether product passes every filter and, when there is a
+// query, how relevant it is. Products that don't match score zero.
+func (f productFilter) matches(product models.Product) (bool, int) {
+	if f.name != "" && !strings.Contains(strings.ToLower(product.Name), f.name) {
+		return false, 0
+	}
+	if f.category != "" && !strings.EqualFold(product.Category, f.category) {
+		return false, 0
+	}
+	if f.supplierId != "" && product.SupplierId != f.supplierId {
+		return false, 0
+	}
+	if f.minQuantity != nil && product.Quantity < *f.minQuantity {
+		return false, 0
+	}
+	if f.maxQuantity != nil && product.Quantity > *f.maxQuantity {
+		return false, 0
+	}
+	for _, tag := range f.tags {
+		if !slices.Contains(product.Tags, tag) {
+			return false, 0
+		}
+	}
+
+	if len(f.terms) == 0 {
+		return true, 0
+	}
+
+	nameWords := tokenise(product.Name)
+	descriptionWords := tokenise(product.Description)
+	score := 0
+	for _, term := range f.terms {
+		termScore := nameWeight*scoreTerm(term, nameWords) + descriptionWeight*scoreTerm(term, descriptionWords)
+		if termScore == 0 {
+			return false, 0
+		}
+		score += termScore
+	}
+	return true, score
+}
+
+// scoreTerm adds up how well term matches each of words.
+func scoreTerm(term string, words []string) int {
+	score := 0
+	for _, word := range words {
+		if word == term {
+			score += wholeWordWeight
+		} else if strings.HasPrefix(word, term) {
+			score++
+		}
+	}
+	return score
+}
+
+// productKey is what search results are sorted by for one product, with
+// only the fields for the chosen ordering set. Search cursors record the key
+// of the last product on the page.
+type productKey struct {
+	Score     int       `json:"s,omitempty"`
+	Name      string    `json:"n,omitempty"`
+	Currency  string    `json:"c,omitempty"`
+	UnitPrice int64     `json:"p,omitempty"`
+	Quantity  int       `json:"q,omitempty"`
+	Time      time.Time `json:"t"`
+	Id        string    `json:"i"`
+}
+
+// compare orders keys by highest score, then ascending by the other fields,
+// with the ID breaking ties.
+func (k productKey) compare(other productKey) int {
+	if c := cmp.Compare(other.Score, k.Score); c != 0 {
+		return c
+	}
+	if c := strings.Compare(k.Name, other.Name); c != 0 {
+		return c
+	}
+	if c := strings.Compare(k.Currency, other.Currency); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(k.UnitPrice, other.UnitPrice); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(k.Quantity, other.Quantity); c != 0 {
+		return c
+	}
+	if c := k.Time.Compare(other.Time); c != 0 {
+		return c
+	}
+	return strings.Compare(k.Id, other.Id)
+}
+
+// productKeyer returns how to key products for sortBy. Names are compared
+// ignoring case to match the repository's name index, and prices are only
+// compared within a currency.
+func productKeyer(sortBy models.SortBy, scores map[string]int) func(models.Product) productKey {
+	return func(product models.Product) productKey {
+		key := productKey{Id: product.Id}
+		switch sortBy {
+		case models.Relevance:
+			key.Score = scores[product.Id]
+			key.Name = strings.ToLower(product.Name)
+		case models.Name:
+			key.Name = strings.ToLower(product.Name)
+		case models.Quantity:
+			key.Quantity = product.Quantity
+		case models.Price:
+			key.Currency = product.Currency
+			key.UnitPrice = product.UnitPrice
+		case models.CreatedAt:
+			key.Time = product.CreatedAt
+		case models.UpdatedAt:
+			key.Time = product.UpdatedAt
+		}
+		return key
+	}
+}
+
+// tokenise splits text into lowercase words of letters and digits.
+func tokenise(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,194 @@
+package product
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/internal/pagination"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProductUseCase_SearchFilters(t *testing.T) {
+	catalogue := []models.Product{
+		{Id: "1", Name: "Playstation 5", Description: "Sony console with disc drive", Category: "Consoles", Tags: []string{"sony", "gaming"}, Quantity: 14, SupplierId: "sony"},
+		{Id: "2", Name: "Playstation 5 Digital Edition", Description: "Sony console", Category: "Consoles", Tags: []string{"sony", "gaming"}, Quantity: 30, SupplierId: "sony"},
+		{Id: "3", Name: "DualSense controller", Description: "Wireless controller for the Playstation 5", Category: "Accessories", Tags: []string{"sony"}, Quantity: 8, SupplierId: "sony"},
+		{Id: "4", Name: "Xbox Series X", Description: "Microsoft console", Category: "Consoles", Tags: []string{"microsoft", "gaming"}, Quantity: 34, SupplierId: "microsoft"},
+		{Id: "5", Name: "iPhone 15", Description: "Apple phone", Category: "Phones", Quantity: 74, SupplierId: "apple"},
+	}
+	twenty := 20
+	ten := 10
+
+	testCases := []struct {
+		name          string
+		request       models.SearchRequest
+		expectedIds   []string
+		expectedTotal int
+		expectedErr   error
+	}{
+		{
+			name:          "Happy path - name and quantity",
+			request:       models.SearchRequest{Name: "playstation", MaxQuantity: &twenty},
+			expectedIds:   []string{"1"},
+			expectedTotal: 1,
+		},
+		{
+			name:          "Happy path - category, supplier and tags",
+			request:       models.SearchRequest{Category: "consoles", SupplierId: "sony", Tags: []string{"Gaming"}, SortBy: models.Quantity, Order: models.Desc},
+			expectedIds:   []string{"2", "1"},
+			expectedTotal: 2,
+		},
+		{
+			name:          "Happy path - minimum quantity",
+			request:       models.SearchRequest{MinQuantity: &twenty, SortBy: models.Quantity},
+			expectedIds:   []string{"2", "4", "5"},
+			expectedTotal: 3,
+		},
+		{
+			name:          "Happy path - query ranks name matches first",
+			request:       models.SearchRequest{Query: "Playstation 5"},
+			expectedIds:   []string{"1", "2", "3"},
+			expectedTotal: 3,
+		},
+		{
+			name:          "Happy path - query matches word prefixes",
+			request:       models.SearchRequest{Query: "cons"},
+			expectedIds:   []string{"1", "2", "4"},
+			expectedTotal: 3,
+		},
+		{
+			name:          "Happy path - every query word must match",
+			request:       models.SearchRequest{Query: "sony wireless"},
+			expectedIds:   []string{},
+			expectedTotal: 0,
+		},
+		{
+			name:          "Happy path - total counts every page",
+			request:       models.SearchRequest{Category: "Consoles", SortBy: models.Name, PageNumber: 2, PageSize: 2},
+			expectedIds:   []string{"4"},
+			expectedTotal: 3,
+		},
+		{
+			name:        "Sad path - quantity range",
+			request:     models.SearchRequest{MinQuantity: &twenty, MaxQuantity: &ten},
+			expectedErr: ErrInvalidSearch,
+		},
+		{
+			name:        "Sad path - relevance without a query",
+			request:     models.SearchRequest{SortBy: models.Relevance},
+			expectedErr: ErrInvalidSearch,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			logger := slog.Default()
+			repo := mocks.NewProductRepository(t)
+			testUc := NewProductUseCase(config.Product{}, testStore{repo}, nil, unscoped(t), *logger)
+
+			if testCase.expectedErr == nil {
+				repo.On("GetAll").Return(append([]models.Product(nil), catalogue...), nil).Maybe()
+				repo.On("GetAllByName").Return(sortedByName(append([]models.Product(nil), catalogue...)), nil).Maybe()
+			}
+
+			request := testCase.request
+			if request.PageNumber == 0 {
+				request.PageNumber = 1
+				request.PageSize = len(catalogue)
+			}
+
+			products, pageInfo, err := testUc.Search("1", request)
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedIds, productIds(products))
+			assert.Equal(t, testCase.expectedTotal, pageInfo.Total)
+		})
+	}
+}
+
+func sortedByName(products []models.Product) []models.Product {
+	slices.SortFunc(products, func(a, b models.Product) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return products
+}
+
+func TestTokenise(t *testing.T) {
+	assert.Equal(t, []string{"playstation", "5", "digital", "edition"}, tokenise("PlayStation 5 - Digital/Edition!"))
+	assert.Empty(t, tokenise(" -- "))
+}
+
+func TestProductUseCase_SearchCursor(t *testing.T) {
+	store := newLedgerStore(t,
+		models.Product{Id: "1", Name: "A", Quantity: 10},
+		models.Product{Id: "2", Name: "B", Quantity: 20},
+		models.Product{Id: "3", Name: "C", Quantity: 30},
+		models.Product{Id: "4", Name: "D", Quantity: 40},
+		models.Product{Id: "5", Name: "E", Quantity: 50},
+	)
+	testUc := NewProductUseCase(config.Product{}, store, nil, unscoped(t), *slog.Default())
+	request := models.SearchRequest{
+		PageRequest: models.PageRequest{Limit: 2},
+		SortBy:      models.Quantity,
+		Order:       models.Desc,
+	}
+
+	products, pageInfo, err := testUc.Search("1", request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"5", "4"}, productIds(products))
+	assert.True(t, pageInfo.HasMore)
+	assert.Equal(t, 5, pageInfo.Total)
+
+	// Removing the last product seen and adding one before it doesn't shift the
+	// next page.
+	assert.NoError(t, store.Products().Delete("4"))
+	assert.NoError(t, store.Products().Create(models.Product{Id: "6", Name: "F", Quantity: 60}))
+
+	request.Cursor = pageInfo.NextCursor
+	products, pageInfo, err = testUc.Search("1", request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "2"}, productIds(products))
+	assert.True(t, pageInfo.HasMore)
+
+	request.Cursor = pageInfo.NextCursor
+	products, pageInfo, err = testUc.Search("1", request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, productIds(products))
+	assert.False(t, pageInfo.HasMore)
+	assert.Empty(t, pageInfo.NextCursor)
+
+	// Cursors only work with the search they came from.
+	_, _, err = testUc.Search("1", models.SearchRequest{PageRequest: models.PageRequest{Cursor: request.Cursor}, SortBy: models.Name})
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	_, _, err = testUc.Search("1", models.SearchRequest{PageRequest: models.PageRequest{Cursor: "not a cursor"}})
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	// Searches that don't page by cursor use the page number, and get every
+	// product without one.
+	products, pageInfo, err = testUc.Search("1", models.SearchRequest{PageNumber: 2, PageSize: 2, SortBy: models.Name})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "5"}, productIds(products))
+	assert.Equal(t, models.PageInfo{HasMore: true, Total: 5}, pageInfo)
+
+	products, pageInfo, err = testUc.Search("1", models.SearchRequest{SortBy: models.Name})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3", "5", "6"}, productIds(products))
+	assert.Equal(t, models.PageInfo{Total: 5}, pageInfo)
+}
+
+func productIds(products []models.Product) []string {
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.Id)
+	}
+	return ids
+}
@@ -0,0 +1,205 @@
+package product
+
+import (
+	"github.com/google/uuid"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrInvalidStockMovement = errors.New("invalid stock movement")
+	ErrInsufficientStock    = errors.New("insufficient stock")
+	ErrInvalidStockQuery    = errors.New("invalid stock history query")
+)
+
+// Recorded for the movement that brings a product stocked before the ledger
+// existed onto the ledger.
+const openingBalanceReason = "Opening balance"
+
+// stockDelta returns the change in quantity the request makes to its product.
+func stockDelta(request models.StockMovementRequest) (int, error) {
+	switch request.Type {
+	case models.Receive:
+		if request.Quantity <= 0 {
+			return 0, errors.Wrap(ErrInvalidStockMovement, "quantity must be positive")
+		}
+		return request.Quantity, nil
+	case models.Sell, models.Damage:
+		if request.Quantity <= 0 {
+			return 0, errors.Wrap(ErrInvalidStockMovement, "quantity must be positive")
+		}
+		return -request.Quantity, nil
+	case models.Transfer:
+		if request.Quantity <= 0 {
+			return 0, errors.Wrap(ErrInvalidStockMovement, "quantity must be positive")
+		}
+		if request.ToProductId == "" || request.ToProductId == request.Id {
+			return 0, errors.Wrap(ErrInvalidStockMovement, "transfers need another product to transfer to")
+		}
+		return -request.Quantity, nil
+	case models.Adjust:
+		if request.Quantity == 0 {
+			return 0, errors.Wrap(ErrInvalidStockMovement, "adjustments must change the quantity")
+		}
+		if request.Reason == "" {
+			return 0, errors.Wrap(ErrInvalidStockMovement, "adjustments need a reason")
+		}
+		return request.Quantity, nil
+	default:
+		return 0, errors.Wrapf(ErrInvalidStockMovement, "unknown movement type %s", request.Type)
+	}
+}
+
+// MoveStock records the movement in the product's ledger and updates its
+// quantity. Transfers record a matching movement against the product the stock
+// is transferred to, which must belong to the same supplier.
+func (p productUseCase) MoveStock(userId string, request models.StockMovementRequest) (*models.StockMovement, error) {
+	delta, err := stockDelta(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var movement *models.StockMovement
+	var notices []notice
+	err = p.Store.Transaction(func(tx domain.Tx) error {
+		product, err := tx.Products().Get(request.Id)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			return ErrProductNotFound
+		}
+
+		var target *models.Product
+		if request.Type == models.Transfer {
+			target, err = tx.Products().Get(request.ToProductId)
+			if err != nil {
+				return err
+			}
+			if target == nil {
+				return ErrProductNotFound
+			}
+			if target.SupplierId != product.SupplierId {
+				return errors.Wrap(ErrInvalidStockMovement, "stock can only be transferred between a supplier's own products")
+			}
+		}
+
+		movement, notices, err = p.recordMovement(tx, product, models.StockMovement{
+			Type:              request.Type,
+			Delta:             delta,
+			UserId:            userId,
+			Reason:            request.Reason,
+			Reference:         request.Reference,
+			TransferProductId: request.ToProductId,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = saveProduct(tx, product)
+		if err != nil || target == nil {
+			return err
+		}
+
+		_, targetNotices, err := p.recordMovement(tx, target, models.StockMovement{
+			Type:              models.Transfer,
+			Delta:             -delta,
+			UserId:            userId,
+			Reason:            request.Reason,
+			Reference:         request.Reference,
+			TransferProductId: product.Id,
+		})
+		if err != nil {
+			return err
+		}
+
+		notices = append(notices, targetNotices...)
+		return saveProduct(tx, target)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(notices)
+
+	p.Logger.Info("moved stock", "productId", request.Id, "type", request.Type, "delta", delta, "quantity", movement.Quantity, "userId", userId)
+	return movement, nil
+}
+
+// recordMovement adds the movement to the product's ledger and sets the
+// product's quantity to what the ledger now gives, recording alerts for the
+// thresholds that takes it to. The caller must save the product and publish the
+// notices returned. It fails with
+// ErrInsufficientStock rather than let the quantity go below zero or take
+// stock held by reservations.
+func (p productUseCase) recordMovement(tx domain.Tx, product *models.Product, movement models.StockMovement) (*models.StockMovement, []notice, error) {
+	ledger := tx.Stock()
+	latest, err := ledger.Latest(product.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balance := product.Quantity
+	if latest != nil {
+		balance = latest.Quantity
+	}
+
+	if balance+movement.Delta < 0 {
+		return nil, nil, errors.Wrapf(ErrInsufficientStock, "%d in stock", balance)
+	}
+	if movement.Delta < 0 && balance+movement.Delta < product.Reserved {
+		return nil, nil, errors.Wrapf(ErrInsufficientStock, "%d in stock of which %d is reserved", balance, product.Reserved)
+	}
+
+	now := time.Now().UTC()
+	if latest == nil && balance != 0 {
+		err = ledger.Add(models.StockMovement{
+			Id:        uuid.New().String(),
+			ProductId: product.Id,
+			Type:      models.Adjust,
+			Delta:     balance,
+			Quantity:  balance,
+			Time:      now,
+			Reason:    openingBalanceReason,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	movement.Id = uuid.New().String()
+	movement.ProductId = product.Id
+	movement.Quantity = balance + movement.Delta
+	movement.Time = now
+	err = ledger.Add(movement)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	product.Quantity = movement.Quantity
+	product.UpdatedAt = now
+	notices, err := p.evaluateThresholds(tx, product)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &movement, notices, nil
+}
+
+// GetStockMovements returns the product's movements in the order they were made.
+func (p productUseCase) GetStockMovements(request models.StockHistoryRequest) ([]models.StockMovement, error) {
+	if request.From != nil && request.To != nil && request.To.Before(*request.From) {
+		return nil, errors.Wrap(ErrInvalidStockQuery, "to must not be before from")
+	}
+
+	product, err := p.ProductRepository.Get(request.Id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+
+	return p.Store.Stock().GetByProduct(request.Id, request.From, request.To)
+}
@@ -0,0 +1,99 @@
+package product
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+type stockData struct {
+	Movements []models.StockMovement `json:"movements"`
+}
+
+// stockRepository keeps the stock ledger in memory, indexed by product, and
+// writes it through to a JSON file.
+type stockRepository struct {
+	Logger    slog.Logger
+	path      string
+	mu        sync.RWMutex
+	movements []models.StockMovement
+	// Positions in movements of each product's movements, in order.
+	byProduct map[string][]int
+}
+
+func newStockRepository(path string, logger slog.Logger) (*stockRepository, error) {
+	var data stockData
+	err := storage.ReadJSON(path, &data)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	repo := &stockRepository{
+		Logger:    logger,
+		path:      path,
+		movements: data.Movements,
+		byProduct: make(map[string][]int),
+	}
+	for i, movement := range repo.movements {
+		repo.byProduct[movement.ProductId] = append(repo.byProduct[movement.ProductId], i)
+	}
+	return repo, nil
+}
+
+func (s *stockRepository) Add(movement models.StockMovement) error {
+	s.Logger.Info("recording stock movement", "productId", movement.ProductId, "type", movement.Type, "delta", movement.Delta)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.movements = append(s.movements, movement)
+	err := storage.WriteJSON(s.path, stockData{Movements: s.movements})
+	if err != nil {
+		s.movements = s.movements[:len(s.movements)-1]
+		return errors.Wrap(err, "failed to persist stock movements")
+	}
+
+	s.byProduct[movement.ProductId] = append(s.byProduct[movement.ProductId], len(s.movements)-1)
+	return nil
+}
+
+func (s *stockRepository) Latest(productId string) (*models.StockMovement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	positions := s.byProduct[productId]
+	if len(positions) == 0 {
+		return nil, nil
+	}
+
+	movement := s.movements[positions[len(positions)-1]]
+	return &movement, nil
+}
+
+func (s *stockRepository) GetByProduct(productId string, from *time.Time, to *time.Time) ([]models.StockMovement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	positions := s.byProduct[productId]
+	start, end := 0, len(positions)
+	if from != nil {
+		start = sort.Search(len(positions), func(i int) bool {
+			return !s.movements[positions[i]].Time.Before(*from)
+		})
+	}
+	if to != nil {
+		end = sort.Search(len(positions), func(i int) bool {
+			return !s.movements[positions[i]].Time.Before(*to)
+		})
+	}
+
+	movements := make([]models.StockMovement, 0)
+	for i := start; i < end; i++ {
+		movements = append(movements, s.movements[positions[i]])
+	}
+	return movements, nil
+}
@@ -0,0 +1,271 @@
+package product
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// ledgerStore runs transactions against real product, stock and threshold
+// repositories.
+type ledgerStore struct {
+	testStore
+	stock        domain.StockRepository
+	thresholds   domain.ThresholdRepository
+	reservations domain.ReservationRepository
+}
+
+func (s ledgerStore) Stock() domain.StockRepository {
+	return s.stock
+}
+
+func (s ledgerStore) Thresholds() domain.ThresholdRepository {
+	return s.thresholds
+}
+
+func (s ledgerStore) Reservations() domain.ReservationRepository {
+	return s.reservations
+}
+
+func (s ledgerStore) Transaction(fn func(tx domain.Tx) error) error {
+	return fn(s)
+}
+
+func newLedgerStore(t *testing.T, products ...models.Product) ledgerStore {
+	dir := t.TempDir()
+	logger := *slog.Default()
+	repo, err := newProductRepository(filepath.Join(dir, "products.json"), filepath.Join(dir, "subscriptions.json"), logger)
+	assert.NoError(t, err)
+	for _, product := range products {
+		assert.NoError(t, repo.Create(product))
+	}
+
+	stock, err := newStockRepository(filepath.Join(dir, "stock.json"), logger)
+	assert.NoError(t, err)
+
+	thresholds, err := newThresholdRepository(filepath.Join(dir, "thresholds.json"), logger)
+	assert.NoError(t, err)
+
+	reservations, err := newReservationRepository(filepath.Join(dir, "reservations.json"), logger)
+	assert.NoError(t, err)
+
+	return ledgerStore{testStore{repo}, stock, thresholds, reservations}
+}
+
+func newLedgerUseCase(t *testing.T, products ...models.Product) (domain.ProductUseCase, domain.StockRepository) {
+	store := newLedgerStore(t, products...)
+	return NewProductUseCase(config.Product{}, store, nil, unscoped(t), *slog.Default()), store.stock
+}
+
+func TestProductUseCase_MoveStock(t *testing.T) {
+	testCases := []struct {
+		name             string
+		request          models.StockMovementRequest
+		expectedErr      error
+		expectedQuantity int
+		expectedLedger   []int
+	}{
+		{
+			name:             "Happy path - receive",
+			request:          models.StockMovementRequest{Id: "1", Type: models.Receive, Quantity: 5, Reference: "PO-1"},
+			expectedQuantity: 15,
+			expectedLedger:   []int{10, 5},
+		},
+		{
+			name:             "Happy path - sell",
+			request:          models.StockMovementRequest{Id: "1", Type: models.Sell, Quantity: 10},
+			expectedQuantity: 0,
+			expectedLedger:   []int{10, -10},
+		},
+		{
+			name:             "Happy path - adjust",
+			request:          models.StockMovementRequest{Id: "1", Type: models.Adjust, Quantity: -3, Reason: "Stock take"},
+			expectedQuantity: 7,
+			expectedLedger:   []int{10, -3},
+		},
+		{
+			name:             "Happy path - transfer",
+			request:          models.StockMovementRequest{Id: "1", Type: models.Transfer, Quantity: 4, ToProductId: "2"},
+			expectedQuantity: 6,
+			expectedLedger:   []int{10, -4},
+		},
+		{
+			name:        "Sad path - insufficient stock",
+			request:     models.StockMovementRequest{Id: "1", Type: models.Damage, Quantity: 11},
+			expectedErr: ErrInsufficientStock,
+		},
+		{
+			name:        "Sad path - adjustment without reason",
+			request:     models.StockMovementRequest{Id: "1", Type: models.Adjust, Quantity: 2},
+			expectedErr: ErrInvalidStockMovement,
+		},
+		{
+			name:        "Sad path - non positive quantity",
+			request:     models.StockMovementRequest{Id: "1", Type: models.Receive, Quantity: -2},
+			expectedErr: ErrInvalidStockMovement,
+		},
+		{
+			name:        "Sad path - transfer to another supplier",
+			request:     models.StockMovementRequest{Id: "1", Type: models.Transfer, Quantity: 1, ToProductId: "3"},
+			expectedErr: ErrInvalidStockMovement,
+		},
+		{
+			name:        "Sad path - product not found",
+			request:     models.StockMovementRequest{Id: "missing", Type: models.Receive, Quantity: 1},
+			expectedErr: ErrProductNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testUc, _ := newLedgerUseCase(t,
+				models.Product{Id: "1", Quantity: 10, SupplierId: "supplier"},
+				models.Product{Id: "2", Quantity: 1, SupplierId: "supplier"},
+				models.Product{Id: "3", SupplierId: "other"},
+			)
+
+			movement, err := testUc.MoveStock("user", testCase.request)
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedQuantity, movement.Quantity)
+			assert.Equal(t, "user", movement.UserId)
+
+			product, err := testUc.Get("user", "1")
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedQuantity, product.Quantity)
+
+			// Stock held before the ledger existed is recorded as an opening balance.
+			movements, err := testUc.GetStockMovements(models.StockHistoryRequest{Id: "1"})
+			assert.NoError(t, err)
+			var deltas []int
+			for _, movement := range movements {
+				deltas = append(deltas, movement.Delta)
+			}
+			assert.Equal(t, testCase.expectedLedger, deltas)
+			assert.Equal(t, openingBalanceReason, movements[0].Reason)
+
+			if testCase.request.Type == models.Transfer {
+				target, err := testUc.Get("user", "2")
+				assert.NoError(t, err)
+				assert.Equal(t, 5, target.Quantity)
+			}
+		})
+	}
+}
+
+func TestProductUseCase_CreateSetsFields(t *testing.T) {
+	testUc, _ := newLedgerUseCase(t)
+
+	// The created product can be sent straight back as an update.
+	product := models.Product{Name: "iPhone 15", Quantity: 8, SupplierId: "supplier"}
+	assert.NoError(t, testUc.Create(&product))
+	assert.NotEmpty(t, product.Id)
+	assert.Equal(t, 1, product.Version)
+	assert.False(t, product.CreatedAt.IsZero())
+	stored, err := testUc.Get("supplier", product.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, &product)
+
+	product.Quantity = 5
+	assert.NoError(t, testUc.Update("supplier", &product))
+	assert.Equal(t, 2, product.Version)
+}
+
+func TestProductUseCase_CreateAndUpdateRecordStock(t *testing.T) {
+	testUc, stock := newLedgerUseCase(t)
+
+	assert.NoError(t, testUc.Create(&models.Product{Name: "iPhone 15", Quantity: 8, SupplierId: "supplier"}))
+	products, err := testUc.GetAll("supplier")
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	product := products[0]
+	assert.Equal(t, 8, product.Quantity)
+
+	product.Quantity = 5
+	assert.NoError(t, testUc.Update("supplier", &product))
+
+	product.Quantity = -1
+	assert.ErrorIs(t, testUc.Update("supplier", &product), ErrInsufficientStock)
+
+	movements, err := stock.GetByProduct(product.Id, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, movements, 2)
+	assert.Equal(t, models.Receive, movements[0].Type)
+	assert.Equal(t, models.StockMovement{
+		Id:        movements[1].Id,
+		ProductId: product.Id,
+		Type:      models.Adjust,
+		Delta:     -3,
+		Quantity:  5,
+		UserId:    "supplier",
+		Time:      movements[1].Time,
+		Reason:    "Product updated",
+	}, movements[1])
+}
+
+func TestStockRepository_GetByProduct(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stock.json")
+	repo, err := newStockRepository(path, *slog.Default())
+	assert.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, repo.Add(models.StockMovement{Id: string(rune('a' + i)), ProductId: "1", Time: start.Add(time.Duration(i) * time.Hour)}))
+	}
+	assert.NoError(t, repo.Add(models.StockMovement{Id: "other", ProductId: "2", Time: start}))
+
+	reloaded, err := newStockRepository(path, *slog.Default())
+	assert.NoError(t, err)
+
+	from := start.Add(time.Hour)
+	to := start.Add(3 * time.Hour)
+	movements, err := reloaded.GetByProduct("1", &from, &to)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, []string{movements[0].Id, movements[1].Id})
+
+	latest, err := reloaded.Latest("1")
+	assert.NoError(t, err)
+	assert.Equal(t, "d", latest.Id)
+
+	latest, err = reloaded.Latest("missing")
+	assert.NoError(t, err)
+	assert.Nil(t, latest)
+}
+
+func TestProductUseCase_Versions(t *testing.T) {
+	testUc, _ := newLedgerUseCase(t,
+		models.Product{Id: "1", Name: "iPhone 15", Quantity: 10, SupplierId: "supplier", Version: 1},
+		models.Product{Id: "2", Name: "Xbox", Quantity: 5, SupplierId: "supplier", Version: 1},
+	)
+
+	// Stock movements save the product as a new version.
+	_, err := testUc.MoveStock("supplier", models.StockMovementRequest{Id: "1", Type: models.Sell, Quantity: 2})
+	assert.NoError(t, err)
+	product, err := testUc.Get("supplier", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, product.Version)
+
+	// An update based on the version before the sale is rejected.
+	stale := models.Product{Id: "1", Name: "iPhone 15", Quantity: 10, Version: 1}
+	assert.ErrorIs(t, testUc.Update("supplier", &stale), domain.ErrVersionConflict)
+
+	current := *product
+	current.Name = "Apple iPhone 15"
+	assert.NoError(t, testUc.Update("supplier", &current))
+	assert.Equal(t, 3, current.Version)
+
+	products, err := testUc.GetAll("supplier")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, []string{products[0].Id, products[1].Id})
+	assert.Equal(t, "Apple iPhone 15", products[0].Name)
+	assert.Equal(t, 8, products[0].Quantity)
+	assert.Equal(t, 3, products[0].Version)
+}
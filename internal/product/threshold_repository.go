@@ -0,0 +1,148 @@
+package product
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type thresholdData struct {
+	Thresholds []models.UserThreshold `json:"thresholds"`
+}
+
+// thresholdRepository keeps users' thresholds by product and then user, and
+// writes them through to a JSON file.
+type thresholdRepository struct {
+	Logger     slog.Logger
+	path       string
+	mu         sync.RWMutex
+	thresholds map[string]map[string]models.UserThreshold
+}
+
+func newThresholdRepository(path string, logger slog.Logger) (*thresholdRepository, error) {
+	var data thresholdData
+	err := storage.ReadJSON(path, &data)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	repo := &thresholdRepository{
+		Logger:     logger,
+		path:       path,
+		thresholds: make(map[string]map[string]models.UserThreshold),
+	}
+	for _, threshold := range data.Thresholds {
+		repo.put(threshold)
+	}
+	return repo, nil
+}
+
+func (t *thresholdRepository) put(threshold models.UserThreshold) {
+	users, ok := t.thresholds[threshold.ProductId]
+	if !ok {
+		users = make(map[string]models.UserThreshold)
+		t.thresholds[threshold.ProductId] = users
+	}
+	users[threshold.UserId] = threshold
+}
+
+func (t *thresholdRepository) remove(userId string, productId string) {
+	delete(t.thresholds[productId], userId)
+	if len(t.thresholds[productId]) == 0 {
+		delete(t.thresholds, productId)
+	}
+}
+
+func (t *thresholdRepository) Save(threshold models.UserThreshold) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous, existed := t.thresholds[threshold.ProductId][threshold.UserId]
+	t.put(threshold)
+	err := t.save()
+	if err != nil {
+		if existed {
+			t.put(previous)
+		} else {
+			t.remove(threshold.UserId, threshold.ProductId)
+		}
+		return err
+	}
+	return nil
+}
+
+func (t *thresholdRepository) Delete(userId string, productId string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous, existed := t.thresholds[productId][userId]
+	if !existed {
+		return nil
+	}
+
+	t.remove(userId, productId)
+	err := t.save()
+	if err != nil {
+		t.put(previous)
+		return err
+	}
+	return nil
+}
+
+func (t *thresholdRepository) GetByProduct(productId string) ([]models.UserThreshold, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	thresholds := make([]models.UserThreshold, 0, len(t.thresholds[productId]))
+	for _, threshold := range t.thresholds[productId] {
+		thresholds = append(thresholds, threshold)
+	}
+	return sortThresholds(thresholds), nil
+}
+
+func (t *thresholdRepository) GetByUser(userId string) ([]models.UserThreshold, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	thresholds := make([]models.UserThreshold, 0)
+	for _, users := range t.thresholds {
+		if threshold, ok := users[userId]; ok {
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	return sortThresholds(thresholds), nil
+}
+
+// sortThresholds orders thresholds by product and then user, the order they're
+// persisted in.
+func sortThresholds(thresholds []models.UserThreshold) []models.UserThreshold {
+	slices.SortFunc(thresholds, func(a, b models.UserThreshold) int {
+		if c := strings.Compare(a.ProductId, b.ProductId); c != 0 {
+			return c
+		}
+		return strings.Compare(a.UserId, b.UserId)
+	})
+	return thresholds
+}
+
+// save persists the current thresholds. The caller must hold the write lock and
+// undo its change if this fails.
+func (t *thresholdRepository) save() error {
+	thresholds := make([]models.UserThreshold, 0)
+	for _, users := range t.thresholds {
+		for _, threshold := range users {
+			thresholds = append(thresholds, threshold)
+		}
+	}
+
+	err := storage.WriteJSON(t.path, thresholdData{Thresholds: sortThresholds(thresholds)})
+	if err != nil {
+		return errors.Wrap(err, "failed to persist thresholds")
+	}
+	return nil
+}
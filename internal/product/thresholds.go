@@ -0,0 +1,168 @@
+package product
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"slices"
+)
+
+var ErrInvalidThreshold = errors.New("thresholds can't be negative")
+
+// notice is a notification recorded in a transaction, to be published to its
+// users' broadcast subscriptions once the transaction has committed.
+type notice struct {
+	message string
+	users   []string
+}
+
+// crossThreshold returns whether a threshold is triggered at quantity, and
+// whether it has only just been, given whether it was triggered before. Once
+// triggered it stays so until quantity rises more than margin above it.
+func crossThreshold(threshold int, triggered bool, quantity int, margin int) (bool, bool) {
+	if !triggered {
+		return quantity <= threshold, quantity <= threshold
+	}
+	return quantity <= threshold+margin, false
+}
+
+// evaluateThresholds updates the triggered state of the product's reorder
+// threshold and of users' own thresholds for its current quantity, recording
+// low stock alerts for those just triggered in the transaction. The caller must
+// save the product and publish the notices returned once it has committed.
+func (p productUseCase) evaluateThresholds(tx domain.Tx, product *models.Product) ([]notice, error) {
+	var notices []notice
+	if product.ReorderThreshold == nil {
+		product.LowStock = false
+	} else {
+		triggered, alert := crossThreshold(*product.ReorderThreshold, product.LowStock, product.Quantity, p.LowStockMargin)
+		product.LowStock = triggered
+		if alert {
+			subscribers, err := tx.Products().GetProductSubscribers(product.Id)
+			if err != nil {
+				return nil, err
+			}
+
+			users := []string{product.SupplierId}
+			for _, userId := range subscribers {
+				if !slices.Contains(users, userId) {
+					users = append(users, userId)
+				}
+			}
+			notices, err = p.recordAlert(tx, notices, *product, users)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	thresholds, err := tx.Thresholds().GetByProduct(product.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, threshold := range thresholds {
+		triggered, alert := crossThreshold(threshold.Threshold, threshold.Triggered, product.Quantity, p.LowStockMargin)
+		if triggered != threshold.Triggered {
+			threshold.Triggered = triggered
+			err = tx.Thresholds().Save(threshold)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if alert {
+			notices, err = p.recordAlert(tx, notices, *product, []string{threshold.UserId})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return notices, nil
+}
+
+// recordAlert records a low stock alert for the users that can see the product
+// in the transaction, adding it to notices.
+func (p productUseCase) recordAlert(tx domain.Tx, notices []notice, product models.Product, userIds []string) ([]notice, error) {
+	users := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		scope, err := p.Organisations.GetScope(userId)
+		if err == nil && scope.CanSeeProduct(product) {
+			users = append(users, userId)
+		}
+	}
+
+	if len(users) == 0 {
+		return notices, nil
+	}
+
+	message := fmt.Sprintf("Product %s is low on stock with %v quantity remaining", product.Name, product.Quantity)
+	p.Logger.Info("sending low stock alert", "productId", product.Id, "users", users)
+	err := p.Notification.Record(tx, message, users)
+	if err != nil {
+		return nil, err
+	}
+	return append(notices, notice{message: message, users: users}), nil
+}
+
+// publish pushes notices recorded in a committed transaction to their users.
+// They are already saved, so failures are only logged.
+func (p productUseCase) publish(notices []notice) {
+	for _, notice := range notices {
+		err := p.Notification.Publish(notice.message, notice.users)
+		if err != nil {
+			p.Logger.Error("failed to publish notification", "users", notice.users, "error", err)
+		}
+	}
+}
+
+// SetUserThreshold sets or, when the request's threshold is null, clears
+// userId's own threshold for a product they can see. Setting a threshold the
+// product is already at or below alerts straight away.
+func (p productUseCase) SetUserThreshold(userId string, request models.UserThresholdRequest) error {
+	product, err := p.Get(userId, request.ProductId)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return ErrProductNotFound
+	}
+
+	if request.Threshold == nil {
+		return p.Store.Transaction(func(tx domain.Tx) error {
+			return tx.Thresholds().Delete(userId, request.ProductId)
+		})
+	}
+
+	if *request.Threshold < 0 {
+		return ErrInvalidThreshold
+	}
+
+	triggered, alert := crossThreshold(*request.Threshold, false, product.Quantity, p.LowStockMargin)
+	var notices []notice
+	err = p.Store.Transaction(func(tx domain.Tx) error {
+		err := tx.Thresholds().Save(models.UserThreshold{
+			UserId:    userId,
+			ProductId: request.ProductId,
+			Threshold: *request.Threshold,
+			Triggered: triggered,
+		})
+		if err != nil || !alert {
+			return err
+		}
+
+		notices, err = p.recordAlert(tx, nil, *product, []string{userId})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	p.publish(notices)
+	return nil
+}
+
+func (p productUseCase) GetUserThresholds(userId string) ([]models.UserThreshold, error) {
+	return p.Store.Thresholds().GetByUser(userId)
+}
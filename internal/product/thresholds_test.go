@@ -0,0 +1,101 @@
+package product
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"testing"
+)
+
+func TestCrossThreshold(t *testing.T) {
+	testCases := []struct {
+		name              string
+		triggered         bool
+		quantity          int
+		expectedTriggered bool
+		expectedAlert     bool
+	}{
+		{name: "Above threshold", triggered: false, quantity: 6, expectedTriggered: false, expectedAlert: false},
+		{name: "Falls to threshold", triggered: false, quantity: 5, expectedTriggered: true, expectedAlert: true},
+		{name: "Falls below threshold", triggered: false, quantity: 0, expectedTriggered: true, expectedAlert: true},
+		{name: "Stays below threshold", triggered: true, quantity: 3, expectedTriggered: true, expectedAlert: false},
+		{name: "Rises within margin", triggered: true, quantity: 8, expectedTriggered: true, expectedAlert: false},
+		{name: "Rises beyond margin", triggered: true, quantity: 9, expectedTriggered: false, expectedAlert: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			triggered, alert := crossThreshold(5, testCase.triggered, testCase.quantity, 3)
+			assert.Equal(t, testCase.expectedTriggered, triggered)
+			assert.Equal(t, testCase.expectedAlert, alert)
+		})
+	}
+}
+
+// expectNotice expects a notification to be recorded and then published once.
+func expectNotice(notification *mocks.NotificationUseCase, message string, users []string) {
+	notification.On("Record", mock.Anything, message, users).Return(nil).Once()
+	notification.On("Publish", message, users).Return(nil).Once()
+}
+
+func TestProductUseCase_LowStockAlerts(t *testing.T) {
+	threshold := 5
+	store := newLedgerStore(t, models.Product{Id: "1", Name: "iPhone 15", Quantity: 10, SupplierId: "supplier", ReorderThreshold: &threshold})
+	assert.NoError(t, store.Products().Subscribe("1", "daily", "john"))
+	assert.NoError(t, store.Products().Subscribe("1", "hourly", "john"))
+
+	notification := mocks.NewNotificationUseCase(t)
+	testUc := NewProductUseCase(config.Product{LowStockMargin: 3}, store, notification, unscoped(t), *slog.Default())
+
+	userThreshold := 8
+	assert.NoError(t, testUc.SetUserThreshold("jane", models.UserThresholdRequest{ProductId: "1", Threshold: &userThreshold}))
+
+	move := func(movementType models.StockMovementType, quantity int) {
+		_, err := testUc.MoveStock("supplier", models.StockMovementRequest{Id: "1", Type: movementType, Quantity: quantity})
+		assert.NoError(t, err)
+	}
+
+	// Falling to jane's threshold alerts only her.
+	expectNotice(notification, "Product iPhone 15 is low on stock with 8 quantity remaining", []string{"jane"})
+	move(models.Sell, 2)
+
+	// Falling to the reorder threshold alerts the supplier and subscribers once.
+	expectNotice(notification, "Product iPhone 15 is low on stock with 5 quantity remaining", []string{"supplier", "john"})
+	move(models.Sell, 3)
+	move(models.Sell, 1)
+
+	// Restocking within the margin and falling back doesn't alert again.
+	move(models.Receive, 4)
+	move(models.Sell, 3)
+
+	product, err := testUc.Get("supplier", "1")
+	assert.NoError(t, err)
+	assert.True(t, product.LowStock)
+
+	// Restocking beyond the margin re-arms both thresholds.
+	move(models.Receive, 7)
+	product, err = testUc.Get("supplier", "1")
+	assert.NoError(t, err)
+	assert.False(t, product.LowStock)
+
+	expectNotice(notification, "Product iPhone 15 is low on stock with 6 quantity remaining", []string{"jane"})
+	move(models.Sell, 6)
+	expectNotice(notification, "Product iPhone 15 is low on stock with 5 quantity remaining", []string{"supplier", "john"})
+	move(models.Sell, 1)
+
+	thresholds, err := testUc.GetUserThresholds("jane")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.UserThreshold{{UserId: "jane", ProductId: "1", Threshold: 8, Triggered: true}}, thresholds)
+
+	assert.NoError(t, testUc.SetUserThreshold("jane", models.UserThresholdRequest{ProductId: "1"}))
+	thresholds, err = testUc.GetUserThresholds("jane")
+	assert.NoError(t, err)
+	assert.Empty(t, thresholds)
+
+	negative := -1
+	assert.ErrorIs(t, testUc.SetUserThreshold("jane", models.UserThresholdRequest{ProductId: "1", Threshold: &negative}), ErrInvalidThreshold)
+	assert.ErrorIs(t, testUc.SetUserThreshold("jane", models.UserThresholdRequest{ProductId: "missing", Threshold: &threshold}), ErrProductNotFound)
+}
@@ -0,0 +1,157 @@
+package product
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidProduct = errors.New("invalid product")
+	ErrDuplicateSku   = errors.New("sku is already used by another product")
+)
+
+const (
+	maxNameLength        = 200
+	maxSkuLength         = 64
+	maxDescriptionLength = 2000
+	maxCategoryLength    = 100
+	maxTags              = 20
+	maxTagLength         = 32
+)
+
+var (
+	skuPattern      = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// normaliseProduct trims the product's descriptive fields, lowercases and
+// de-duplicates its tags, uppercases its currency and defaults its unit of
+// measure, then checks they are valid.
+func normaliseProduct(product *models.Product) error {
+	product.Name = strings.TrimSpace(product.Name)
+	if product.Name == "" {
+		return errors.Wrap(ErrInvalidProduct, "name is required")
+	}
+	if utf8.RuneCountInString(product.Name) > maxNameLength {
+		return errors.Wrapf(ErrInvalidProduct, "name must be at most %d characters", maxNameLength)
+	}
+
+	product.Sku = strings.TrimSpace(product.Sku)
+	if product.Sku != "" && (len(product.Sku) > maxSkuLength || !skuPattern.MatchString(product.Sku)) {
+		return errors.Wrapf(ErrInvalidProduct, "sku must be at most %d letters, digits, dots, dashes and underscores", maxSkuLength)
+	}
+
+	product.Barcode = strings.TrimSpace(product.Barcode)
+	if product.Barcode != "" && !isValidBarcode(product.Barcode) {
+		return errors.Wrap(ErrInvalidProduct, "barcode must be a GTIN-8, 12, 13 or 14 with a valid check digit")
+	}
+
+	product.Description = strings.TrimSpace(product.Description)
+	if utf8.RuneCountInString(product.Description) > maxDescriptionLength {
+		return errors.Wrapf(ErrInvalidProduct, "description must be at most %d characters", maxDescriptionLength)
+	}
+
+	product.Category = strings.TrimSpace(product.Category)
+	if utf8.RuneCountInString(product.Category) > maxCategoryLength {
+		return errors.Wrapf(ErrInvalidProduct, "category must be at most %d characters", maxCategoryLength)
+	}
+
+	tags := make([]string, 0, len(product.Tags))
+	for _, tag := range product.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return errors.Wrapf(ErrInvalidProduct, "tags must be at most %d characters", maxTagLength)
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxTags {
+		return errors.Wrapf(ErrInvalidProduct, "products can have at most %d tags", maxTags)
+	}
+	product.Tags = nil
+	if len(tags) > 0 {
+		product.Tags = tags
+	}
+
+	if product.UnitPrice < 0 {
+		return errors.Wrap(ErrInvalidProduct, "unit price can't be negative")
+	}
+	product.Currency = strings.ToUpper(strings.TrimSpace(product.Currency))
+	if product.Currency == "" && product.UnitPrice > 0 {
+		return errors.Wrap(ErrInvalidProduct, "currency is required with a unit price")
+	}
+	if product.Currency != "" && !currencyPattern.MatchString(product.Currency) {
+		return errors.Wrap(ErrInvalidProduct, "currency must be a three letter ISO 4217 code")
+	}
+
+	if product.UnitOfMeasure == "" {
+		product.UnitOfMeasure = models.Each
+	}
+	if !product.UnitOfMeasure.IsValid() {
+		return errors.Wrapf(ErrInvalidProduct, "unknown unit of measure %s", product.UnitOfMeasure)
+	}
+
+	if product.ReorderThreshold != nil && *product.ReorderThreshold < 0 {
+		return ErrInvalidThreshold
+	}
+	return nil
+}
+
+// isValidBarcode reports whether barcode is a GTIN whose last digit is the
+// check digit of the rest.
+func isValidBarcode(barcode string) bool {
+	switch len(barcode) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	sum := 0
+	for i := len(barcode) - 1; i >= 0; i-- {
+		digit := int(barcode[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+
+		// Digits are weighted 3 and 1 alternately, starting with 3 from the
+		// digit before the check digit.
+		if i == len(barcode)-1 {
+			continue
+		}
+		if (len(barcode)-1-i)%2 == 1 {
+			sum += digit * 3
+		} else {
+			sum += digit
+		}
+	}
+
+	check := int(barcode[len(barcode)-1] - '0')
+	return (10-sum%10)%10 == check
+}
+
+// checkSkuAvailable fails with ErrDuplicateSku if another of the supplier's
+// products already has the product's SKU.
+func checkSkuAvailable(tx domain.Tx, product models.Product) error {
+	if product.Sku == "" {
+		return nil
+	}
+
+	products, err := tx.Products().GetBySku(product.SupplierId, product.Sku)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range products {
+		if existing.Id != product.Id {
+			return ErrDuplicateSku
+		}
+	}
+	return nil
+}
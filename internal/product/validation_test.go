@@ -0,0 +1,123 @@
+package product
+
+import (
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNormaliseProduct(t *testing.T) {
+	negative := -1
+	testCases := []struct {
+		name        string
+		product     models.Product
+		expected    models.Product
+		expectedErr error
+	}{
+		{
+			name: "Happy path",
+			product: models.Product{
+				Name:      " iPhone 15 ",
+				Sku:       "APL-IP15-128",
+				Barcode:   "0194253401520",
+				Tags:      []string{"Phones", " apple", "phones", ""},
+				UnitPrice: 79900,
+				Currency:  "gbp",
+			},
+			expected: models.Product{
+				Name:          "iPhone 15",
+				Sku:           "APL-IP15-128",
+				Barcode:       "0194253401520",
+				Tags:          []string{"phones", "apple"},
+				UnitPrice:     79900,
+				Currency:      "GBP",
+				UnitOfMeasure: models.Each,
+			},
+		},
+		{
+			name:     "Happy path - GTIN-8 barcode",
+			product:  models.Product{Name: "Gum", Barcode: "96385074", UnitOfMeasure: models.Pack},
+			expected: models.Product{Name: "Gum", Barcode: "96385074", UnitOfMeasure: models.Pack},
+		},
+		{
+			name:        "Sad path - missing name",
+			product:     models.Product{Name: "  "},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name:        "Sad path - invalid sku",
+			product:     models.Product{Name: "iPhone 15", Sku: "APL IP15"},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name:        "Sad path - wrong check digit",
+			product:     models.Product{Name: "iPhone 15", Barcode: "0194253401521"},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name:        "Sad path - price without currency",
+			product:     models.Product{Name: "iPhone 15", UnitPrice: 100},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name:        "Sad path - invalid currency",
+			product:     models.Product{Name: "iPhone 15", Currency: "pounds"},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name:        "Sad path - negative price",
+			product:     models.Product{Name: "iPhone 15", UnitPrice: -1, Currency: "GBP"},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name:        "Sad path - unknown unit of measure",
+			product:     models.Product{Name: "iPhone 15", UnitOfMeasure: "crate"},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name:        "Sad path - long description",
+			product:     models.Product{Name: "iPhone 15", Description: strings.Repeat("a", maxDescriptionLength+1)},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name:        "Sad path - negative reorder threshold",
+			product:     models.Product{Name: "iPhone 15", ReorderThreshold: &negative},
+			expectedErr: ErrInvalidThreshold,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			product := testCase.product
+			err := normaliseProduct(&product)
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, product)
+		})
+	}
+}
+
+func TestProductUseCase_DuplicateSku(t *testing.T) {
+	testUc, _ := newLedgerUseCase(t,
+		models.Product{Id: "1", Name: "iPhone 15", Sku: "IP15", SupplierId: "supplier"},
+		models.Product{Id: "2", Name: "iPhone 14", Sku: "IP14", SupplierId: "supplier"},
+	)
+
+	assert.ErrorIs(t, testUc.Create(&models.Product{Name: "iPhone 15 Pro", Sku: "ip15", SupplierId: "supplier"}), ErrDuplicateSku)
+	assert.NoError(t, testUc.Create(&models.Product{Name: "iPhone 15", Sku: "IP15", SupplierId: "other"}))
+
+	assert.ErrorIs(t, testUc.Update("supplier", &models.Product{Id: "2", Name: "iPhone 14", Sku: "IP15"}), ErrDuplicateSku)
+
+	// A product keeps its own SKU when updated, and its creation time.
+	product := models.Product{Id: "1", Name: "iPhone 15", Sku: "IP15", Category: "Phones"}
+	assert.NoError(t, testUc.Update("supplier", &product))
+	updated, err := testUc.Get("supplier", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Phones", updated.Category)
+	assert.True(t, updated.CreatedAt.IsZero())
+	assert.False(t, updated.UpdatedAt.IsZero())
+}
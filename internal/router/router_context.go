@@ -2,6 +2,7 @@ package router
 
 import (
 	"encoding/json"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
 )
 
 type RouterContext struct {
@@ -9,6 +10,12 @@ type RouterContext struct {
 	Headers  map[string]string
 	Response *string
 	Sender   string
+	// The status code of the response written to Response.
+	StatusCode int
+
+	// The authenticated caller, populated by the router guard for non-public
+	// routes and by the login routes once the user has logged in.
+	User *models.UserClaim
 }
 
 func (rc *RouterContext) JSON(code int, i interface{}) {
@@ -19,6 +26,7 @@ func (rc *RouterContext) JSON(code int, i interface{}) {
 
 	respStr := string(bytes)
 	rc.Response = &respStr
+	rc.StatusCode = code
 }
 
 func (rc *RouterContext) GetAuthToken() *string {
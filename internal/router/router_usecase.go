@@ -13,9 +13,19 @@ type HandlerKey struct {
 	Method models.RequestType
 }
 
+// Guard runs before every handler. It returns false, having written a response
+// to ctx, when the request must not reach the handler.
+type Guard func(key HandlerKey, ctx *RouterContext) bool
+
+// Hook runs after every request has been handled, including requests rejected
+// by the guard, once the response has been written to ctx.
+type Hook func(key HandlerKey, ctx *RouterContext)
+
 type RouterUseCase struct {
 	Logger   slog.Logger
 	Handlers map[HandlerKey]func(ctx *RouterContext)
+	Guard    Guard
+	Hooks    []Hook
 }
 
 func NewRouterUseCase(logger slog.Logger) *RouterUseCase {
@@ -56,7 +66,13 @@ func (r *RouterUseCase) Handle(message []byte, remoteAddr string) (*string, erro
 		Sender:  remoteAddr,
 	}
 
-	handler(ctx)
+	if r.Guard == nil || r.Guard(handlerKey, ctx) {
+		handler(ctx)
+	}
+
+	for _, hook := range r.Hooks {
+		hook(handlerKey, ctx)
+	}
 	return ctx.Response, nil
 }
 
@@ -70,6 +86,14 @@ func (r *RouterUseCase) parseMessage(message []byte) (*models.Request, error) {
 	return &request, nil
 }
 
+func (r *RouterUseCase) SetGuard(guard Guard) {
+	r.Guard = guard
+}
+
+func (r *RouterUseCase) AddHook(hook Hook) {
+	r.Hooks = append(r.Hooks, hook)
+}
+
 func (r *RouterUseCase) AddRoute(route string, method models.RequestType, handler func(ctx *RouterContext)) {
 	key := HandlerKey{
 		Route:  route,
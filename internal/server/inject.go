@@ -1,7 +1,10 @@
 package server
 
 import (
+	"github.com/kkcaz/shu-dades-server/internal/apikey"
+	"github.com/kkcaz/shu-dades-server/internal/audit"
 	"github.com/kkcaz/shu-dades-server/internal/auth"
+	"github.com/kkcaz/shu-dades-server/internal/authorisation"
 	"github.com/kkcaz/shu-dades-server/internal/broadcast"
 	"github.com/kkcaz/shu-dades-server/internal/chat"
 	"github.com/kkcaz/shu-dades-server/internal/config"
@@ -9,9 +12,13 @@ import (
 	"github.com/kkcaz/shu-dades-server/internal/domain"
 	encryption2 "github.com/kkcaz/shu-dades-server/internal/encryption"
 	"github.com/kkcaz/shu-dades-server/internal/front_controller"
+	"github.com/kkcaz/shu-dades-server/internal/mail"
 	"github.com/kkcaz/shu-dades-server/internal/notification"
+	"github.com/kkcaz/shu-dades-server/internal/organisation"
 	"github.com/kkcaz/shu-dades-server/internal/product"
 	routerUc "github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/internal/store"
+	"github.com/kkcaz/shu-dades-server/internal/user"
 	"github.com/pkg/errors"
 	"log/slog"
 	"os"
@@ -27,28 +34,74 @@ func Inject(cfg *config.Config) (domain.FrontController, error) {
 
 	encryption := encryption2.NewEncryptionUseCase(*logger)
 
-	authUseCase := auth.NewAuthUseCase()
 	broadcastUseCase := broadcast.NewBroadcastUseCase(*logger, encryption)
+	userRepository := user.NewUserRepository(*logger)
+	err = auth.BootstrapAdmin(userRepository, cfg.Auth.AdminPassword, cfg.Service.Environment == config.Development, *logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to bootstrap admin")
+	}
+	revocationRepository := auth.NewRevocationRepository(*logger)
+	sessionRepository := auth.NewSessionRepository(*logger)
+	organisationRepository := organisation.NewOrganisationRepository(*logger)
+	organisationUseCase := organisation.NewOrganisationUseCase(organisationRepository, userRepository, *logger)
+	apiKeyRepository := apikey.NewApiKeyRepository(*logger)
+	apiKeyUseCase := apikey.NewApiKeyUseCase(apiKeyRepository, userRepository, *logger)
+	authUseCase := auth.NewAuthUseCase(cfg.Auth, userRepository, revocationRepository, sessionRepository, apiKeyUseCase, organisationUseCase, broadcastUseCase, *logger)
+	userUseCase := user.NewUserUseCase(userRepository, organisationUseCase, authUseCase, *logger)
+
+	dataStore, err := store.NewStore(cfg.Storage, *logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open storage")
+	}
+
+	notificationUseCase := notification.NewNotificationUseCase(dataStore.Notifications(), authUseCase, broadcastUseCase, *logger)
+
+	var resetCodeSender domain.ResetCodeSender
+	switch cfg.Auth.ResetChannel {
+	case "mail":
+		resetCodeSender = auth.NewMailResetCodeSender(mail.NewSMTPMailer(cfg.Mail, *logger))
+	case "notification":
+		resetCodeSender = auth.NewNotificationResetCodeSender(notificationUseCase)
+	default:
+		return nil, errors.Errorf("unknown password reset channel: %s", cfg.Auth.ResetChannel)
+	}
+	passwordResetUseCase := auth.NewPasswordResetUseCase(userRepository, authUseCase, resetCodeSender, cfg.Auth.ResetCodeTTL, *logger)
+	loginGuard := auth.NewLoginGuard(cfg.Auth.Lockout, userRepository, notificationUseCase, *logger)
+
+	productRepository := dataStore.Products()
+	productUseCase := product.NewProductUseCase(cfg.Product, dataStore, notificationUseCase, organisationUseCase, *logger)
 
-	notificationRepository := notification.NewNotificationRepository(*logger)
-	notificationUseCase := notification.NewNotificationUseCase(notificationRepository, authUseCase, broadcastUseCase, *logger)
+	chatUseCase := chat.NewChatUseCase(dataStore.Chats(), authUseCase, broadcastUseCase, *logger)
 
-	productRepository := product.NewProductRepository(*logger)
-	productUseCase := product.NewProductUseCase(productRepository, notificationUseCase, *logger)
+	authorisationUseCase := authorisation.NewAuthorisationUseCase(authorisation.Policy, map[authorisation.Resource]authorisation.OwnerLookup{
+		authorisation.ProductResource: func(id string) (string, error) {
+			product, err := productRepository.Get(id)
+			if err != nil || product == nil {
+				return "", err
+			}
+			return product.SupplierId, nil
+		},
+	}, authUseCase, *logger)
 
-	chatRepository := chat.NewChatRepository(*logger)
-	chatUseCase := chat.NewChatUseCase(chatRepository, authUseCase, broadcastUseCase, *logger)
+	auditRepository := audit.NewAuditRepository(cfg.Audit, *logger)
+	auditUseCase := audit.NewAuditUseCase(auditRepository, *logger)
 
 	router := routerUc.NewRouterUseCase(*logger)
+	router.SetGuard(authorisation.NewRouterGuard(authorisationUseCase, authUseCase))
+	router.AddHook(audit.NewRouterHook(auditUseCase, authorisation.IsRoleGated))
 	product.NewProductHandler(router, productUseCase, authUseCase)
-	auth.NewAuthHandler(router, authUseCase)
+	auth.NewAuthHandler(router, authUseCase, passwordResetUseCase, loginGuard)
+	user.NewUserHandler(router, userUseCase)
+	apikey.NewApiKeyHandler(router, apiKeyUseCase)
+	organisation.NewOrganisationHandler(router, organisationUseCase)
+	audit.NewAuditHandler(router, auditUseCase)
 	broadcast.NewBroadcastHandler(router, broadcastUseCase, authUseCase)
 	notification.NewNotificationHandler(router, notificationUseCase, authUseCase)
 	chat.NewChatHandler(router, chatUseCase, authUseCase)
 
 	frontController := front_controller.NewFrontController(*router, encryption, broadcastUseCase)
 
-	cronManager := cron.NewCronManager(productUseCase, *logger)
+	cronManager := cron.NewCronManager(productUseCase, loginGuard, *logger)
 	cronManager.Start()
 
 	return frontController, nil
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/authorisation"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/encryption"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	productId  = "166e910e-49bd-4334-8522-3939cb7e3a90"
+	supplierId = "3975b95b-131a-44ce-973a-5b646bbaf70a"
+	customerId = "e891aca9-0fd7-49ed-9c2c-68587ce39728"
+)
+
+// newTestServer injects the whole server against a copy of internal/data, so
+// requests can't change the real data files.
+func newTestServer(t *testing.T) domain.FrontController {
+	dir := t.TempDir()
+	err := copyData("../data", filepath.Join(dir, "internal", "data"))
+	assert.NoError(t, err)
+
+	workingDir, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(workingDir) })
+
+	cfg := &config.Config{
+		Service: config.Service{LogLevel: "error"},
+		Auth: config.Auth{
+			TokenSecret:      "test",
+			AdminPassword:    "password",
+			AccessTokenTTL:   time.Minute,
+			RefreshTokenTTL:  time.Hour,
+			ResetChannel:     "notification",
+			ResetCodeTTL:     time.Minute,
+			ImpersonationTTL: time.Minute,
+			Lockout: config.Lockout{
+				MaxAttempts:        1000,
+				MaxAddressAttempts: 1000,
+				NotifyAfter:        1000,
+				Duration:           time.Minute,
+			},
+			TwoFactor: config.TwoFactor{Issuer: "DADES", ChallengeTTL: time.Minute},
+		},
+		Audit:   config.Audit{MaxFileSize: 64 * 1024, MaxFiles: 2},
+		Storage: config.Storage{BoltPath: filepath.Join(dir, "dades.db")},
+	}
+
+	frontController, err := Inject(cfg)
+	assert.NoError(t, err)
+	return frontController
+}
+
+func copyData(src string, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+
+		dat, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, dat, 0o600)
+	})
+}
+
+type testClient struct {
+	conn       net.Conn
+	encryption domain.EncryptionUseCase
+}
+
+func connect(frontController domain.FrontController) *testClient {
+	client, server := net.Pipe()
+	go frontController.HandleConnection(server)
+
+	return &testClient{
+		conn:       client,
+		encryption: encryption.NewEncryptionUseCase(*slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}
+}
+
+// send makes a request and returns the status code of the response.
+func (c *testClient) send(route string, method models.RequestType, token string, body interface{}, response interface{}) (int, error) {
+	request, err := json.Marshal(models.Request{
+		Route:   route,
+		Type:    method,
+		Body:    body,
+		Headers: map[string]string{"Authorization": token},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	encrypted, err := c.encryption.Encrypt(string(request))
+	if err != nil {
+		return 0, err
+	}
+
+	err = c.conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = c.conn.Write([]byte(encrypted))
+	if err != nil {
+		return 0, err
+	}
+
+	buffer := make([]byte, 1024*1024)
+	n, err := c.conn.Read(buffer)
+	if err != nil {
+		return 0, fmt.Errorf("no response to %s %s: %w", method, route, err)
+	}
+
+	decrypted, err := c.encryption.Decrypt(buffer[:n])
+	if err != nil {
+		return 0, err
+	}
+
+	var status struct {
+		StatusCode int `json:"statusCode"`
+	}
+	err = json.Unmarshal(decrypted, &status)
+	if err != nil {
+		return 0, err
+	}
+
+	if response != nil {
+		err = json.Unmarshal(decrypted, response)
+	}
+	return status.StatusCode, err
+}
+
+func (c *testClient) login(username string) (string, error) {
+	var response models.AuthResponse
+	statusCode, err := c.send("/auth", models.POST, "", models.AuthRequest{Username: username, Password: "password"}, &response)
+	if err != nil {
+		return "", err
+	}
+	if statusCode != 200 || response.UserClaim == nil {
+		return "", fmt.Errorf("failed to log in as %s: %d", username, statusCode)
+	}
+	return response.UserClaim.Token, nil
+}
+
+// TestServer_ConcurrentRequests calls every route in the authorisation policy
+// from many connections at once. Run it with -race to check shared state is
+// safely accessed.
+func TestServer_ConcurrentRequests(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping concurrent request test in short mode")
+	}
+
+	frontController := newTestServer(t)
+
+	// A body with every field the routes look at, so requests get past parsing
+	// and reach the repositories.
+	body := map[string]interface{}{
+		"id":           productId,
+		"productId":    productId,
+		"subType":      "daily",
+		"userId":       customerId,
+		"userIds":      []string{supplierId, customerId},
+		"participants": []string{supplierId, customerId},
+		"chatId":       productId,
+		"message":      "hello",
+		"name":         "Product",
+		"quantity":     10,
+		"pageNumber":   1,
+		"pageSize":     10,
+		"username":     "race",
+		"role":         "customer",
+	}
+
+	const workers = 8
+	const rounds = 2
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds*len(authorisation.Policy))
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			client := connect(frontController)
+			defer client.conn.Close()
+
+			username := []string{"admin", "TechUK", "LeedsGadgets"}[worker%3]
+			for round := 0; round < rounds; round++ {
+				// Some routes, such as logging out or revoking users, end the
+				// session, so log in again every round.
+				token, err := client.login(username)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				for key := range authorisation.Policy {
+					statusCode, err := client.send(key.Route, key.Method, token, body, nil)
+					if err != nil {
+						errs <- err
+						continue
+					}
+					if statusCode == 0 {
+						errs <- fmt.Errorf("%s %s as %s returned %d", key.Method, key.Route, username, statusCode)
+					}
+				}
+			}
+		}(worker)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// The data must still be readable once the dust has settled.
+	client := connect(frontController)
+	defer client.conn.Close()
+	token, err := client.login("admin")
+	assert.NoError(t, err)
+
+	var products models.ProductListResponse
+	statusCode, err := client.send("/product/all", models.GET, token, nil, &products)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, statusCode)
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+)
+
+// ReadJSON reads the file at path and unmarshals its contents into v.
+func ReadJSON(path string, v interface{}) error {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(dat, v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal %s", path)
+	}
+
+	return nil
+}
+
+// WriteJSON marshals v and atomically replaces the file at path with the result.
+// The data is written to a temporary file in the same directory, synced and then
+// renamed over the original so readers never observe a partially written file.
+func WriteJSON(path string, v interface{}) error {
+	dat, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file in %s", dir)
+	}
+	tmpName := tmp.Name()
+
+	_, err = tmp.Write(dat)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return errors.Wrapf(err, "failed to write %s", tmpName)
+	}
+
+	err = os.Rename(tmpName, path)
+	if err != nil {
+		os.Remove(tmpName)
+		return errors.Wrapf(err, "failed to replace %s", path)
+	}
+
+	return nil
+}
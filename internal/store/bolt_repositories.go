@@ -0,0 +1,811 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	bolt "go.etcd.io/bbolt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Every product can be subscribed to with each of these subscription types.
+var subscriptionTypes = []string{"hourly", "daily"}
+
+func get(bucket *bolt.Bucket, key []byte, v interface{}) (bool, error) {
+	dat := bucket.Get(key)
+	if dat == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(dat, v)
+}
+
+func put(bucket *bolt.Bucket, key []byte, v interface{}) error {
+	dat, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, dat)
+}
+
+type boltProductRepository struct {
+	boltRepository
+}
+
+func (p *boltProductRepository) Get(id string) (*models.Product, error) {
+	var product models.Product
+	var found bool
+	err := p.view(func(tx *bolt.Tx) error {
+		var err error
+		found, err = get(tx.Bucket(productsBucket), []byte(id), &product)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (p *boltProductRepository) GetAll() ([]models.Product, error) {
+	products := make([]models.Product, 0)
+	err := p.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(productsBucket).ForEach(func(_, dat []byte) error {
+			var product models.Product
+			err := json.Unmarshal(dat, &product)
+			if err != nil {
+				return err
+			}
+			products = append(products, product)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetAllByName walks the name index, which is keyed by lowercase name then ID.
+func (p *boltProductRepository) GetAllByName() ([]models.Product, error) {
+	products := make([]models.Product, 0)
+	err := p.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(productsBucket)
+		return tx.Bucket(productsByNameBucket).ForEach(func(_, id []byte) error {
+			var product models.Product
+			found, err := get(bucket, id, &product)
+			if err != nil {
+				return err
+			}
+			if found {
+				products = append(products, product)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetBySku walks the SKU index, which is keyed by supplier, lowercase SKU then ID.
+func (p *boltProductRepository) GetBySku(supplierId string, sku string) ([]models.Product, error) {
+	products := make([]models.Product, 0)
+	err := p.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(productsBucket)
+		prefix := indexKey(supplierId, strings.ToLower(sku), "")
+		cursor := tx.Bucket(productsBySkuBucket).Cursor()
+		for key, id := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, id = cursor.Next() {
+			var product models.Product
+			found, err := get(bucket, id, &product)
+			if err != nil {
+				return err
+			}
+			if found {
+				products = append(products, product)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func productNameKey(product models.Product) []byte {
+	return indexKey(strings.ToLower(product.Name), product.Id)
+}
+
+func productSkuKey(product models.Product) []byte {
+	return indexKey(product.SupplierId, strings.ToLower(product.Sku), product.Id)
+}
+
+// indexProduct adds the product to the name index, and to the SKU index if it
+// has a SKU.
+func indexProduct(tx *bolt.Tx, product models.Product) error {
+	err := tx.Bucket(productsByNameBucket).Put(productNameKey(product), []byte(product.Id))
+	if err != nil || product.Sku == "" {
+		return err
+	}
+	return tx.Bucket(productsBySkuBucket).Put(productSkuKey(product), []byte(product.Id))
+}
+
+// unindexProduct undoes indexProduct.
+func unindexProduct(tx *bolt.Tx, product models.Product) error {
+	err := tx.Bucket(productsByNameBucket).Delete(productNameKey(product))
+	if err != nil || product.Sku == "" {
+		return err
+	}
+	return tx.Bucket(productsBySkuBucket).Delete(productSkuKey(product))
+}
+
+// reindexProducts rebuilds the name and SKU indexes from the products bucket.
+func reindexProducts(tx *bolt.Tx) error {
+	return tx.Bucket(productsBucket).ForEach(func(id, dat []byte) error {
+		var product models.Product
+		err := json.Unmarshal(dat, &product)
+		if err != nil {
+			return err
+		}
+		return indexProduct(tx, product)
+	})
+}
+
+// Create adds the product, replacing any existing product with the same ID.
+func (p *boltProductRepository) Create(product models.Product) error {
+	p.Logger.Debug("Creating product: {product}", "product", product)
+	return p.update(func(tx *bolt.Tx) error {
+		err := p.unindex(tx, product.Id)
+		if err != nil {
+			return err
+		}
+
+		err = put(tx.Bucket(productsBucket), []byte(product.Id), product)
+		if err != nil {
+			return err
+		}
+
+		err = indexProduct(tx, product)
+		if err != nil {
+			return err
+		}
+
+		subscriptions := tx.Bucket(subscriptionsBucket)
+		for _, subType := range subscriptionTypes {
+			key := indexKey(product.Id, subType)
+			if subscriptions.Get(key) != nil {
+				continue
+			}
+
+			err = put(subscriptions, key, models.ProductSubscription{
+				ProductId: product.Id,
+				SubType:   subType,
+				Users:     make([]string, 0),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Update replaces the product if the stored product is still at version.
+func (p *boltProductRepository) Update(product models.Product, version int) error {
+	p.Logger.Debug("Updating product: {product}", "product", product)
+	return p.update(func(tx *bolt.Tx) error {
+		products := tx.Bucket(productsBucket)
+		var stored models.Product
+		found, err := get(products, []byte(product.Id), &stored)
+		if err != nil {
+			return err
+		}
+		if !found || stored.Version != version {
+			return domain.ErrVersionConflict
+		}
+
+		err = unindexProduct(tx, stored)
+		if err != nil {
+			return err
+		}
+
+		err = put(products, []byte(product.Id), product)
+		if err != nil {
+			return err
+		}
+		return indexProduct(tx, product)
+	})
+}
+
+// Delete removes the product. Its subscriptions are kept, so a product
+// recreated with the same ID keeps its subscribers.
+func (p *boltProductRepository) Delete(id string) error {
+	return p.update(func(tx *bolt.Tx) error {
+		err := p.unindex(tx, id)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(productsBucket).Delete([]byte(id))
+	})
+}
+
+// unindex removes the stored product from the name and SKU indexes.
+func (p *boltProductRepository) unindex(tx *bolt.Tx, id string) error {
+	var product models.Product
+	found, err := get(tx.Bucket(productsBucket), []byte(id), &product)
+	if err != nil || !found {
+		return err
+	}
+	return unindexProduct(tx, product)
+}
+
+func (p *boltProductRepository) Subscribe(productId string, subType string, userId string) error {
+	p.Logger.Info("subscribing user to product", "productId", productId, "userId", userId)
+	return p.updateSubscription(productId, subType, userId, func(users []string) []string {
+		if slices.Contains(users, userId) {
+			return users
+		}
+		return append(users, userId)
+	})
+}
+
+func (p *boltProductRepository) Unsubscribe(productId string, subType string, userId string) error {
+	p.Logger.Info("unsubscribing user to product", "productId", productId, "userId", userId)
+	return p.updateSubscription(productId, subType, userId, func(users []string) []string {
+		return slices.DeleteFunc(users, func(user string) bool {
+			return user == userId
+		})
+	})
+}
+
+// updateSubscription applies change to the subscription's users and keeps the
+// index of userId's subscriptions in step.
+func (p *boltProductRepository) updateSubscription(productId string, subType string, userId string, change func(users []string) []string) error {
+	return p.update(func(tx *bolt.Tx) error {
+		subscriptions := tx.Bucket(subscriptionsBucket)
+		key := indexKey(productId, subType)
+
+		var subscription models.ProductSubscription
+		found, err := get(subscriptions, key, &subscription)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("product subscription not found")
+		}
+
+		subscription.Users = change(subscription.Users)
+		err = put(subscriptions, key, subscription)
+		if err != nil {
+			return err
+		}
+
+		byUser := tx.Bucket(subscriptionsByUserBucket)
+		userKey := indexKey(userId, productId, subType)
+		if slices.Contains(subscription.Users, userId) {
+			return byUser.Put(userKey, []byte{})
+		}
+		return byUser.Delete(userKey)
+	})
+}
+
+func (p *boltProductRepository) GetSubscriptions(subType string) ([]models.ProductSubscription, error) {
+	var subscriptions []models.ProductSubscription
+	err := p.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(subscriptionsBucket).ForEach(func(_, dat []byte) error {
+			var subscription models.ProductSubscription
+			err := json.Unmarshal(dat, &subscription)
+			if err != nil {
+				return err
+			}
+			if subscription.SubType == subType {
+				subscriptions = append(subscriptions, subscription)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (p *boltProductRepository) GetSubscriptionsByUser(userId string) ([]models.ProductSubscription, error) {
+	var subscriptions []models.ProductSubscription
+	err := p.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(subscriptionsBucket)
+		prefix := indexKey(userId, "")
+		cursor := tx.Bucket(subscriptionsByUserBucket).Cursor()
+		for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+			var subscription models.ProductSubscription
+			found, err := get(bucket, key[len(prefix):], &subscription)
+			if err != nil {
+				return err
+			}
+			if found {
+				subscriptions = append(subscriptions, subscription)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (p *boltProductRepository) GetProductSubscribers(productId string) ([]string, error) {
+	var users []string
+	err := p.view(func(tx *bolt.Tx) error {
+		for _, subType := range subscriptionTypes {
+			var subscription models.ProductSubscription
+			_, err := get(tx.Bucket(subscriptionsBucket), indexKey(productId, subType), &subscription)
+			if err != nil {
+				return err
+			}
+			for _, userId := range subscription.Users {
+				if !slices.Contains(users, userId) {
+					users = append(users, userId)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// boltStockRepository keys each movement by its product and a sequence number,
+// so a product's movements are stored together in the order they were added.
+type boltStockRepository struct {
+	boltRepository
+}
+
+func (s *boltStockRepository) Add(movement models.StockMovement) error {
+	s.Logger.Info("recording stock movement", "productId", movement.ProductId, "type", movement.Type, "delta", movement.Delta)
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(stockBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		return put(bucket, binary.BigEndian.AppendUint64(indexKey(movement.ProductId, ""), seq), movement)
+	})
+}
+
+func (s *boltStockRepository) Latest(productId string) (*models.StockMovement, error) {
+	var movement *models.StockMovement
+	err := s.view(func(tx *bolt.Tx) error {
+		prefix := indexKey(productId, "")
+		cursor := tx.Bucket(stockBucket).Cursor()
+
+		// The first key after the product's movements, as the separator sorts
+		// before every other byte.
+		key, dat := cursor.Seek([]byte(productId + "\x01"))
+		if key == nil {
+			key, dat = cursor.Last()
+		} else {
+			key, dat = cursor.Prev()
+		}
+		if key == nil || !bytes.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		movement = &models.StockMovement{}
+		return json.Unmarshal(dat, movement)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+func (s *boltStockRepository) GetByProduct(productId string, from *time.Time, to *time.Time) ([]models.StockMovement, error) {
+	movements := make([]models.StockMovement, 0)
+	err := s.view(func(tx *bolt.Tx) error {
+		prefix := indexKey(productId, "")
+		cursor := tx.Bucket(stockBucket).Cursor()
+		for key, dat := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, dat = cursor.Next() {
+			var movement models.StockMovement
+			err := json.Unmarshal(dat, &movement)
+			if err != nil {
+				return err
+			}
+			if from != nil && movement.Time.Before(*from) {
+				continue
+			}
+			if to != nil && !movement.Time.Before(*to) {
+				break
+			}
+			movements = append(movements, movement)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// boltThresholdRepository keys thresholds by product and then user, with an
+// index by user and then product.
+type boltThresholdRepository struct {
+	boltRepository
+}
+
+func (t *boltThresholdRepository) Save(threshold models.UserThreshold) error {
+	return t.update(func(tx *bolt.Tx) error {
+		err := put(tx.Bucket(thresholdsBucket), indexKey(threshold.ProductId, threshold.UserId), threshold)
+		if err != nil {
+			return err
+		}
+		return indexThreshold(tx, threshold)
+	})
+}
+
+func indexThreshold(tx *bolt.Tx, threshold models.UserThreshold) error {
+	return tx.Bucket(thresholdsByUserBucket).Put(indexKey(threshold.UserId, threshold.ProductId), []byte(threshold.ProductId))
+}
+
+// reindexThresholds rebuilds the user index from the thresholds bucket.
+func reindexThresholds(tx *bolt.Tx) error {
+	return tx.Bucket(thresholdsBucket).ForEach(func(_, dat []byte) error {
+		var threshold models.UserThreshold
+		err := json.Unmarshal(dat, &threshold)
+		if err != nil {
+			return err
+		}
+		return indexThreshold(tx, threshold)
+	})
+}
+
+func (t *boltThresholdRepository) Delete(userId string, productId string) error {
+	return t.update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(thresholdsBucket).Delete(indexKey(productId, userId))
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(thresholdsByUserBucket).Delete(indexKey(userId, productId))
+	})
+}
+
+func (t *boltThresholdRepository) GetByProduct(productId string) ([]models.UserThreshold, error) {
+	thresholds := make([]models.UserThreshold, 0)
+	err := t.view(func(tx *bolt.Tx) error {
+		prefix := indexKey(productId, "")
+		cursor := tx.Bucket(thresholdsBucket).Cursor()
+		for key, dat := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, dat = cursor.Next() {
+			var threshold models.UserThreshold
+			err := json.Unmarshal(dat, &threshold)
+			if err != nil {
+				return err
+			}
+			thresholds = append(thresholds, threshold)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return thresholds, nil
+}
+
+func (t *boltThresholdRepository) GetByUser(userId string) ([]models.UserThreshold, error) {
+	thresholds := make([]models.UserThreshold, 0)
+	err := t.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(thresholdsBucket)
+		prefix := indexKey(userId, "")
+		cursor := tx.Bucket(thresholdsByUserBucket).Cursor()
+		for key, productId := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, productId = cursor.Next() {
+			var threshold models.UserThreshold
+			found, err := get(bucket, indexKey(string(productId), userId), &threshold)
+			if err != nil {
+				return err
+			}
+			if found {
+				thresholds = append(thresholds, threshold)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return thresholds, nil
+}
+
+// Held reservations are indexed by when they expire. The time is formatted
+// with a fixed width so keys sort in time order.
+const reservationExpiryFormat = "2006-01-02T15:04:05.000000000"
+
+func reservationExpiryKey(t time.Time) string {
+	return t.UTC().Format(reservationExpiryFormat)
+}
+
+// boltReservationRepository keys reservations by ID, with indexes by product,
+// by user and, while held, by expiry. Lists are sorted by when the
+// reservation was made.
+type boltReservationRepository struct {
+	boltRepository
+}
+
+func (r *boltReservationRepository) Get(id string) (*models.Reservation, error) {
+	var reservation models.Reservation
+	var found bool
+	err := r.view(func(tx *bolt.Tx) error {
+		var err error
+		found, err = get(tx.Bucket(reservationsBucket), []byte(id), &reservation)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Save stores the reservation, moving its index entries from those of the
+// reservation it replaces.
+func (r *boltReservationRepository) Save(reservation models.Reservation) error {
+	r.Logger.Info("saving reservation", "id", reservation.Id, "productId", reservation.ProductId, "status", reservation.Status)
+	return r.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reservationsBucket)
+
+		var previous models.Reservation
+		found, err := get(bucket, []byte(reservation.Id), &previous)
+		if err != nil {
+			return err
+		}
+		if found {
+			err = unindexReservation(tx, previous)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = put(bucket, []byte(reservation.Id), reservation)
+		if err != nil {
+			return err
+		}
+		return indexReservation(tx, reservation)
+	})
+}
+
+func indexReservation(tx *bolt.Tx, reservation models.Reservation) error {
+	id := []byte(reservation.Id)
+	err := tx.Bucket(reservationsByProductBucket).Put(indexKey(reservation.ProductId, reservation.Id), id)
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket(reservationsByUserBucket).Put(indexKey(reservation.UserId, reservation.Id), id)
+	if err != nil || reservation.Status != models.ReservationHeld {
+		return err
+	}
+	return tx.Bucket(reservationsByExpiryBucket).Put(indexKey(reservationExpiryKey(reservation.ExpiresAt), reservation.Id), id)
+}
+
+func unindexReservation(tx *bolt.Tx, reservation models.Reservation) error {
+	err := tx.Bucket(reservationsByProductBucket).Delete(indexKey(reservation.ProductId, reservation.Id))
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket(reservationsByUserBucket).Delete(indexKey(reservation.UserId, reservation.Id))
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(reservationsByExpiryBucket).Delete(indexKey(reservationExpiryKey(reservation.ExpiresAt), reservation.Id))
+}
+
+// reindexReservations rebuilds the product, user and expiry indexes from the
+// reservations bucket.
+func reindexReservations(tx *bolt.Tx) error {
+	return tx.Bucket(reservationsBucket).ForEach(func(_, dat []byte) error {
+		var reservation models.Reservation
+		err := json.Unmarshal(dat, &reservation)
+		if err != nil {
+			return err
+		}
+		return indexReservation(tx, reservation)
+	})
+}
+
+func (r *boltReservationRepository) GetByProduct(productId string) ([]models.Reservation, error) {
+	return r.scan(reservationsByProductBucket, indexKey(productId, ""), func([]byte) bool { return true })
+}
+
+func (r *boltReservationRepository) GetByUser(userId string) ([]models.Reservation, error) {
+	return r.scan(reservationsByUserBucket, indexKey(userId, ""), func([]byte) bool { return true })
+}
+
+// GetExpired walks the expiry index from the earliest expiry up to t.
+func (r *boltReservationRepository) GetExpired(t time.Time) ([]models.Reservation, error) {
+	limit := []byte(reservationExpiryKey(t))
+	return r.scan(reservationsByExpiryBucket, nil, func(key []byte) bool {
+		expiry, _, _ := bytes.Cut(key, []byte(keySeparator))
+		return bytes.Compare(expiry, limit) <= 0
+	})
+}
+
+// scan returns the reservations in the index bucket under prefix, stopping at
+// the first key for which more returns false.
+func (r *boltReservationRepository) scan(index []byte, prefix []byte, more func(key []byte) bool) ([]models.Reservation, error) {
+	reservations := make([]models.Reservation, 0)
+	err := r.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reservationsBucket)
+		cursor := tx.Bucket(index).Cursor()
+		for key, id := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix) && more(key); key, id = cursor.Next() {
+			var reservation models.Reservation
+			found, err := get(bucket, id, &reservation)
+			if err != nil {
+				return err
+			}
+			if found {
+				reservations = append(reservations, reservation)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sortReservations(reservations), nil
+}
+
+// sortReservations orders reservations by when they were made.
+func sortReservations(reservations []models.Reservation) []models.Reservation {
+	slices.SortFunc(reservations, func(a, b models.Reservation) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+	return reservations
+}
+
+type boltChatRepository struct {
+	boltRepository
+}
+
+func (c *boltChatRepository) GetAllChatThumbnails() ([]models.ChatThumbnail, error) {
+	chatThumbnails := make([]models.ChatThumbnail, 0)
+	err := c.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(chatsBucket).ForEach(func(_, dat []byte) error {
+			var chat models.Chat
+			err := json.Unmarshal(dat, &chat)
+			if err != nil {
+				return err
+			}
+
+			var message *models.Message
+			if len(chat.Messages) != 0 {
+				message = &chat.Messages[len(chat.Messages)-1]
+			}
+
+			chatThumbnails = append(chatThumbnails, models.ChatThumbnail{
+				ChatId:       chat.Id,
+				LastMessage:  message,
+				Participants: chat.Participants,
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chatThumbnails, nil
+}
+
+func (c *boltChatRepository) GetChat(chatId string) (*models.Chat, error) {
+	var chat models.Chat
+	var found bool
+	err := c.view(func(tx *bolt.Tx) error {
+		var err error
+		found, err = get(tx.Bucket(chatsBucket), []byte(chatId), &chat)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+func (c *boltChatRepository) CreateChat(chat models.Chat) error {
+	c.Logger.Info("creating chat", "chat", chat)
+	return c.update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(chatsBucket), []byte(chat.Id), chat)
+	})
+}
+
+func (c *boltChatRepository) AddMessage(chatId string, message models.Message) error {
+	c.Logger.Info("adding message to chat", "chatId", chatId, "message", message)
+	return c.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(chatsBucket)
+
+		var chat models.Chat
+		found, err := get(bucket, []byte(chatId), &chat)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("chat not found")
+		}
+
+		chat.Messages = append(chat.Messages, message)
+		return put(bucket, []byte(chatId), chat)
+	})
+}
+
+type boltNotificationRepository struct {
+	boltRepository
+}
+
+func (n *boltNotificationRepository) Get(userId string) ([]models.Notification, error) {
+	notifs := make([]models.Notification, 0)
+	err := n.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(notificationsBucket)
+		prefix := indexKey(userId, "")
+		cursor := tx.Bucket(notificationsByUserBucket).Cursor()
+		for key, id := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, id = cursor.Next() {
+			var notif models.Notification
+			found, err := get(bucket, id, &notif)
+			if err != nil {
+				return err
+			}
+			if found {
+				notifs = append(notifs, notif)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notifs, nil
+}
+
+// Add stores the notification and indexes it under its user by a sequence
+// number, so a user's notifications are listed in the order they were added.
+func (n *boltNotificationRepository) Add(notification models.Notification) error {
+	n.Logger.Info("adding notification", "notification", notification)
+	return n.update(func(tx *bolt.Tx) error {
+		err := put(tx.Bucket(notificationsBucket), []byte(notification.Id), notification)
+		if err != nil {
+			return err
+		}
+
+		byUser := tx.Bucket(notificationsByUserBucket)
+		seq, err := byUser.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		key := binary.BigEndian.AppendUint64(indexKey(notification.UserId, ""), seq)
+		return byUser.Put(key, []byte(notification.Id))
+	})
+}
+
+func (n *boltNotificationRepository) Delete(userId string, notificationId string) error {
+	n.Logger.Info("deleting notification", "userId", userId, "notificationId", notificationId)
+	return n.update(func(tx *bolt.Tx) error {
+		prefix := indexKey(userId, "")
+		cursor := tx.Bucket(notificationsByUserBucket).Cursor()
+		for key, id := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, id = cursor.Next() {
+			if string(id) != notificationId {
+				continue
+			}
+
+			err := cursor.Delete()
+			if err != nil {
+				return err
+			}
+			return tx.Bucket(notificationsBucket).Delete([]byte(notificationId))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"log/slog"
+	"time"
+)
+
+var (
+	productsBucket              = []byte("products")
+	productsByNameBucket        = []byte("productsByName")
+	productsBySkuBucket         = []byte("productsBySku")
+	stockBucket                 = []byte("stock")
+	thresholdsBucket            = []byte("thresholds")
+	thresholdsByUserBucket      = []byte("thresholdsByUser")
+	reservationsBucket          = []byte("reservations")
+	reservationsByProductBucket = []byte("reservationsByProduct")
+	reservationsByUserBucket    = []byte("reservationsByUser")
+	reservationsByExpiryBucket  = []byte("reservationsByExpiry")
+	subscriptionsBucket         = []byte("subscriptions")
+	subscriptionsByUserBucket   = []byte("subscriptionsByUser")
+	chatsBucket                 = []byte("chats")
+	notificationsBucket         = []byte("notifications")
+	notificationsByUserBucket   = []byte("notificationsByUser")
+
+	buckets = [][]byte{
+		productsBucket, productsByNameBucket, productsBySkuBucket, stockBucket, thresholdsBucket, thresholdsByUserBucket,
+		reservationsBucket, reservationsByProductBucket, reservationsByUserBucket, reservationsByExpiryBucket,
+		subscriptionsBucket, subscriptionsByUserBucket, chatsBucket, notificationsBucket, notificationsByUserBucket,
+	}
+)
+
+// Separates the parts of composite index keys. IDs never contain it.
+const keySeparator = "\x00"
+
+// BoltStore keeps every entity as JSON in an embedded bolt database. Entities are
+// keyed by ID, with index buckets for looking them up by user, product, name,
+// SKU or expiry.
+type BoltStore struct {
+	Logger slog.Logger
+	db     *bolt.DB
+}
+
+func OpenBoltStore(path string, logger slog.Logger) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		indexProducts := tx.Bucket(productsByNameBucket) == nil || tx.Bucket(productsBySkuBucket) == nil
+		indexThresholds := tx.Bucket(thresholdsByUserBucket) == nil
+		indexReservations := tx.Bucket(reservationsByExpiryBucket) == nil
+		for _, bucket := range buckets {
+			_, err := tx.CreateBucketIfNotExists(bucket)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Databases created before an index existed need it building.
+		if indexProducts {
+			err := reindexProducts(tx)
+			if err != nil {
+				return err
+			}
+		}
+		if indexThresholds {
+			err := reindexThresholds(tx)
+			if err != nil {
+				return err
+			}
+		}
+		if indexReservations {
+			return reindexReservations(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrapf(err, "failed to create buckets in %s", path)
+	}
+
+	return &BoltStore{
+		Logger: logger,
+		db:     db,
+	}, nil
+}
+
+func (s *BoltStore) Products() domain.ProductRepository {
+	return &boltProductRepository{boltRepository{Logger: s.Logger, db: s.db}}
+}
+
+func (s *BoltStore) Stock() domain.StockRepository {
+	return &boltStockRepository{boltRepository{Logger: s.Logger, db: s.db}}
+}
+
+func (s *BoltStore) Thresholds() domain.ThresholdRepository {
+	return &boltThresholdRepository{boltRepository{Logger: s.Logger, db: s.db}}
+}
+
+func (s *BoltStore) Reservations() domain.ReservationRepository {
+	return &boltReservationRepository{boltRepository{Logger: s.Logger, db: s.db}}
+}
+
+func (s *BoltStore) Chats() domain.ChatRepository {
+	return &boltChatRepository{boltRepository{Logger: s.Logger, db: s.db}}
+}
+
+func (s *BoltStore) Notifications() domain.NotificationRepository {
+	return &boltNotificationRepository{boltRepository{Logger: s.Logger, db: s.db}}
+}
+
+func (s *BoltStore) Transaction(fn func(tx domain.Tx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{logger: s.Logger, tx: tx})
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+type boltTx struct {
+	logger slog.Logger
+	tx     *bolt.Tx
+}
+
+func (t boltTx) Products() domain.ProductRepository {
+	return &boltProductRepository{boltRepository{Logger: t.logger, tx: t.tx}}
+}
+
+func (t boltTx) Stock() domain.StockRepository {
+	return &boltStockRepository{boltRepository{Logger: t.logger, tx: t.tx}}
+}
+
+func (t boltTx) Thresholds() domain.ThresholdRepository {
+	return &boltThresholdRepository{boltRepository{Logger: t.logger, tx: t.tx}}
+}
+
+func (t boltTx) Reservations() domain.ReservationRepository {
+	return &boltReservationRepository{boltRepository{Logger: t.logger, tx: t.tx}}
+}
+
+func (t boltTx) Chats() domain.ChatRepository {
+	return &boltChatRepository{boltRepository{Logger: t.logger, tx: t.tx}}
+}
+
+func (t boltTx) Notifications() domain.NotificationRepository {
+	return &boltNotificationRepository{boltRepository{Logger: t.logger, tx: t.tx}}
+}
+
+// boltRepository runs each call in its own transaction, or in tx when the
+// repository belongs to a wider transaction.
+type boltRepository struct {
+	Logger slog.Logger
+	db     *bolt.DB
+	tx     *bolt.Tx
+}
+
+func (r boltRepository) view(fn func(tx *bolt.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.db.View(fn)
+}
+
+func (r boltRepository) update(fn func(tx *bolt.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.db.Update(fn)
+}
+
+func indexKey(parts ...string) []byte {
+	key := ""
+	for i, part := range parts {
+		if i > 0 {
+			key += keySeparator
+		}
+		key += part
+	}
+	return []byte(key)
+}
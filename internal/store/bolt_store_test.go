@@ -0,0 +1,469 @@
+package store
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/internal/notification"
+	"github.com/kkcaz/shu-dades-server/internal/product"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestBoltStore(t *testing.T) *BoltStore {
+	store, err := OpenBoltStore(filepath.Join(t.TempDir(), "test.db"), *slog.Default())
+	assert.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestBoltStore_Repositories(t *testing.T) {
+	store := newTestBoltStore(t)
+	products := store.Products()
+
+	assert.NoError(t, products.Create(models.Product{Id: "1", Name: "iPhone 15", Quantity: 10}))
+	assert.NoError(t, products.Subscribe("1", "daily", "john"))
+	assert.NoError(t, products.Subscribe("1", "hourly", "jane"))
+
+	product, err := products.Get("1")
+	assert.NoError(t, err)
+	assert.Equal(t, "iPhone 15", product.Name)
+
+	assert.NoError(t, products.Create(models.Product{Id: "2", Name: "apple Watch"}))
+	assert.NoError(t, products.Create(models.Product{Id: "3", Name: "Xbox"}))
+	assert.NoError(t, products.Create(models.Product{Id: "3", Name: "Playstation 5"}))
+	assert.NoError(t, products.Delete("2"))
+	byName, err := products.GetAllByName()
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Product{
+		{Id: "1", Name: "iPhone 15", Quantity: 10},
+		{Id: "3", Name: "Playstation 5"},
+	}, byName)
+
+	assert.NoError(t, products.Update(models.Product{Id: "3", Name: "Playstation 5 Pro", Version: 1}, 0))
+	assert.ErrorIs(t, products.Update(models.Product{Id: "3", Name: "Playstation 5", Version: 1}, 0), domain.ErrVersionConflict)
+	assert.ErrorIs(t, products.Update(models.Product{Id: "missing", Version: 1}, 0), domain.ErrVersionConflict)
+	byName, err = products.GetAllByName()
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Product{
+		{Id: "1", Name: "iPhone 15", Quantity: 10},
+		{Id: "3", Name: "Playstation 5 Pro", Version: 1},
+	}, byName)
+
+	product, err = products.Get("missing")
+	assert.NoError(t, err)
+	assert.Nil(t, product)
+
+	subscriptions, err := products.GetSubscriptionsByUser("john")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ProductSubscription{{ProductId: "1", SubType: "daily", Users: []string{"john"}}}, subscriptions)
+
+	subscribers, err := products.GetProductSubscribers("1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"jane", "john"}, subscribers)
+
+	assert.NoError(t, products.Unsubscribe("1", "daily", "john"))
+	subscriptions, err = products.GetSubscriptionsByUser("john")
+	assert.NoError(t, err)
+	assert.Empty(t, subscriptions)
+	assert.Error(t, products.Subscribe("missing", "daily", "john"))
+
+	notifications := store.Notifications()
+	for _, notification := range []models.Notification{
+		{Id: "b", UserId: "john", Message: "first"},
+		{Id: "a", UserId: "john", Message: "second"},
+		{Id: "c", UserId: "johnny", Message: "other"},
+	} {
+		assert.NoError(t, notifications.Add(notification))
+	}
+
+	notifs, err := notifications.Get("john")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Notification{
+		{Id: "b", UserId: "john", Message: "first"},
+		{Id: "a", UserId: "john", Message: "second"},
+	}, notifs)
+
+	assert.NoError(t, notifications.Delete("johnny", "b"))
+	assert.NoError(t, notifications.Delete("john", "b"))
+	notifs, err = notifications.Get("john")
+	assert.NoError(t, err)
+	assert.Len(t, notifs, 1)
+
+	chats := store.Chats()
+	assert.NoError(t, chats.CreateChat(models.Chat{Id: "chat", Participants: []models.Participant{{UserId: "john"}}}))
+	assert.NoError(t, chats.AddMessage("chat", models.Message{Content: "hello"}))
+	assert.Error(t, chats.AddMessage("missing", models.Message{Content: "hello"}))
+
+	thumbnails, err := chats.GetAllChatThumbnails()
+	assert.NoError(t, err)
+	assert.Len(t, thumbnails, 1)
+	assert.Equal(t, "hello", thumbnails[0].LastMessage.Content)
+}
+
+func TestBoltStore_SkuIndex(t *testing.T) {
+	store := newTestBoltStore(t)
+	products := store.Products()
+
+	assert.NoError(t, products.Create(models.Product{Id: "1", Sku: "IP-15", SupplierId: "apple"}))
+	assert.NoError(t, products.Create(models.Product{Id: "2", Sku: "ip-15", SupplierId: "samsung"}))
+	assert.NoError(t, products.Create(models.Product{Id: "3", Sku: "ip-15-pro", SupplierId: "apple"}))
+
+	// SKUs are matched in full, so one that starts with another isn't found.
+	bySku, err := products.GetBySku("apple", "Ip-15")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Product{{Id: "1", Sku: "IP-15", SupplierId: "apple"}}, bySku)
+
+	assert.NoError(t, products.Update(models.Product{Id: "1", Sku: "IP-16", SupplierId: "apple", Version: 1}, 0))
+	bySku, err = products.GetBySku("apple", "ip-15")
+	assert.NoError(t, err)
+	assert.Empty(t, bySku)
+	bySku, err = products.GetBySku("apple", "ip-16")
+	assert.NoError(t, err)
+	assert.Len(t, bySku, 1)
+
+	assert.NoError(t, products.Delete("2"))
+	bySku, err = products.GetBySku("samsung", "ip-15")
+	assert.NoError(t, err)
+	assert.Empty(t, bySku)
+}
+
+func TestBoltStore_Transaction(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{
+			name:     "Happy path",
+			expected: 1,
+		},
+		{
+			name:     "Sad path - rolled back",
+			err:      assert.AnError,
+			expected: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newTestBoltStore(t)
+
+			err := store.Transaction(func(tx domain.Tx) error {
+				err := tx.Products().Create(models.Product{Id: "1", Quantity: 5})
+				if err != nil {
+					return err
+				}
+
+				err = tx.Notifications().Add(models.Notification{Id: "n", UserId: "john", Message: "stock changed"})
+				if err != nil {
+					return err
+				}
+				return tc.err
+			})
+			assert.ErrorIs(t, err, tc.err)
+
+			products, err := store.Products().GetAll()
+			assert.NoError(t, err)
+			assert.Len(t, products, tc.expected)
+
+			notifs, err := store.Notifications().Get("john")
+			assert.NoError(t, err)
+			assert.Len(t, notifs, tc.expected)
+		})
+	}
+}
+
+// failingNotifications fails every notification written through a transaction.
+type failingNotifications struct {
+	*BoltStore
+}
+
+type failingNotificationsTx struct {
+	domain.Tx
+}
+
+type failingNotificationRepository struct {
+	domain.NotificationRepository
+}
+
+func (s failingNotifications) Transaction(fn func(tx domain.Tx) error) error {
+	return s.BoltStore.Transaction(func(tx domain.Tx) error {
+		return fn(failingNotificationsTx{tx})
+	})
+}
+
+func (t failingNotificationsTx) Notifications() domain.NotificationRepository {
+	return failingNotificationRepository{t.Tx.Notifications()}
+}
+
+func (failingNotificationRepository) Add(notification models.Notification) error {
+	return assert.AnError
+}
+
+func TestBoltStore_StockAndNotificationsCommitTogether(t *testing.T) {
+	threshold := 5
+	testCases := []struct {
+		name              string
+		failNotification  bool
+		expectedQuantity  int
+		expectedMovements int
+		expectedNotices   int
+	}{
+		{
+			name:             "Happy path",
+			expectedQuantity: 4,
+			// The opening balance and the sale.
+			expectedMovements: 2,
+			expectedNotices:   1,
+		},
+		{
+			name:             "Sad path - notification fails",
+			failNotification: true,
+			expectedQuantity: 10,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newTestBoltStore(t)
+			assert.NoError(t, store.Products().Create(models.Product{Id: "1", Name: "iPhone 15", Quantity: 10, SupplierId: "supplier", ReorderThreshold: &threshold, Version: 1}))
+
+			organisations := mocks.NewOrganisationUseCase(t)
+			organisations.On("GetScope", "supplier").Return(&models.TenantScope{UserId: "supplier", All: true}, nil)
+			broadcast := mocks.NewBroadcastUseCase(t)
+			notifications := notification.NewNotificationUseCase(store.Notifications(), nil, broadcast, *slog.Default())
+
+			var productStore domain.Store = store
+			if tc.failNotification {
+				productStore = failingNotifications{store}
+			} else {
+				broadcast.On("PublishToUsers", "Product iPhone 15 is low on stock with 4 quantity remaining", "notification", []string{"supplier"}).Return(nil)
+			}
+			productUc := product.NewProductUseCase(config.Product{}, productStore, notifications, organisations, *slog.Default())
+
+			_, err := productUc.MoveStock("supplier", models.StockMovementRequest{Id: "1", Type: models.Sell, Quantity: 6})
+			if tc.failNotification {
+				assert.ErrorIs(t, err, assert.AnError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			saved, err := store.Products().Get("1")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedQuantity, saved.Quantity)
+			assert.Equal(t, tc.expectedNotices > 0, saved.LowStock)
+
+			movements, err := store.Stock().GetByProduct("1", nil, nil)
+			assert.NoError(t, err)
+			assert.Len(t, movements, tc.expectedMovements)
+
+			notices, err := store.Notifications().Get("supplier")
+			assert.NoError(t, err)
+			assert.Len(t, notices, tc.expectedNotices)
+		})
+	}
+}
+
+func TestBoltStore_Stock(t *testing.T) {
+	stock := newTestBoltStore(t).Stock()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, productId := range []string{"1", "1", "10", "1", "0"} {
+		assert.NoError(t, stock.Add(models.StockMovement{Id: fmt.Sprint(i), ProductId: productId, Time: start.Add(time.Duration(i) * time.Hour)}))
+	}
+
+	latest, err := stock.Latest("1")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", latest.Id)
+
+	latest, err = stock.Latest("10")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", latest.Id)
+
+	latest, err = stock.Latest("2")
+	assert.NoError(t, err)
+	assert.Nil(t, latest)
+
+	from := start.Add(time.Hour)
+	movements, err := stock.GetByProduct("1", &from, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "3"}, []string{movements[0].Id, movements[1].Id})
+
+	to := start.Add(time.Hour)
+	movements, err = stock.GetByProduct("1", nil, &to)
+	assert.NoError(t, err)
+	assert.Len(t, movements, 1)
+}
+
+func TestBoltStore_Thresholds(t *testing.T) {
+	thresholds := newTestBoltStore(t).Thresholds()
+
+	assert.NoError(t, thresholds.Save(models.UserThreshold{UserId: "john", ProductId: "1", Threshold: 5}))
+	assert.NoError(t, thresholds.Save(models.UserThreshold{UserId: "john", ProductId: "1", Threshold: 3, Triggered: true}))
+	assert.NoError(t, thresholds.Save(models.UserThreshold{UserId: "jane", ProductId: "1", Threshold: 2}))
+	assert.NoError(t, thresholds.Save(models.UserThreshold{UserId: "john", ProductId: "10", Threshold: 1}))
+
+	byProduct, err := thresholds.GetByProduct("1")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.UserThreshold{
+		{UserId: "jane", ProductId: "1", Threshold: 2},
+		{UserId: "john", ProductId: "1", Threshold: 3, Triggered: true},
+	}, byProduct)
+
+	assert.NoError(t, thresholds.Delete("john", "1"))
+	byUser, err := thresholds.GetByUser("john")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.UserThreshold{{UserId: "john", ProductId: "10", Threshold: 1}}, byUser)
+}
+
+func TestBoltStore_Reservations(t *testing.T) {
+	reservations := newTestBoltStore(t).Reservations()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, reservation := range []models.Reservation{
+		{Id: "b", ProductId: "1", UserId: "john", Status: models.ReservationHeld, ExpiresAt: start.Add(time.Hour)},
+		{Id: "a", ProductId: "1", UserId: "jane", Status: models.ReservationHeld, ExpiresAt: start.Add(2 * time.Hour)},
+		{Id: "c", ProductId: "2", UserId: "john", Status: models.ReservationReleased, ExpiresAt: start},
+	} {
+		reservation.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		assert.NoError(t, reservations.Save(reservation))
+	}
+
+	reservation, err := reservations.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "jane", reservation.UserId)
+
+	reservation, err = reservations.Get("missing")
+	assert.NoError(t, err)
+	assert.Nil(t, reservation)
+
+	byProduct, err := reservations.GetByProduct("1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, []string{byProduct[0].Id, byProduct[1].Id})
+
+	byUser, err := reservations.GetByUser("john")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, []string{byUser[0].Id, byUser[1].Id})
+
+	expired, err := reservations.GetExpired(start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Reservation{byProduct[0]}, expired)
+
+	byProduct[0].Status = models.ReservationExpired
+	assert.NoError(t, reservations.Save(byProduct[0]))
+	expired, err = reservations.GetExpired(start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, expired)
+
+	// Saving a reservation moves it out of the indexes it no longer belongs in.
+	byProduct[1].UserId = "john"
+	byProduct[1].ExpiresAt = start.Add(3 * time.Hour)
+	assert.NoError(t, reservations.Save(byProduct[1]))
+	byUser, err = reservations.GetByUser("jane")
+	assert.NoError(t, err)
+	assert.Empty(t, byUser)
+	expired, err = reservations.GetExpired(start.Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, expired)
+	expired, err = reservations.GetExpired(start.Add(3 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, []string{expired[0].Id})
+}
+
+func TestBoltStore_ReindexesOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := OpenBoltStore(path, *slog.Default())
+	assert.NoError(t, err)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, store.Thresholds().Save(models.UserThreshold{UserId: "john", ProductId: "1", Threshold: 5}))
+	assert.NoError(t, store.Reservations().Save(models.Reservation{Id: "r", ProductId: "1", UserId: "john", Status: models.ReservationHeld, ExpiresAt: start}))
+
+	// Drop the indexes, as in a database made before they existed.
+	err = store.db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{thresholdsByUserBucket, reservationsByProductBucket, reservationsByUserBucket, reservationsByExpiryBucket} {
+			err := tx.DeleteBucket(bucket)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Close())
+
+	store, err = OpenBoltStore(path, *slog.Default())
+	assert.NoError(t, err)
+	defer store.Close()
+
+	thresholds, err := store.Thresholds().GetByUser("john")
+	assert.NoError(t, err)
+	assert.Len(t, thresholds, 1)
+	reservations, err := store.Reservations().GetByProduct("1")
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 1)
+	reservations, err = store.Reservations().GetByUser("john")
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 1)
+	reservations, err = store.Reservations().GetExpired(start)
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 1)
+}
+
+func TestBoltStore_ImportExport(t *testing.T) {
+	source := newTestBoltStore(t)
+	assert.NoError(t, source.Products().Create(models.Product{Id: "1", Name: "iPhone 15", Quantity: 10}))
+	assert.NoError(t, source.Products().Subscribe("1", "daily", "john"))
+	assert.NoError(t, source.Stock().Add(models.StockMovement{Id: "m", ProductId: "1", Type: models.Receive, Delta: 10, Quantity: 10}))
+	assert.NoError(t, source.Reservations().Save(models.Reservation{Id: "r", ProductId: "1", UserId: "john", Quantity: 2, Status: models.ReservationHeld}))
+	assert.NoError(t, source.Chats().CreateChat(models.Chat{Id: "chat", Messages: []models.Message{}}))
+	assert.NoError(t, source.Notifications().Add(models.Notification{Id: "n", UserId: "john", Message: "hello"}))
+
+	dir := t.TempDir()
+	assert.NoError(t, source.ExportJSON(dir))
+
+	imported := newTestBoltStore(t)
+	assert.NoError(t, imported.Products().Create(models.Product{Id: "stale"}))
+	assert.NoError(t, imported.ImportJSON(dir))
+
+	products, err := imported.Products().GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Product{{Id: "1", Name: "iPhone 15", Quantity: 10}}, products)
+
+	products, err = imported.Products().GetAllByName()
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Product{{Id: "1", Name: "iPhone 15", Quantity: 10}}, products)
+
+	latest, err := imported.Stock().Latest("1")
+	assert.NoError(t, err)
+	assert.Equal(t, "m", latest.Id)
+
+	subscriptions, err := imported.Products().GetSubscriptionsByUser("john")
+	assert.NoError(t, err)
+	assert.Len(t, subscriptions, 1)
+
+	reservation, err := imported.Reservations().Get("r")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reservation.Quantity)
+
+	chat, err := imported.Chats().GetChat("chat")
+	assert.NoError(t, err)
+	assert.NotNil(t, chat)
+
+	notifs, err := imported.Notifications().Get("john")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Notification{{Id: "n", UserId: "john", Message: "hello"}}, notifs)
+
+	// Every JSON file is exported.
+	reexported := t.TempDir()
+	assert.NoError(t, imported.ExportJSON(reexported))
+	for _, file := range []string{productsFile, subscriptionsFile, stockFile, thresholdsFile, reservationsFile, chatsFile, notificationsFile} {
+		assert.FileExists(t, filepath.Join(reexported, file))
+	}
+}
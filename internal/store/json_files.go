@@ -0,0 +1,213 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+)
+
+// The files, relative to the data directory, that the bolt store imports each
+// repository from and exports it to.
+var (
+	productsFile      = filepath.Join("product", "products.json")
+	subscriptionsFile = filepath.Join("product", "subscriptions.json")
+	stockFile         = filepath.Join("product", "stock.json")
+	thresholdsFile    = filepath.Join("product", "thresholds.json")
+	reservationsFile  = filepath.Join("product", "reservations.json")
+	chatsFile         = filepath.Join("chat", "chats.json")
+	notificationsFile = filepath.Join("notification", "notifications.json")
+)
+
+type jsonFiles struct {
+	Products struct {
+		Products []models.Product `json:"products"`
+	}
+	Subscriptions struct {
+		Subscriptions []models.ProductSubscription `json:"subscriptions"`
+	}
+	Stock struct {
+		Movements []models.StockMovement `json:"movements"`
+	}
+	Thresholds struct {
+		Thresholds []models.UserThreshold `json:"thresholds"`
+	}
+	Reservations struct {
+		Reservations []models.Reservation `json:"reservations"`
+	}
+	Chats struct {
+		Chats []models.Chat `json:"chats"`
+	}
+	Notifications struct {
+		Notifications []models.Notification `json:"notifications"`
+	}
+}
+
+func (f *jsonFiles) files() map[string]interface{} {
+	return map[string]interface{}{
+		productsFile:      &f.Products,
+		subscriptionsFile: &f.Subscriptions,
+		stockFile:         &f.Stock,
+		thresholdsFile:    &f.Thresholds,
+		reservationsFile:  &f.Reservations,
+		chatsFile:         &f.Chats,
+		notificationsFile: &f.Notifications,
+	}
+}
+
+// ImportJSON replaces the contents of the store with the JSON files in dataDir,
+// in a single transaction. Missing files are treated as empty.
+func (s *BoltStore) ImportJSON(dataDir string) error {
+	var data jsonFiles
+	for file, v := range data.files() {
+		err := storage.ReadJSON(filepath.Join(dataDir, file), v)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range buckets {
+			err := tx.DeleteBucket(bucket)
+			if err != nil {
+				return err
+			}
+			_, err = tx.CreateBucket(bucket)
+			if err != nil {
+				return err
+			}
+		}
+
+		repos := boltTx{logger: s.Logger, tx: tx}
+		for _, product := range data.Products.Products {
+			err := repos.Products().Create(product)
+			if err != nil {
+				return err
+			}
+		}
+
+		subscriptions := tx.Bucket(subscriptionsBucket)
+		byUser := tx.Bucket(subscriptionsByUserBucket)
+		for _, subscription := range data.Subscriptions.Subscriptions {
+			err := put(subscriptions, indexKey(subscription.ProductId, subscription.SubType), subscription)
+			if err != nil {
+				return err
+			}
+			for _, user := range subscription.Users {
+				err = byUser.Put(indexKey(user, subscription.ProductId, subscription.SubType), []byte{})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, movement := range data.Stock.Movements {
+			err := repos.Stock().Add(movement)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, threshold := range data.Thresholds.Thresholds {
+			err := repos.Thresholds().Save(threshold)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, reservation := range data.Reservations.Reservations {
+			err := repos.Reservations().Save(reservation)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, chat := range data.Chats.Chats {
+			err := repos.Chats().CreateChat(chat)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, notification := range data.Notifications.Notifications {
+			err := repos.Notifications().Add(notification)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ExportJSON writes the contents of the store to dataDir as the JSON files
+// ImportJSON reads, from a single consistent snapshot.
+func (s *BoltStore) ExportJSON(dataDir string) error {
+	var data jsonFiles
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for _, export := range []struct {
+			bucket []byte
+			add    func(dat []byte) error
+		}{
+			{productsBucket, appendJSON(&data.Products.Products)},
+			{subscriptionsBucket, appendJSON(&data.Subscriptions.Subscriptions)},
+			{stockBucket, appendJSON(&data.Stock.Movements)},
+			{thresholdsBucket, appendJSON(&data.Thresholds.Thresholds)},
+			{reservationsBucket, appendJSON(&data.Reservations.Reservations)},
+			{chatsBucket, appendJSON(&data.Chats.Chats)},
+		} {
+			err := tx.Bucket(export.bucket).ForEach(func(_, dat []byte) error {
+				return export.add(dat)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Notifications are exported in the order they were added for each user.
+		notifications := tx.Bucket(notificationsBucket)
+		addNotification := appendJSON(&data.Notifications.Notifications)
+		return tx.Bucket(notificationsByUserBucket).ForEach(func(_, id []byte) error {
+			dat := notifications.Get(id)
+			if dat == nil {
+				return nil
+			}
+			return addNotification(dat)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	sortReservations(data.Reservations.Reservations)
+
+	for file, v := range data.files() {
+		path := filepath.Join(dataDir, file)
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s", filepath.Dir(path))
+		}
+
+		err = storage.WriteJSON(path, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendJSON returns a function that unmarshals each value it is given and
+// appends it to values.
+func appendJSON[T any](values *[]T) func(dat []byte) error {
+	*values = make([]T, 0)
+	return func(dat []byte) error {
+		var value T
+		err := json.Unmarshal(dat, &value)
+		if err != nil {
+			return err
+		}
+		*values = append(*values, value)
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/config"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/pkg/errors"
+	"log/slog"
+	"os"
+)
+
+// NewStore opens the bolt database at cfg.BoltPath. A new database is seeded
+// from the JSON files under internal/data.
+func NewStore(cfg config.Storage, logger slog.Logger) (domain.Store, error) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = os.Stat(cfg.BoltPath)
+	seed := os.IsNotExist(err)
+
+	store, err := OpenBoltStore(cfg.BoltPath, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if seed {
+		logger.Info("seeding new bolt database from JSON files", "path", cfg.BoltPath)
+		err = store.ImportJSON(fmt.Sprintf("%s/internal/data", currentDir))
+		if err != nil {
+			store.Close()
+			os.Remove(cfg.BoltPath)
+			return nil, errors.Wrap(err, "failed to seed bolt database")
+		}
+	}
+
+	return store, nil
+}
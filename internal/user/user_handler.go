@@ -0,0 +1,139 @@
+package user
+
+import (
+	"encoding/json"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/router"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type UserHandler struct {
+	UserUseCase domain.UserUseCase
+}
+
+func NewUserHandler(router *router.RouterUseCase, uc domain.UserUseCase) {
+	handler := UserHandler{
+		UserUseCase: uc,
+	}
+
+	router.AddRoute("/user", models.POST, handler.Create)
+	router.AddRoute("/user", models.PUT, handler.Update)
+	router.AddRoute("/user", models.DELETE, handler.Delete)
+	router.AddRoute("/user/disable", models.POST, handler.SetDisabled)
+	router.AddRoute("/user/role", models.PUT, handler.SetRole)
+}
+
+func (u UserHandler) Create(ctx *router.RouterContext) {
+	var request models.CreateUserRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	user, err := u.UserUseCase.Create(request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.UserResponse{
+		StatusCode: 200,
+		User:       user,
+	})
+}
+
+func (u UserHandler) Update(ctx *router.RouterContext) {
+	var request models.UpdateUserRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	user, err := u.UserUseCase.Update(request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.UserResponse{
+		StatusCode: 200,
+		User:       user,
+	})
+}
+
+func (u UserHandler) Delete(ctx *router.RouterContext) {
+	var request models.RequestById
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	if request.Id == ctx.User.UserId {
+		ctx.JSON(400, models.NewErrorResponse(400, "You cannot delete yourself"))
+		return
+	}
+
+	err = u.UserUseCase.Delete(request.Id)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "User deleted"))
+}
+
+func (u UserHandler) SetDisabled(ctx *router.RouterContext) {
+	var request models.SetUserDisabledRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	if request.Id == ctx.User.UserId {
+		ctx.JSON(400, models.NewErrorResponse(400, "You cannot disable yourself"))
+		return
+	}
+
+	err = u.UserUseCase.SetDisabled(request.Id, request.Disabled)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "User updated"))
+}
+
+func (u UserHandler) SetRole(ctx *router.RouterContext) {
+	var request models.SetUserRoleRequest
+	err := json.Unmarshal([]byte(ctx.Body), &request)
+	if err != nil {
+		ctx.JSON(500, models.NewInternalServerError())
+		return
+	}
+
+	err = u.UserUseCase.SetRole(request.Id, request.Role)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, models.NewSuccessResponse(200, "User role updated"))
+}
+
+func writeError(ctx *router.RouterContext, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		ctx.JSON(404, models.NewErrorResponse(404, "User not found"))
+	case errors.Is(err, ErrUsernameTaken):
+		ctx.JSON(409, models.NewErrorResponse(409, err.Error()))
+	case errors.Is(err, ErrInvalidUser):
+		ctx.JSON(400, models.NewErrorResponse(400, err.Error()))
+	default:
+		ctx.JSON(500, models.NewInternalServerError())
+	}
+}
@@ -0,0 +1,170 @@
+package user
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+var (
+	ErrUserNotFound  = errors.New("user not found")
+	ErrUsernameTaken = errors.New("username is already taken")
+)
+
+type userData struct {
+	Users []models.User `json:"users"`
+}
+
+type userRepository struct {
+	Logger slog.Logger
+	path   string
+	mu     sync.RWMutex
+	users  []models.User
+}
+
+func NewUserRepository(logger slog.Logger) domain.UserRepository {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	repo, err := NewUserRepositoryFromFile(fmt.Sprintf("%s/internal/data/auth/users.json", currentDir), logger)
+	if err != nil {
+		panic(err)
+	}
+
+	return repo
+}
+
+// NewUserRepositoryFromFile creates a repository that reads and persists users
+// to the file at path.
+func NewUserRepositoryFromFile(path string, logger slog.Logger) (domain.UserRepository, error) {
+	var data userData
+	err := storage.ReadJSON(path, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userRepository{
+		Logger: logger,
+		path:   path,
+		users:  data.Users,
+	}, nil
+}
+
+func (u *userRepository) GetAll() ([]models.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return append([]models.User{}, u.users...), nil
+}
+
+func (u *userRepository) GetById(id string) (*models.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	for _, user := range u.users {
+		if user.Id == id {
+			return &user, nil
+		}
+	}
+
+	return nil, ErrUserNotFound
+}
+
+func (u *userRepository) GetByUsername(username string) (*models.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	for _, user := range u.users {
+		if user.Username == username {
+			return &user, nil
+		}
+	}
+
+	return nil, ErrUserNotFound
+}
+
+func (u *userRepository) Create(user models.User) error {
+	u.Logger.Info("creating user", "userId", user.Id, "username", user.Username)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	err := u.checkUsernameAvailable(user)
+	if err != nil {
+		return err
+	}
+
+	users := append([]models.User{}, u.users...)
+	users = append(users, user)
+	return u.save(users)
+}
+
+func (u *userRepository) Update(user models.User) error {
+	u.Logger.Info("updating user", "userId", user.Id)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	err := u.checkUsernameAvailable(user)
+	if err != nil {
+		return err
+	}
+
+	users := append([]models.User{}, u.users...)
+	for i := range users {
+		if users[i].Id == user.Id {
+			users[i] = user
+			return u.save(users)
+		}
+	}
+
+	return ErrUserNotFound
+}
+
+func (u *userRepository) Delete(id string) error {
+	u.Logger.Info("deleting user", "userId", id)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	users := make([]models.User, 0, len(u.users))
+	for _, user := range u.users {
+		if user.Id != id {
+			users = append(users, user)
+		}
+	}
+
+	if len(users) == len(u.users) {
+		return ErrUserNotFound
+	}
+
+	return u.save(users)
+}
+
+// checkUsernameAvailable fails if a user other than user already has its
+// username. The caller must hold the write lock, so two users can't claim the
+// same username at once.
+func (u *userRepository) checkUsernameAvailable(user models.User) error {
+	for _, existing := range u.users {
+		if existing.Username == user.Username && existing.Id != user.Id {
+			return ErrUsernameTaken
+		}
+	}
+	return nil
+}
+
+// save persists users and, only once that succeeds, makes them the current set.
+// The caller must hold the write lock.
+func (u *userRepository) save(users []models.User) error {
+	err := storage.WriteJSON(u.path, userData{Users: users})
+	if err != nil {
+		return errors.Wrap(err, "failed to persist users")
+	}
+
+	u.users = users
+	return nil
+}
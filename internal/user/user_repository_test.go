@@ -0,0 +1,49 @@
+package user
+
+import (
+	"fmt"
+	"github.com/kkcaz/shu-dades-server/internal/storage"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestUserRepository_UniqueUsernames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	assert.NoError(t, storage.WriteJSON(path, userData{Users: []models.User{{Id: "1", Username: "john"}}}))
+	repo, err := NewUserRepositoryFromFile(path, *slog.Default())
+	assert.NoError(t, err)
+
+	// Only one of several users created at once with the same username is saved.
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = repo.Create(models.User{Id: fmt.Sprint(i + 2), Username: "jane"})
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+		} else {
+			assert.ErrorIs(t, err, ErrUsernameTaken)
+		}
+	}
+	assert.Equal(t, 1, created)
+
+	assert.ErrorIs(t, repo.Create(models.User{Id: "20", Username: "john"}), ErrUsernameTaken)
+	assert.ErrorIs(t, repo.Update(models.User{Id: "1", Username: "jane"}), ErrUsernameTaken)
+	assert.NoError(t, repo.Update(models.User{Id: "1", Username: "john", Email: "john@example.com"}))
+
+	users, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+}
@@ -0,0 +1,185 @@
+package user
+
+import (
+	"github.com/google/uuid"
+	"github.com/kkcaz/shu-dades-server/internal/auth"
+	"github.com/kkcaz/shu-dades-server/internal/domain"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/pkg/errors"
+	"log/slog"
+	"strings"
+)
+
+var ErrInvalidUser = errors.New("invalid user")
+
+type userUseCase struct {
+	Repository    domain.UserRepository
+	Organisations domain.OrganisationUseCase
+	Auth          domain.AuthUseCase
+	Logger        slog.Logger
+}
+
+func NewUserUseCase(repository domain.UserRepository, organisations domain.OrganisationUseCase, auth domain.AuthUseCase, logger slog.Logger) domain.UserUseCase {
+	return &userUseCase{
+		Repository:    repository,
+		Organisations: organisations,
+		Auth:          auth,
+		Logger:        logger,
+	}
+}
+
+func (u *userUseCase) Create(request models.CreateUserRequest) (*models.UserInfo, error) {
+	username := strings.TrimSpace(request.Username)
+	if username == "" || request.Password == "" {
+		return nil, errors.Wrap(ErrInvalidUser, "username and password are required")
+	}
+
+	if !request.Role.IsValid() {
+		return nil, errors.Wrapf(ErrInvalidUser, "unknown role %s", request.Role)
+	}
+
+	err := auth.ValidatePassword(request.Password)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidUser, err.Error())
+	}
+
+	err = u.checkOrganisationExists(request.OrganisationId)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		Id:       uuid.New().String(),
+		Username: username,
+		Password: hash,
+		Email:    request.Email,
+		Role:     request.Role,
+
+		OrganisationId: request.OrganisationId,
+	}
+
+	err = u.Repository.Create(user)
+	if err != nil {
+		return nil, err
+	}
+
+	info := user.Info()
+	return &info, nil
+}
+
+func (u *userUseCase) Update(request models.UpdateUserRequest) (*models.UserInfo, error) {
+	user, err := u.Repository.GetById(request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	username := strings.TrimSpace(request.Username)
+	if username != "" {
+		user.Username = username
+	}
+
+	if request.Email != "" {
+		user.Email = request.Email
+	}
+
+	if request.OrganisationId != "" && request.OrganisationId != user.OrganisationId {
+		err = u.checkOrganisationExists(request.OrganisationId)
+		if err != nil {
+			return nil, err
+		}
+		u.Logger.Info("moving user to organisation", "userId", user.Id, "organisationId", request.OrganisationId)
+		user.OrganisationId = request.OrganisationId
+	}
+
+	if request.Password != "" {
+		err = auth.ValidatePassword(request.Password)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidUser, err.Error())
+		}
+
+		user.Password, err = auth.HashPassword(request.Password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = u.Repository.Update(*user)
+	if err != nil {
+		return nil, err
+	}
+
+	info := user.Info()
+	return &info, nil
+}
+
+func (u *userUseCase) SetDisabled(userId string, disabled bool) error {
+	user, err := u.Repository.GetById(userId)
+	if err != nil {
+		return err
+	}
+
+	u.Logger.Info("setting user disabled", "userId", userId, "disabled", disabled)
+	user.Disabled = disabled
+	err = u.Repository.Update(*user)
+	if err != nil {
+		return err
+	}
+
+	if disabled {
+		return u.Auth.RevokeUser(userId)
+	}
+
+	return nil
+}
+
+func (u *userUseCase) SetRole(userId string, role models.Role) error {
+	if !role.IsValid() {
+		return errors.Wrapf(ErrInvalidUser, "unknown role %s", role)
+	}
+
+	user, err := u.Repository.GetById(userId)
+	if err != nil {
+		return err
+	}
+
+	if user.Role == role {
+		return nil
+	}
+
+	u.Logger.Info("changing user role", "userId", userId, "from", user.Role, "to", role)
+	user.Role = role
+	err = u.Repository.Update(*user)
+	if err != nil {
+		return err
+	}
+
+	// Tokens carry the role they were issued with, so force the user to log in again.
+	return u.Auth.RevokeUser(userId)
+}
+
+func (u *userUseCase) Delete(userId string) error {
+	// Revoke first, the user must still exist to be revoked.
+	err := u.Auth.RevokeUser(userId)
+	if err != nil {
+		return err
+	}
+
+	return u.Repository.Delete(userId)
+}
+
+func (u *userUseCase) checkOrganisationExists(organisationId string) error {
+	if organisationId == "" {
+		return nil
+	}
+
+	_, err := u.Organisations.Get(organisationId)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidUser, "organisation %s does not exist", organisationId)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package user
+
+import (
+	"github.com/kkcaz/shu-dades-server/internal/auth"
+	"github.com/kkcaz/shu-dades-server/internal/domain/mocks"
+	"github.com/kkcaz/shu-dades-server/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"testing"
+)
+
+func TestUserUseCase_Create(t *testing.T) {
+	testCases := []struct {
+		name        string
+		request     models.CreateUserRequest
+		createErr   error
+		expectedErr error
+	}{
+		{
+			name: "Happy path",
+			request: models.CreateUserRequest{
+				Username: "new",
+				Password: "password",
+				Role:     models.Customer,
+			},
+		},
+		{
+			name: "Sad path - missing password",
+			request: models.CreateUserRequest{
+				Username: "new",
+				Role:     models.Customer,
+			},
+			expectedErr: ErrInvalidUser,
+		},
+		{
+			name: "Sad path - invalid role",
+			request: models.CreateUserRequest{
+				Username: "new",
+				Password: "password",
+				Role:     "superuser",
+			},
+			expectedErr: ErrInvalidUser,
+		},
+		{
+			name: "Sad path - username taken",
+			request: models.CreateUserRequest{
+				Username: "new",
+				Password: "password",
+				Role:     models.Customer,
+			},
+			createErr:   ErrUsernameTaken,
+			expectedErr: ErrUsernameTaken,
+		},
+		{
+			name: "Sad path - create error",
+			request: models.CreateUserRequest{
+				Username: "new",
+				Password: "password",
+				Role:     models.Customer,
+			},
+			createErr:   assert.AnError,
+			expectedErr: assert.AnError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			authUc := mocks.NewAuthUseCase(t)
+			testUc := NewUserUseCase(repo, mocks.NewOrganisationUseCase(t), authUc, *slog.Default())
+
+			repo.On("Create", mock.MatchedBy(func(user models.User) bool {
+				matches, _ := auth.VerifyPassword(user.Password, tc.request.Password)
+				return auth.IsHashed(user.Password) && matches
+			})).Return(tc.createErr).Maybe()
+
+			user, err := testUc.Create(tc.request)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.request.Username, user.Username)
+			assert.NotEmpty(t, user.Id)
+		})
+	}
+}
+
+func TestUserUseCase_SetDisabled(t *testing.T) {
+	testCases := []struct {
+		name     string
+		disabled bool
+		getErr   error
+	}{
+		{
+			name:     "Happy path - disable revokes sessions",
+			disabled: true,
+		},
+		{
+			name:     "Happy path - enable",
+			disabled: false,
+		},
+		{
+			name:     "Sad path - user not found",
+			disabled: true,
+			getErr:   ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			authUc := mocks.NewAuthUseCase(t)
+			testUc := NewUserUseCase(repo, mocks.NewOrganisationUseCase(t), authUc, *slog.Default())
+
+			if tc.getErr != nil {
+				repo.On("GetById", "1").Return(nil, tc.getErr)
+			} else {
+				repo.On("GetById", "1").Return(&models.User{Id: "1"}, nil)
+				repo.On("Update", models.User{Id: "1", Disabled: tc.disabled}).Return(nil)
+				if tc.disabled {
+					authUc.On("RevokeUser", "1").Return(nil)
+				}
+			}
+
+			err := testUc.SetDisabled("1", tc.disabled)
+			if tc.getErr != nil {
+				assert.ErrorIs(t, err, tc.getErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserUseCase_SetRole(t *testing.T) {
+	testCases := []struct {
+		name        string
+		current     models.Role
+		role        models.Role
+		expectedErr error
+	}{
+		{
+			name:    "Happy path - role changed",
+			current: models.Customer,
+			role:    models.Supplier,
+		},
+		{
+			name:    "Happy path - role unchanged",
+			current: models.Customer,
+			role:    models.Customer,
+		},
+		{
+			name:        "Sad path - invalid role",
+			current:     models.Customer,
+			role:        "superuser",
+			expectedErr: ErrInvalidUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			authUc := mocks.NewAuthUseCase(t)
+			testUc := NewUserUseCase(repo, mocks.NewOrganisationUseCase(t), authUc, *slog.Default())
+
+			if tc.expectedErr == nil {
+				repo.On("GetById", "1").Return(&models.User{Id: "1", Role: tc.current}, nil)
+				if tc.current != tc.role {
+					repo.On("Update", models.User{Id: "1", Role: tc.role}).Return(nil)
+					authUc.On("RevokeUser", "1").Return(nil)
+				}
+			}
+
+			err := testUc.SetRole("1", tc.role)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ApiKeyPrefix starts every API key so they can be told apart from session tokens.
+const ApiKeyPrefix = "dk_"
+
+// IsApiKey reports whether token looks like an API key rather than a session token.
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, ApiKeyPrefix)
+}
+
+type ApiKeyScope string
+
+const (
+	// Read products, searches and subscriptions.
+	ProductsRead ApiKeyScope = "products:read"
+	// Create, update and delete products.
+	ProductsWrite ApiKeyScope = "products:write"
+	// Change product quantities only.
+	StockAdjust ApiKeyScope = "stock:adjust"
+)
+
+func (s ApiKeyScope) IsValid() bool {
+	return s == ProductsRead || s == ProductsWrite || s == StockAdjust
+}
+
+type ApiKey struct {
+	Id         string        `json:"id"`
+	UserId     string        `json:"userId"`
+	Name       string        `json:"name"`
+	Hash       string        `json:"hash"`
+	Scopes     []ApiKeyScope `json:"scopes"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	ExpiresAt  time.Time     `json:"expiresAt"`
+	LastUsedAt *time.Time    `json:"lastUsedAt"`
+}
+
+// Info returns the key without its hash.
+func (k ApiKey) Info() ApiKeyInfo {
+	return ApiKeyInfo{
+		Id:         k.Id,
+		UserId:     k.UserId,
+		Name:       k.Name,
+		Scopes:     k.Scopes,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+	}
+}
+
+type ApiKeyInfo struct {
+	Id         string        `json:"id"`
+	UserId     string        `json:"userId"`
+	Name       string        `json:"name"`
+	Scopes     []ApiKeyScope `json:"scopes"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	ExpiresAt  time.Time     `json:"expiresAt"`
+	LastUsedAt *time.Time    `json:"lastUsedAt"`
+}
+
+type CreateApiKeyRequest struct {
+	UserId    string        `json:"userId"`
+	Name      string        `json:"name"`
+	Scopes    []ApiKeyScope `json:"scopes"`
+	ExpiresAt time.Time     `json:"expiresAt"`
+}
+
+type CreateApiKeyResponse struct {
+	StatusCode int `json:"statusCode"`
+	// The key itself, only ever returned here.
+	Key    string     `json:"key"`
+	ApiKey ApiKeyInfo `json:"apiKey"`
+}
+
+type ApiKeyListRequest struct {
+	// Only return keys for this user, all keys are returned when empty.
+	UserId string `json:"userId"`
+}
+
+type ApiKeyListResponse struct {
+	StatusCode int          `json:"statusCode"`
+	ApiKeys    []ApiKeyInfo `json:"apiKeys"`
+}
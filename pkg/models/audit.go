@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+type AuditEvent string
+
+const (
+	// A login, logout, token refresh or other change to a user's credentials.
+	AuditAuthentication AuditEvent = "authentication"
+	// A request rejected because the caller wasn't authenticated or allowed to make it.
+	AuditDenied AuditEvent = "denied"
+	// A request to a route restricted to particular roles.
+	AuditPrivileged AuditEvent = "privileged"
+	// A request to a route that changes data.
+	AuditChange AuditEvent = "change"
+)
+
+type AuditEntry struct {
+	Id       string     `json:"id"`
+	Time     time.Time  `json:"time"`
+	Event    AuditEvent `json:"event"`
+	ActorId  string     `json:"actorId,omitempty"`
+	ApiKeyId string     `json:"apiKeyId,omitempty"`
+	// The admin acting as ActorId, when the request came from an impersonation session.
+	ImpersonatorId string `json:"impersonatorId,omitempty"`
+	// The username sent by a caller that isn't logged in, such as with a failed
+	// login or a password reset.
+	Username      string      `json:"username,omitempty"`
+	RemoteAddress string      `json:"remoteAddress"`
+	Route         string      `json:"route"`
+	Method        RequestType `json:"method"`
+	StatusCode    int         `json:"statusCode"`
+	// The request body, with passwords, codes and tokens redacted and long values
+	// truncated.
+	Summary map[string]interface{} `json:"summary,omitempty"`
+}
+
+type AuditQueryRequest struct {
+	ActorId string `json:"actorId"`
+	// Only return entries from callers that weren't logged in and sent this
+	// username.
+	Username string     `json:"username"`
+	From     *time.Time `json:"from"`
+	To       *time.Time `json:"to"`
+	// The most entries to return, newest first.
+	Limit int `json:"limit"`
+}
+
+type AuditListResponse struct {
+	StatusCode int          `json:"statusCode"`
+	Entries    []AuditEntry `json:"entries"`
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"slices"
+	"time"
+)
+
 type AuthRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
@@ -8,10 +13,151 @@ type AuthRequest struct {
 type AuthResponse struct {
 	StatusCode int        `json:"statusCode"`
 	UserClaim  *UserClaim `json:"userClaim"`
+
+	// Set instead of UserClaim when the password was correct but a one-time
+	// code is needed. The challenge token is sent with the code to
+	// /auth/2fa, or to /auth/2fa/enrol when the user must enrol first.
+	TwoFactorRequired          bool   `json:"twoFactorRequired,omitempty"`
+	TwoFactorEnrolmentRequired bool   `json:"twoFactorEnrolmentRequired,omitempty"`
+	ChallengeToken             string `json:"challengeToken,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
 }
 
 type UserClaim struct {
+	UserId       string    `json:"userId"`
+	SessionId    string    `json:"sessionId"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	Role         Role      `json:"role"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+
+	// Set when the caller authenticated with an API key rather than a session.
+	ApiKeyId string        `json:"apiKeyId,omitempty"`
+	Scopes   []ApiKeyScope `json:"scopes,omitempty"`
+
+	// Set when an admin is acting as this user. Read only impersonation
+	// sessions may only call GET routes.
+	ImpersonatorId string `json:"impersonatorId,omitempty"`
+	ReadOnly       bool   `json:"readOnly,omitempty"`
+}
+
+func (c UserClaim) IsApiKey() bool {
+	return c.ApiKeyId != ""
+}
+
+func (c UserClaim) IsImpersonated() bool {
+	return c.ImpersonatorId != ""
+}
+
+func (c UserClaim) HasScope(scope ApiKeyScope) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+type RevokeUserRequest struct {
+	UserId string `json:"userId"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+type PasswordResetRequest struct {
+	Username string `json:"username"`
+}
+
+type CompletePasswordResetRequest struct {
+	Username    string `json:"username"`
+	Code        string `json:"code"`
+	NewPassword string `json:"newPassword"`
+}
+
+type Lockout struct {
+	// Either "username:<username>" or "address:<address>".
+	Key          string    `json:"key"`
+	Failures     int       `json:"failures"`
+	LastFailure  time.Time `json:"lastFailure"`
+	BlockedUntil time.Time `json:"blockedUntil"`
+	Locked       bool      `json:"locked"`
+}
+
+type LockoutListResponse struct {
+	StatusCode int       `json:"statusCode"`
+	Lockouts   []Lockout `json:"lockouts"`
+}
+
+type ClearLockoutRequest struct {
+	Key string `json:"key"`
+}
+
+type TwoFactorRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	// A one-time code or a recovery code.
+	Code string `json:"code"`
+}
+
+type TwoFactorEnrolRequest struct {
+	// Only needed when enrolling before the first login, otherwise the caller's session is used.
+	ChallengeToken string `json:"challengeToken"`
+}
+
+type TwoFactorEnrolment struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioningUri"`
+}
+
+type TwoFactorEnrolmentResponse struct {
+	StatusCode int                `json:"statusCode"`
+	Enrolment  TwoFactorEnrolment `json:"enrolment"`
+}
+
+type ConfirmTwoFactorRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code"`
+}
+
+type RecoveryCodesResponse struct {
+	StatusCode    int      `json:"statusCode"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type DisableTwoFactorRequest struct {
+	Code string `json:"code"`
+}
+
+type Session struct {
+	Id            string    `json:"id"`
+	UserId        string    `json:"userId"`
+	CreatedAt     time.Time `json:"createdAt"`
+	LastSeenAt    time.Time `json:"lastSeenAt"`
+	RemoteAddress string    `json:"remoteAddress"`
+	// When the session's refresh token expires, after which it can't be used.
+	ExpiresAt time.Time `json:"expiresAt"`
+	// The ID of the latest refresh token issued, the only one that can be exchanged.
+	RefreshTokenId string `json:"refreshTokenId,omitempty"`
+}
+
+type SessionInfo struct {
+	Id            string    `json:"id"`
+	CreatedAt     time.Time `json:"createdAt"`
+	LastSeenAt    time.Time `json:"lastSeenAt"`
+	RemoteAddress string    `json:"remoteAddress"`
+	// Whether a broadcast subscription is attached to the session.
+	Subscribed bool `json:"subscribed"`
+	// Whether this is the session making the request.
+	Current bool `json:"current"`
+}
+
+type SessionListResponse struct {
+	StatusCode int           `json:"statusCode"`
+	Sessions   []SessionInfo `json:"sessions"`
+}
+
+type ImpersonateRequest struct {
 	UserId string `json:"userId"`
-	Token  string `json:"token"`
-	Role   Role   `json:"role"`
+	// Allow the impersonation session to call routes that change data.
+	AllowWrites bool `json:"allowWrites"`
 }
@@ -14,4 +14,5 @@ type BroadcastConnection struct {
 	PublishAddress   string `json:"publishAddress"`
 	SubscribeAddress string `json:"subscribeAddress"`
 	UserId           string `json:"userId"`
+	SessionId        string `json:"sessionId"`
 }
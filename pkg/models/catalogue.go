@@ -0,0 +1,63 @@
+package models
+
+type CatalogueFormat string
+
+const (
+	CSV  CatalogueFormat = "csv"
+	JSON CatalogueFormat = "json"
+)
+
+type ImportProductsRequest struct {
+	Format CatalogueFormat `json:"format"`
+	// The contents of the file.
+	Data string `json:"data"`
+	// Check the file and report what would change without changing anything.
+	DryRun bool `json:"dryRun"`
+	// Only honoured for admins, suppliers always import into their own catalogue.
+	SupplierId string `json:"supplierId"`
+}
+
+type ImportAction string
+
+const (
+	ImportCreated   ImportAction = "created"
+	ImportUpdated   ImportAction = "updated"
+	ImportUnchanged ImportAction = "unchanged"
+	ImportFailed    ImportAction = "failed"
+)
+
+type ImportRowResult struct {
+	// The line of a CSV file, so the first product after the header is row 2,
+	// or the position of the product in a JSON array starting at 1.
+	Row    int          `json:"row"`
+	Sku    string       `json:"sku"`
+	Action ImportAction `json:"action"`
+	Error  string       `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun    bool              `json:"dryRun"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+type ImportProductsResponse struct {
+	StatusCode int `json:"statusCode"`
+	ImportReport
+}
+
+type ExportProductsRequest struct {
+	Format CatalogueFormat `json:"format"`
+	// Only honoured for admins, who export every supplier's products when it is
+	// empty. Suppliers always export their own catalogue.
+	SupplierId string `json:"supplierId"`
+}
+
+type ExportProductsResponse struct {
+	StatusCode int             `json:"statusCode"`
+	Format     CatalogueFormat `json:"format"`
+	Data       string          `json:"data"`
+}
@@ -45,6 +45,18 @@ type CreateChatRequest struct {
 	UserIds []string `json:"userIds"`
 }
 
+type ChatMessagesRequest struct {
+	ChatId string `json:"chatId"`
+	PageRequest
+}
+
+type ChatMessagesResponse struct {
+	StatusCode int `json:"statusCode"`
+	// Newest first.
+	Messages []Message `json:"messages"`
+	PageInfo
+}
+
 type SendMessageRequest struct {
 	ChatId  string `json:"chatId"`
 	Message string `json:"message"`
@@ -1,14 +1,18 @@
 package models
 
+import "time"
+
 type Notification struct {
-	Id      string `json:"id"`
-	Message string `json:"message"`
-	UserId  string `json:"userId"`
+	Id        string    `json:"id"`
+	Message   string    `json:"message"`
+	UserId    string    `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 type NotificationListResponse struct {
 	StatusCode    int            `json:"statusCode"`
 	Notifications []Notification `json:"notifications"`
+	PageInfo
 }
 
 type DeleteNotificationRequest struct {
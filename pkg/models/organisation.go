@@ -0,0 +1,67 @@
+package models
+
+import "slices"
+
+type Organisation struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// Supplier organisations whose catalogues members of this organisation can see.
+	LinkedSupplierIds []string `json:"linkedSupplierIds"`
+}
+
+type OrganisationResponse struct {
+	StatusCode   int           `json:"statusCode"`
+	Organisation *Organisation `json:"organisation"`
+}
+
+type OrganisationListResponse struct {
+	StatusCode    int            `json:"statusCode"`
+	Organisations []Organisation `json:"organisations"`
+}
+
+type CreateOrganisationRequest struct {
+	Name              string   `json:"name"`
+	LinkedSupplierIds []string `json:"linkedSupplierIds"`
+}
+
+type UpdateOrganisationRequest struct {
+	Id                string   `json:"id"`
+	Name              string   `json:"name"`
+	LinkedSupplierIds []string `json:"linkedSupplierIds"`
+}
+
+// TenantScope describes which users and products a user may see.
+type TenantScope struct {
+	UserId string
+	// Admins aren't restricted to any organisation.
+	All            bool
+	OrganisationId string
+	// Organisations whose members are visible: the user's own and any linked to it
+	// in either direction.
+	Organisations []string
+	// Organisations whose products are visible: the user's own and the suppliers it's linked to.
+	Catalogues []string
+}
+
+func (s TenantScope) CanSeeUser(user User) bool {
+	if s.All || user.Id == s.UserId {
+		return true
+	}
+	return user.OrganisationId != "" && slices.Contains(s.Organisations, user.OrganisationId)
+}
+
+// CanSeeContactDetails reports whether the user's email should be shown, which
+// is only the case within an organisation.
+func (s TenantScope) CanSeeContactDetails(user User) bool {
+	if s.All || user.Id == s.UserId {
+		return true
+	}
+	return user.OrganisationId != "" && user.OrganisationId == s.OrganisationId
+}
+
+func (s TenantScope) CanSeeProduct(product Product) bool {
+	if s.All || product.SupplierId == s.UserId {
+		return true
+	}
+	return product.OrganisationId != "" && slices.Contains(s.Catalogues, product.OrganisationId)
+}
@@ -1,9 +1,67 @@
 package models
 
+import "time"
+
 type Product struct {
-	Id       string `json:"id"`
-	Name     string `json:"name"`
-	Quantity int    `json:"quantity"`
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// The supplier's stock keeping unit, unique among their products.
+	Sku string `json:"sku,omitempty"`
+	// A GTIN-8, 12, 13 or 14 barcode such as an EAN or UPC.
+	Barcode     string   `json:"barcode,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// In the minor unit of Currency, such as pence.
+	UnitPrice int64 `json:"unitPrice"`
+	// An ISO 4217 code such as GBP. Required when the product has a price.
+	Currency      string        `json:"currency,omitempty"`
+	UnitOfMeasure UnitOfMeasure `json:"unitOfMeasure,omitempty"`
+	// The stock on hand, including any held by reservations.
+	Quantity int `json:"quantity"`
+	// The stock held by reservations, which can only be sold to their holders.
+	Reserved   int    `json:"reserved"`
+	SupplierId string `json:"supplierId"`
+	// The supplier's organisation, whose linked customers can see the product.
+	OrganisationId string `json:"organisationId"`
+	// The supplier and subscribers are alerted when the quantity falls to this.
+	ReorderThreshold *int `json:"reorderThreshold,omitempty"`
+	// Set once the quantity has fallen to the reorder threshold, until it is
+	// restocked.
+	LowStock bool `json:"lowStock,omitempty"`
+	// Zero for products created before they were recorded.
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Increases every time the product is saved. Updates must give the version
+	// they were based on.
+	Version int `json:"version"`
+}
+
+// Available returns the stock that can be sold or reserved.
+func (p Product) Available() int {
+	return p.Quantity - p.Reserved
+}
+
+type UnitOfMeasure string
+
+const (
+	Each       UnitOfMeasure = "each"
+	Pack       UnitOfMeasure = "pack"
+	Box        UnitOfMeasure = "box"
+	Kilogram   UnitOfMeasure = "kg"
+	Gram       UnitOfMeasure = "g"
+	Litre      UnitOfMeasure = "l"
+	Millilitre UnitOfMeasure = "ml"
+	Metre      UnitOfMeasure = "m"
+)
+
+func (u UnitOfMeasure) IsValid() bool {
+	switch u {
+	case Each, Pack, Box, Kilogram, Gram, Litre, Millilitre, Metre:
+		return true
+	default:
+		return false
+	}
 }
 
 type ProductResponse struct {
@@ -16,16 +74,40 @@ type ProductListResponse struct {
 	Products   []Product `json:"products"`
 }
 
+type ProductSearchResponse struct {
+	StatusCode int       `json:"statusCode"`
+	Products   []Product `json:"products"`
+	PageInfo
+}
+
 type CreateProductRequest struct {
-	Name     string `json:"name"`
-	Quantity int    `json:"quantity"`
+	Name          string        `json:"name"`
+	Sku           string        `json:"sku"`
+	Barcode       string        `json:"barcode"`
+	Description   string        `json:"description"`
+	Category      string        `json:"category"`
+	Tags          []string      `json:"tags"`
+	UnitPrice     int64         `json:"unitPrice"`
+	Currency      string        `json:"currency"`
+	UnitOfMeasure UnitOfMeasure `json:"unitOfMeasure"`
+	Quantity      int           `json:"quantity"`
+
+	ReorderThreshold *int `json:"reorderThreshold"`
+
+	// Only honoured for admins, suppliers always own the products they create.
+	SupplierId string `json:"supplierId"`
 }
 
 type SortBy string
 
 const (
-	Name     SortBy = "name"
-	Quantity SortBy = "quantity"
+	Name      SortBy = "name"
+	Quantity  SortBy = "quantity"
+	Price     SortBy = "price"
+	CreatedAt SortBy = "createdAt"
+	UpdatedAt SortBy = "updatedAt"
+	// Best matches for the search query first, ignores Order.
+	Relevance SortBy = "relevance"
 )
 
 type Order string
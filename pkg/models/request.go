@@ -27,9 +27,39 @@ type RequestById struct {
 	Id string `json:"id"`
 }
 
+// PageRequest asks for a page of a list.
+type PageRequest struct {
+	// The nextCursor of the previous page, empty for the first page.
+	Cursor string `json:"cursor"`
+	// Maximum number of items to return, 50 when zero and at most 500. Lists
+	// that predate cursors return every item when neither Cursor nor Limit is set.
+	Limit int `json:"limit"`
+}
+
 type SearchRequest struct {
-	PageNumber int    `json:"pageNumber"`
-	PageSize   int    `json:"pageSize"`
-	SortBy     SortBy `json:"sortBy"`
-	Order      Order  `json:"order"`
+	PageRequest
+	// Deprecated: page with Cursor and Limit, which stay stable while products
+	// change. Used when there's no Cursor or Limit, and every product is
+	// returned when PageSize isn't set either.
+	PageNumber int `json:"pageNumber"`
+	PageSize   int `json:"pageSize"`
+	// Defaults to relevance when Query is set.
+	SortBy SortBy `json:"sortBy"`
+	Order  Order  `json:"order"`
+
+	// Words to look for in product names and descriptions. Every word must
+	// match, either exactly or as the start of a longer word.
+	Query string `json:"query"`
+
+	// Only products whose name contains Name, ignoring case.
+	Name string `json:"name"`
+	// Only products in Category, ignoring case.
+	Category   string `json:"category"`
+	SupplierId string `json:"supplierId"`
+	// Only products with at least MinQuantity in stock.
+	MinQuantity *int `json:"minQuantity"`
+	// Only products with at most MaxQuantity in stock.
+	MaxQuantity *int `json:"maxQuantity"`
+	// Only products with every one of Tags.
+	Tags []string `json:"tags"`
 }
@@ -30,3 +30,12 @@ func NewSuccessResponse(statusCode int, message string) *SuccessResponse {
 		Message:    message,
 	}
 }
+
+// PageInfo describes where a page sits in its list.
+type PageInfo struct {
+	// Pass as the cursor to get the next page, empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+	// Number of items in the whole list.
+	Total int `json:"total"`
+}
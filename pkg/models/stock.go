@@ -0,0 +1,164 @@
+package models
+
+import "time"
+
+type StockMovementType string
+
+const (
+	// Receive adds stock delivered to the supplier.
+	Receive StockMovementType = "receive"
+	// Sell removes stock that has been sold.
+	Sell StockMovementType = "sell"
+	// Adjust corrects the stock by a signed amount, such as after a stock take.
+	Adjust StockMovementType = "adjust"
+	// Damage removes stock that can no longer be sold.
+	Damage StockMovementType = "damage"
+	// Transfer moves stock to another of the supplier's products.
+	Transfer StockMovementType = "transfer"
+)
+
+// StockMovement is an entry in a product's stock ledger. A product's quantity is
+// the sum of the deltas of its movements.
+type StockMovement struct {
+	Id        string            `json:"id"`
+	ProductId string            `json:"productId"`
+	Type      StockMovementType `json:"type"`
+	Delta     int               `json:"delta"`
+	// The product's quantity once the movement was applied.
+	Quantity  int       `json:"quantity"`
+	UserId    string    `json:"userId"`
+	Time      time.Time `json:"time"`
+	Reason    string    `json:"reason"`
+	Reference string    `json:"reference"`
+	// The other product in a transfer.
+	TransferProductId string `json:"transferProductId,omitempty"`
+}
+
+type StockMovementRequest struct {
+	// The product the stock is moved for.
+	Id   string            `json:"id"`
+	Type StockMovementType `json:"type"`
+	// The amount of stock moved. Signed for adjustments, positive otherwise.
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"`
+	// The product stock is transferred to.
+	ToProductId string `json:"toProductId"`
+}
+
+type StockMovementResponse struct {
+	StatusCode int            `json:"statusCode"`
+	Movement   *StockMovement `json:"movement"`
+}
+
+// StockHistoryRequest selects a product's movements, optionally only those at or
+// after From and before To.
+type StockHistoryRequest struct {
+	Id   string     `json:"id"`
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+}
+
+type StockHistoryResponse struct {
+	StatusCode int             `json:"statusCode"`
+	Movements  []StockMovement `json:"movements"`
+}
+
+// UserThreshold alerts a user when a product's quantity falls to Threshold.
+type UserThreshold struct {
+	UserId    string `json:"userId"`
+	ProductId string `json:"productId"`
+	Threshold int    `json:"threshold"`
+	// Set once the user has been alerted, until the product is restocked.
+	Triggered bool `json:"triggered"`
+}
+
+// UserThresholdRequest sets the caller's threshold for a product, or clears it
+// when Threshold is null.
+type UserThresholdRequest struct {
+	ProductId string `json:"productId"`
+	Threshold *int   `json:"threshold"`
+}
+
+type UserThresholdListResponse struct {
+	StatusCode int             `json:"statusCode"`
+	Thresholds []UserThreshold `json:"thresholds"`
+}
+
+type ReservationStatus string
+
+const (
+	// Held reservations count against the product's available stock.
+	ReservationHeld ReservationStatus = "held"
+	// Converted reservations have been sold to their holder.
+	ReservationConverted ReservationStatus = "converted"
+	// Released reservations were given up before they expired.
+	ReservationReleased ReservationStatus = "released"
+	// Expired reservations weren't converted or released in time.
+	ReservationExpired ReservationStatus = "expired"
+)
+
+// Reservation holds stock of a product for a user until it expires, so it
+// can't be sold to anyone else while they confirm a deal.
+type Reservation struct {
+	Id        string `json:"id"`
+	ProductId string `json:"productId"`
+	// The product's name when it was reserved, for telling the holder about the
+	// reservation after the product is deleted.
+	ProductName string `json:"productName,omitempty"`
+	// The user the stock is held for.
+	UserId   string            `json:"userId"`
+	Quantity int               `json:"quantity"`
+	Status   ReservationStatus `json:"status"`
+	// Free text such as the chat the deal was agreed in.
+	Reference string    `json:"reference"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// When the reservation stopped being held.
+	ClosedAt *time.Time `json:"closedAt,omitempty"`
+}
+
+type ReservationRequest struct {
+	ProductId string `json:"productId"`
+	Quantity  int    `json:"quantity"`
+	// Defaults to the configured reservation TTL from now.
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Reference string     `json:"reference"`
+}
+
+type ExtendReservationRequest struct {
+	Id        string    `json:"id"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ReservationListRequest selects the caller's reservations, or every
+// reservation on ProductId when the caller supplies it. Either can be narrowed
+// to one Status.
+type ReservationListRequest struct {
+	ProductId string            `json:"productId"`
+	Status    ReservationStatus `json:"status"`
+}
+
+type ReservationResponse struct {
+	StatusCode  int          `json:"statusCode"`
+	Reservation *Reservation `json:"reservation"`
+}
+
+type ReservationListResponse struct {
+	StatusCode   int           `json:"statusCode"`
+	Reservations []Reservation `json:"reservations"`
+}
+
+// StockLevel splits a product's stock on hand into what is held by
+// reservations and what is still available.
+type StockLevel struct {
+	ProductId string `json:"productId"`
+	OnHand    int    `json:"onHand"`
+	Reserved  int    `json:"reserved"`
+	Available int    `json:"available"`
+}
+
+type StockLevelResponse struct {
+	StatusCode int `json:"statusCode"`
+	StockLevel
+}
@@ -6,6 +6,43 @@ type User struct {
 	Password string `json:"password"`
 	Email    string `json:"email"`
 	Role     Role   `json:"role"`
+	Disabled bool   `json:"disabled,omitempty"`
+
+	OrganisationId string `json:"organisationId,omitempty"`
+
+	// Addresses the user has previously logged in from.
+	KnownAddresses []string `json:"knownAddresses,omitempty"`
+
+	TwoFactor *TwoFactor `json:"twoFactor,omitempty"`
+}
+
+// TwoFactor holds a user's time-based one-time password enrolment.
+type TwoFactor struct {
+	Secret string `json:"secret"`
+	// False until the user has proven they can generate codes for the secret.
+	Enabled bool `json:"enabled"`
+	// Hashes of the unused recovery codes.
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+	// The last time step a code was accepted for, so codes can't be replayed.
+	LastUsedStep int64 `json:"lastUsedStep,omitempty"`
+}
+
+func (u User) TwoFactorEnabled() bool {
+	return u.TwoFactor != nil && u.TwoFactor.Enabled
+}
+
+// Info returns the user without their credentials.
+func (u User) Info() UserInfo {
+	return UserInfo{
+		Id:       u.Id,
+		Username: u.Username,
+		Email:    u.Email,
+		Role:     u.Role,
+		Disabled: u.Disabled,
+
+		OrganisationId:   u.OrganisationId,
+		TwoFactorEnabled: u.TwoFactorEnabled(),
+	}
 }
 
 type UserInfo struct {
@@ -13,6 +50,10 @@ type UserInfo struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Role     Role   `json:"role"`
+	Disabled bool   `json:"disabled"`
+
+	OrganisationId   string `json:"organisationId"`
+	TwoFactorEnabled bool   `json:"twoFactorEnabled"`
 }
 
 type Role string
@@ -20,9 +61,59 @@ type Role string
 const (
 	Supplier Role = "supplier"
 	Customer Role = "customer"
+	Admin    Role = "admin"
 )
 
+func (r Role) IsValid() bool {
+	return r == Supplier || r == Customer || r == Admin
+}
+
+type UserListRequest struct {
+	// Only return users with this role, all roles are returned when empty.
+	Role Role `json:"role"`
+	PageRequest
+	// Deprecated: page with Cursor and Limit. Used when there's no Cursor or
+	// Limit, and every user is returned when PageSize isn't set either.
+	PageNumber int `json:"pageNumber"`
+	PageSize   int `json:"pageSize"`
+}
+
 type UserListResponse struct {
 	StatusCode int        `json:"statusCode"`
 	Users      []UserInfo `json:"users"`
+	PageInfo
+}
+
+type UserResponse struct {
+	StatusCode int       `json:"statusCode"`
+	User       *UserInfo `json:"user"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email"`
+	Role     Role   `json:"role"`
+
+	OrganisationId string `json:"organisationId"`
+}
+
+type UpdateUserRequest struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	// Left unchanged when empty.
+	Password string `json:"password"`
+	// Left unchanged when empty.
+	OrganisationId string `json:"organisationId"`
+}
+
+type SetUserDisabledRequest struct {
+	Id       string `json:"id"`
+	Disabled bool   `json:"disabled"`
+}
+
+type SetUserRoleRequest struct {
+	Id   string `json:"id"`
+	Role Role   `json:"role"`
 }